@@ -0,0 +1,92 @@
+// Command sse-client subscribes to the live event stream of a quiz and
+// prints every event, reconnecting when the server drops the connection.
+//
+//	go run ./cmd/sse-client -token <jwt> -quiz <quiz_id>
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+func main() {
+	baseURL := flag.String("url", "http://localhost:8080/api/v1", "API base URL")
+	token := flag.String("token", os.Getenv("QUIZMASTER_TOKEN"), "JWT token (defaults to $QUIZMASTER_TOKEN)")
+	quizID := flag.String("quiz", "", "Quiz ID to follow")
+	flag.Parse()
+
+	if *token == "" || *quizID == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	streamURL := fmt.Sprintf("%s/stream/quizzes/%s", strings.TrimRight(*baseURL, "/"), *quizID)
+	for {
+		err := follow(ctx, streamURL, *token)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Stream closed: %v, reconnecting in 2s", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+// follow reads one stream connection until it ends
+func follow(ctx context.Context, streamURL, token string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, streamURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var eventType, data string
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if eventType != "" || data != "" {
+				log.Printf("%-20s %s", eventType, data)
+			}
+			eventType, data = "", ""
+		case strings.HasPrefix(line, ":"):
+			// Keep-alive comment
+		case strings.HasPrefix(line, "event: "):
+			eventType = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("server ended the stream")
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -21,7 +22,22 @@ type Config struct {
 	JWTSecret         string
 	ServerPort        string
 	ExternalCourseAPI string
+	CourseAPI         CourseAPIConfig
 	LogDir            string
+	LeaderboardTTL    time.Duration
+	TermStart         *time.Time // Start of the current academic term, for term leaderboards
+}
+
+// CourseAPIConfig tunes calls to the external course API
+type CourseAPIConfig struct {
+	Timeout          time.Duration // Per request
+	MaxRetries       int           // Retries after a failed request, with jittered backoff
+	RetryBackoff     time.Duration // Backoff before the first retry, doubled on each retry
+	BreakerThreshold int           // Consecutive failed calls that open the circuit breaker
+	BreakerCooldown  time.Duration // How long the breaker stays open before a trial call
+	FailOpen         bool          // Treat courses as completed while the breaker is open
+	NegativeCacheTTL time.Duration // How long a course reported as not completed is remembered
+	WebhookSecret    string        // Signs completion webhooks, which are refused when empty
 }
 
 var AppConfig *Config
@@ -38,6 +54,18 @@ func LoadConfig() {
 		ServerPort:        getEnv("SERVER_PORT", "8080"),
 		ExternalCourseAPI: getEnv("EXTERNAL_COURSE_API", "http://localhost:9000/api/v1"),
 		LogDir:            getEnv("LOG_DIR", "var/logs"),
+		LeaderboardTTL:    getDurationEnv("LEADERBOARD_CACHE_TTL", 30*time.Second),
+		TermStart:         getDateEnv("TERM_START"),
+		CourseAPI: CourseAPIConfig{
+			Timeout:          getDurationEnv("COURSE_API_TIMEOUT", 3*time.Second),
+			MaxRetries:       getIntEnv("COURSE_API_MAX_RETRIES", 2),
+			RetryBackoff:     getDurationEnv("COURSE_API_RETRY_BACKOFF", 200*time.Millisecond),
+			BreakerThreshold: getIntEnv("COURSE_API_BREAKER_THRESHOLD", 5),
+			BreakerCooldown:  getDurationEnv("COURSE_API_BREAKER_COOLDOWN", 30*time.Second),
+			FailOpen:         getBoolEnv("COURSE_API_FAIL_OPEN", false),
+			NegativeCacheTTL: getDurationEnv("COURSE_NEGATIVE_CACHE_TTL", 5*time.Minute),
+			WebhookSecret:    getEnv("COURSE_WEBHOOK_SECRET", ""),
+		},
 	}
 }
 
@@ -49,6 +77,62 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid duration for %s: %q, using %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return duration
+}
+
+func getIntEnv(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 {
+		log.Printf("Invalid number for %s: %q, using %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return number
+}
+
+func getBoolEnv(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	flag, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid boolean for %s: %q, using %t", key, value, defaultValue)
+		return defaultValue
+	}
+	return flag
+}
+
+func getDateEnv(key string) *time.Time {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		log.Printf("Invalid date for %s: %q, expected YYYY-MM-DD", key, value)
+		return nil
+	}
+	return &date
+}
+
 // Database connection
 var DB *mongo.Database
 
@@ -0,0 +1,99 @@
+// Package config handles application configuration
+package config
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// collectionIndexes lists the indexes each collection needs
+var collectionIndexes = map[string][]mongo.IndexModel{
+	"attempts": {
+		// Leaderboard rebuilds and per-quiz attempt listings
+		{Keys: bson.D{{Key: "quiz_id", Value: 1}, {Key: "total_score", Value: -1}}},
+		{Keys: bson.D{{Key: "completed_at", Value: -1}}},
+		// Daily activity and performance stats of a student
+		{Keys: bson.D{{Key: "student_id", Value: 1}, {Key: "completed_at", Value: -1}}},
+		{Keys: bson.D{{Key: "session_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+	},
+	"achievements": {
+		{Keys: bson.D{{Key: "key", Value: 1}}, Options: options.Index().SetUnique(true)},
+	},
+	"user_achievements": {
+		// One award per badge and student
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "key", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "earned_at", Value: -1}}},
+	},
+	"challenges": {
+		{Keys: bson.D{{Key: "challenger_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "opponent_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "expires_at", Value: 1}}},
+	},
+	"live_sessions": {
+		// Join code lookups among running sessions
+		{Keys: bson.D{{Key: "join_code", Value: 1}, {Key: "status", Value: 1}}},
+	},
+	"quizzes": {
+		{
+			// Full-text search over quiz titles and descriptions
+			Keys: bson.D{{Key: "title", Value: "text"}, {Key: "description", Value: "text"}},
+		},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "attempt_count", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "average_score", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "title", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "course_id", Value: 1}}},
+		{Keys: bson.D{{Key: "creator_id", Value: 1}}},
+	},
+	"leaderboard_entries": {
+		{Keys: bson.D{{Key: "quiz_id", Value: 1}, {Key: "score", Value: -1}, {Key: "time_taken", Value: 1}}},
+		{Keys: bson.D{{Key: "quiz_id", Value: 1}, {Key: "student_id", Value: 1}, {Key: "score", Value: -1}}},
+		{Keys: bson.D{{Key: "completed_at", Value: -1}}},
+	},
+	"review_items": {
+		// One item per student and question, and the due items of a student
+		{
+			Keys:    bson.D{{Key: "student_id", Value: 1}, {Key: "quiz_id", Value: 1}, {Key: "question_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "student_id", Value: 1}, {Key: "due_at", Value: 1}}},
+	},
+	"course_completions": {
+		// One result per student and course
+		{
+			Keys:    bson.D{{Key: "student_id", Value: 1}, {Key: "course_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			// Drop results that a course was not completed once they expire
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	},
+	"idempotency_keys": {
+		{
+			// Expire stored responses after a day
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(24 * 60 * 60),
+		},
+	},
+}
+
+// EnsureIndexes creates the indexes required by the application
+func EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for name, indexes := range collectionIndexes {
+		if _, err := DB.Collection(name).Indexes().CreateMany(ctx, indexes); err != nil {
+			return fmt.Errorf("failed to create indexes on %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
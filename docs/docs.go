@@ -24,13 +24,62 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/attempts": {
+        "/achievements": {
             "get": {
+                "description": "List the achievements students can earn and their rules. Professors can include inactive ones with all=true",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "achievements"
+                ],
+                "summary": "List achievements",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Include inactive achievements (professors only)",
+                        "name": "all",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Achievement"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
-                ],
+                ]
+            }
+        },
+        "/attempts": {
+            "get": {
                 "description": "Get all quiz attempts by the authenticated student",
                 "consumes": [
                     "application/json"
@@ -70,16 +119,16 @@ const docTemplate = `{
                             }
                         }
                     }
-                }
-            }
-        },
-        "/attempts/answer": {
-            "post": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
-                ],
+                ]
+            }
+        },
+        "/attempts/answer": {
+            "post": {
                 "description": "Submit an answer for a specific question in an ongoing attempt",
                 "consumes": [
                     "application/json"
@@ -128,6 +177,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -137,16 +195,16 @@ const docTemplate = `{
                             }
                         }
                     }
-                }
-            }
-        },
-        "/attempts/complete": {
-            "put": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
-                ],
+                ]
+            }
+        },
+        "/attempts/complete": {
+            "put": {
                 "description": "Mark a quiz attempt as complete and calculate final score",
                 "consumes": [
                     "application/json"
@@ -202,18 +260,27 @@ const docTemplate = `{
                                 "type": "string"
                             }
                         }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
-                }
-            }
-        },
-        "/attempts/start": {
-            "post": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
-                ],
-                "description": "Start attempting a quiz (students only, requires course completion)",
+                ]
+            }
+        },
+        "/attempts/start": {
+            "post": {
+                "description": "Start attempting a quiz (students only, requires course completion). Practice attempts need the quiz to allow practice, skip the course check if the quiz allows it, reveal the correct answer after each answer (when the quiz review policy is immediately) and never count towards leaderboards",
                 "consumes": [
                     "application/json"
                 ],
@@ -287,16 +354,16 @@ const docTemplate = `{
                             }
                         }
                     }
-                }
-            }
-        },
-        "/attempts/{id}": {
-            "get": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
-                ],
+                ]
+            }
+        },
+        "/attempts/{id}": {
+            "get": {
                 "description": "Get details of a specific quiz attempt",
                 "consumes": [
                     "application/json"
@@ -351,12 +418,17 @@ const docTemplate = `{
                             }
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
             }
         },
-        "/auth/login": {
+        "/attempts/{id}/assist": {
             "post": {
-                "description": "Authenticate user and get JWT token",
+                "description": "Reveal the next hint of a question, remove half of the options, all wrong ones (fifty_fifty) or skip a question during an in-progress attempt. Penalties configured on the quiz are deducted from the question's points",
                 "consumes": [
                     "application/json"
                 ],
@@ -364,17 +436,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "attempts"
                 ],
-                "summary": "Login user",
+                "summary": "Use a hint or lifeline",
                 "parameters": [
                     {
-                        "description": "Login credentials",
+                        "type": "string",
+                        "description": "Attempt ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Question and assist type",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.LoginRequest"
+                            "$ref": "#/definitions/handlers.UseAssistRequest"
                         }
                     }
                 ],
@@ -382,7 +461,8 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.LoginResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -402,39 +482,58 @@ const docTemplate = `{
                                 "type": "string"
                             }
                         }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
             }
         },
-        "/auth/register": {
+        "/attempts/{id}/next": {
             "post": {
-                "description": "Register a new user account (student or professor)",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Serve the next question of an adaptive attempt, the unseen question of the pool whose difficulty best matches the current ability estimate. The served question is returned again until it is answered or skipped; done is true once the attempt has seen all its questions",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "attempts"
                 ],
-                "summary": "Register a new user",
+                "summary": "Get the next adaptive question",
                 "parameters": [
                     {
-                        "description": "Registration details",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.RegisterRequest"
-                        }
+                        "type": "string",
+                        "description": "Attempt ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -446,8 +545,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "409": {
-                        "description": "Conflict",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -455,49 +554,17 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
-                    }
-                }
-            }
-        },
-        "/leaderboards/global": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Get the top performing students across all quizzes (top 50)",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "leaderboards"
-                ],
-                "summary": "Get global leaderboard",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "object",
-                                "additionalProperties": true
-                            }
-                        }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -505,8 +572,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -514,17 +581,17 @@ const docTemplate = `{
                             }
                         }
                     }
-                }
-            }
-        },
-        "/leaderboards/quiz/{quiz_id}": {
-            "get": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
-                ],
-                "description": "Get the leaderboard for a specific quiz, showing rankings of all students",
+                ]
+            }
+        },
+        "/attempts/{id}/review": {
+            "get": {
+                "description": "Get each question of a completed attempt with the student's answer, and the correct answer and explanation when the quiz review policy allows it",
                 "consumes": [
                     "application/json"
                 ],
@@ -532,14 +599,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "leaderboards"
+                    "attempts"
                 ],
-                "summary": "Get quiz leaderboard",
+                "summary": "Review a completed attempt",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Quiz ID",
-                        "name": "quiz_id",
+                        "description": "Attempt ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
@@ -548,10 +615,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.LeaderboardEntry"
-                            }
+                            "$ref": "#/definitions/models.AttemptReview"
                         }
                     },
                     "400": {
@@ -572,8 +636,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -581,17 +645,17 @@ const docTemplate = `{
                             }
                         }
                     }
-                }
-            }
-        },
-        "/leaderboards/quiz/{quiz_id}/my-rank": {
-            "get": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
-                ],
-                "description": "Get the authenticated student's rank for a specific quiz",
+                ]
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "Authenticate user and get JWT token",
                 "consumes": [
                     "application/json"
                 ],
@@ -599,24 +663,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "leaderboards"
+                    "auth"
                 ],
-                "summary": "Get my rank",
+                "summary": "Login user",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Quiz ID",
-                        "name": "quiz_id",
-                        "in": "path",
-                        "required": true
+                        "description": "Login credentials",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LoginRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.LoginResponse"
                         }
                     },
                     "400": {
@@ -636,36 +701,13 @@ const docTemplate = `{
                                 "type": "string"
                             }
                         }
-                    },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
                     }
                 }
             }
         },
-        "/quizzes": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Get a list of quizzes with optional filters (category, difficulty, status)",
+        "/auth/register": {
+            "post": {
+                "description": "Register a new user account (student or professor)",
                 "consumes": [
                     "application/json"
                 ],
@@ -673,51 +715,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "quizzes"
+                    "auth"
                 ],
-                "summary": "List quizzes",
+                "summary": "Register a new user",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Filter by category",
-                        "name": "category",
-                        "in": "query"
-                    },
-                    {
-                        "enum": [
-                            "easy",
-                            "medium",
-                            "hard"
-                        ],
-                        "type": "string",
-                        "description": "Filter by difficulty level",
-                        "name": "difficulty",
-                        "in": "query"
-                    },
-                    {
-                        "enum": [
-                            "pending",
-                            "approved",
-                            "rejected"
-                        ],
-                        "type": "string",
-                        "description": "Filter by status (professors only)",
-                        "name": "status",
-                        "in": "query"
+                        "description": "Registration details",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RegisterRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Quiz"
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -735,40 +764,48 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Create a new quiz. Professors create approved quizzes, students create pending quizzes",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/challenges": {
+            "get": {
+                "description": "List the challenges the current student sent or received, newest first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "quizzes"
+                    "challenges"
                 ],
-                "summary": "Create a new quiz",
+                "summary": "List my challenges",
                 "parameters": [
                     {
-                        "description": "Quiz details",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.CreateQuizRequest"
-                        }
+                        "enum": [
+                            "pending",
+                            "active",
+                            "finished",
+                            "declined",
+                            "expired"
+                        ],
+                        "type": "string",
+                        "description": "Filter by status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Number of challenges (max 100)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Quiz"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.ChallengeView"
+                            }
                         }
                     },
                     "400": {
@@ -798,17 +835,15 @@ const docTemplate = `{
                             }
                         }
                     }
-                }
-            }
-        },
-        "/quizzes/{id}": {
-            "get": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
-                ],
-                "description": "Get detailed information about a specific quiz",
+                ]
+            },
+            "post": {
+                "description": "Challenge another student to a quiz. The challenge stays pending until the opponent accepts it, and must be played before it expires",
                 "consumes": [
                     "application/json"
                 ],
@@ -816,23 +851,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "quizzes"
+                    "challenges"
                 ],
-                "summary": "Get quiz by ID",
+                "summary": "Challenge a classmate",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Quiz ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Quiz and opponent",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateChallengeRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.Quiz"
+                            "$ref": "#/definitions/handlers.ChallengeView"
                         }
                     },
                     "400": {
@@ -870,30 +907,38 @@ const docTemplate = `{
                                 "type": "string"
                             }
                         }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
-                }
-            },
-            "delete": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
-                ],
-                "description": "Delete a quiz (creator or professor only)",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/challenges/{id}": {
+            "get": {
+                "description": "Get one of the current student's challenges with its result",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "quizzes"
+                    "challenges"
                 ],
-                "summary": "Delete a quiz",
+                "summary": "Get a challenge",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Quiz ID",
+                        "description": "Challenge ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -903,10 +948,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/handlers.ChallengeView"
                         }
                     },
                     "400": {
@@ -927,15 +969,6 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -945,42 +978,39 @@ const docTemplate = `{
                             }
                         }
                     }
-                }
-            }
-        },
-        "/quizzes/{id}/{action}": {
-            "put": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
-                ],
-                "description": "Approve or reject a pending quiz (professors only) using URL parameter",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/challenges/{id}/{action}": {
+            "put": {
+                "description": "Accept or decline a pending challenge (opponent only). Once accepted, both students start their attempt with the challenge_id",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "quizzes"
+                    "challenges"
                 ],
-                "summary": "Approve or reject a quiz",
+                "summary": "Accept or decline a challenge",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Quiz ID",
+                        "description": "Challenge ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
                         "enum": [
-                            "approve",
-                            "reject"
+                            "accept",
+                            "decline"
                         ],
                         "type": "string",
-                        "description": "Action (approve or reject)",
+                        "description": "Action",
                         "name": "action",
                         "in": "path",
                         "required": true
@@ -990,10 +1020,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/handlers.ChallengeView"
                         }
                     },
                     "400": {
@@ -1014,15 +1041,6 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -1032,32 +1050,108 @@ const docTemplate = `{
                             }
                         }
                     }
-                }
-            }
-        },
-        "/users/profile": {
-            "get": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
-                ],
-                "description": "Get the profile of the authenticated user",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/leaderboards/global": {
+            "get": {
+                "description": "Get the top performing students across quizzes, optionally scoped to a period, category, difficulty or course. Students with the same score share a rank",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "leaderboards"
+                ],
+                "summary": "Get global leaderboard",
+                "parameters": [
+                    {
+                        "enum": [
+                            "all",
+                            "daily",
+                            "weekly",
+                            "monthly",
+                            "term"
+                        ],
+                        "type": "string",
+                        "default": "all",
+                        "description": "Time window, term starts at TERM_START",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only count quizzes of this category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "easy",
+                            "medium",
+                            "hard"
+                        ],
+                        "type": "string",
+                        "description": "Only count quizzes of this difficulty",
+                        "name": "difficulty",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only count quizzes of this course",
+                        "name": "course_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Minimum completed attempts to be ranked",
+                        "name": "min_attempts",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "average",
+                            "total",
+                            "weighted",
+                            "bayesian"
+                        ],
+                        "type": "string",
+                        "default": "weighted",
+                        "description": "Ranking metric",
+                        "name": "metric",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Number of students (max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get user profile",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "401": {
@@ -1069,8 +1163,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -1078,538 +1172,5632 @@ const docTemplate = `{
                             }
                         }
                     }
-                }
-            }
-        }
-    },
-    "definitions": {
-        "handlers.CompleteAttemptRequest": {
-            "type": "object",
-            "required": [
-                "id"
-            ],
-            "properties": {
-                "id": {
-                    "type": "string",
-                    "example": "507f1f77bcf86cd799439011"
-                }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
             }
         },
-        "handlers.CreateQuestionRequest": {
-            "type": "object",
-            "required": [
-                "correct_answer",
-                "points",
-                "question_text",
-                "type"
-            ],
-            "properties": {
-                "correct_answer": {
-                    "type": "string",
-                    "example": "true"
-                },
-                "options": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
+        "/leaderboards/quiz/{quiz_id}": {
+            "get": {
+                "description": "Get a page of the leaderboard for a specific quiz. Attempts with the same score share a rank; ties are ordered by time taken",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "leaderboards"
+                ],
+                "summary": "Get quiz leaderboard",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "quiz_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "standard",
+                            "dense"
+                        ],
+                        "type": "string",
+                        "default": "standard",
+                        "description": "Tie handling: standard (1,1,3) or dense (1,1,2)",
+                        "name": "ranking",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of entries to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size (max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.QuizLeaderboardPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
                 },
-                "points": {
-                    "type": "integer",
-                    "minimum": 1,
-                    "example": 10
-                },
-                "question_text": {
-                    "type": "string",
-                    "example": "Is Go a statically typed language?"
-                },
-                "type": {
-                    "enum": [
-                        "true_false",
-                        "multiple_choice"
-                    ],
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/models.QuestionType"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/leaderboards/quiz/{quiz_id}/my-rank": {
+            "get": {
+                "description": "Get the authenticated student's rank for a specific quiz",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "leaderboards"
+                ],
+                "summary": "Get my rank",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "quiz_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "standard",
+                            "dense"
+                        ],
+                        "type": "string",
+                        "default": "standard",
+                        "description": "Tie handling: standard (1,1,3) or dense (1,1,2)",
+                        "name": "ranking",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
-                    ],
-                    "example": "true_false"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/manage/achievements/{key}": {
+            "put": {
+                "description": "Create or replace the achievement with the given key (professors only). Rules are evaluated whenever an attempt is completed; badges already awarded are kept",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "achievements"
+                ],
+                "summary": "Create or update an achievement",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Achievement key, a lowercase slug",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Achievement definition",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpsertAchievementRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Achievement"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/manage/attempts": {
+            "get": {
+                "description": "List attempts on the professor's quizzes, filtered by quiz and/or student (professors only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attempt-management"
+                ],
+                "summary": "List student attempts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by quiz ID",
+                        "name": "quiz_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by student ID",
+                        "name": "student_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.QuizAttempt"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/manage/attempts/{id}": {
+            "get": {
+                "description": "View a student's attempt with answers, correct answers and audit trail (professors only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attempt-management"
+                ],
+                "summary": "View a student attempt",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Attempt ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/manage/attempts/{id}/answers/{question_id}": {
+            "put": {
+                "description": "Manually set the points of one answer in a completed attempt, with a reason. Points can go up to the most the question scores under the attempt's scoring policy, bonuses included (professors only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attempt-management"
+                ],
+                "summary": "Override an answer's points",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Attempt ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Question ID",
+                        "name": "question_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New points and reason",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.OverrideScoreRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.QuizAttempt"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/manage/attempts/{id}/invalidate": {
+            "put": {
+                "description": "Invalidate an attempt, e.g. for cheating, removing it from stats and leaderboards. The XP and achievements it earned are taken back, and an attempt played for a challenge forfeits it (professors only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attempt-management"
+                ],
+                "summary": "Invalidate an attempt",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Attempt ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reason for invalidation",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.InvalidateAttemptRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.QuizAttempt"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/manage/courses/{course_id}/completions/{student_id}/{action}": {
+            "put": {
+                "description": "Manually grant or revoke the completion of a course by a student (professors only), e.g. to fix a wrong result of the course platform. The manual result overrides the course API and completion webhooks until changed again, and is recorded in the audit trail",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "courses"
+                ],
+                "summary": "Grant or revoke a course completion",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "External course ID",
+                        "name": "course_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Student ID",
+                        "name": "student_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "grant or revoke",
+                        "name": "action",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reason",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateCompletionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CourseCompletion"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/manage/courses/{course_id}/gradebook": {
+            "get": {
+                "description": "Export the students × quizzes score matrix of a course (professors only), covering the approved quizzes of the course the professor created or approved. The score of each quiz is the best, last or average score of the student's completed, non invalidated attempts; missing quizzes count as 0 in the total. The export is streamed",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "attempt-management"
+                ],
+                "summary": "Export a course gradebook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "External course ID",
+                        "name": "course_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "xlsx",
+                            "json"
+                        ],
+                        "type": "string",
+                        "default": "csv",
+                        "description": "Export format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "best",
+                            "last",
+                            "average"
+                        ],
+                        "type": "string",
+                        "default": "best",
+                        "description": "Score selected per quiz",
+                        "name": "rule",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/manage/quizzes/{quiz_id}/calibrate": {
+            "post": {
+                "description": "Estimate the Rasch difficulty of each question of an adaptive quiz from the answers of its completed attempts, and use it to select questions from now on (professors only). Questions with fewer than 10 answers keep their previous difficulty, or the default of the quiz's difficulty level",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attempt-management"
+                ],
+                "summary": "Calibrate adaptive question difficulties",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "quiz_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.CalibrationReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/manage/quizzes/{quiz_id}/item-analysis": {
+            "get": {
+                "description": "Per question statistics computed from the completed, non invalidated attempts of a quiz (professors only): percent correct, option distribution for multiple choice, average time to answer, discrimination index (top vs bottom 27%) and point-biserial correlation, plus the quiz's Cronbach's alpha. Questions with suspicious statistics are flagged once the quiz has 10 attempts",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attempt-management"
+                ],
+                "summary": "Get quiz item analysis",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "quiz_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.ItemAnalysis"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/manage/quizzes/{quiz_id}/leaderboard/rebuild": {
+            "post": {
+                "description": "Recompute the materialized leaderboard of a quiz from its attempts, e.g. after a failed update (professors only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attempt-management"
+                ],
+                "summary": "Rebuild a quiz leaderboard",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "quiz_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/manage/quizzes/{quiz_id}/leaderboard/verify": {
+            "get": {
+                "description": "Compare the materialized leaderboard of a quiz with a full recomputation from its attempts (professors only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attempt-management"
+                ],
+                "summary": "Verify a quiz leaderboard",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "quiz_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.LeaderboardVerification"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/manage/quizzes/{quiz_id}/practice": {
+            "put": {
+                "description": "Allow or forbid practice attempts of a quiz, and choose whether they skip the course completion check (professors only). Practice attempts never count towards leaderboards. Practice can only be enabled on quizzes whose review policy is immediately, since it shows the correct answer after each answer",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attempt-management"
+                ],
+                "summary": "Update quiz practice settings",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "quiz_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Practice settings",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PracticeSettings"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PracticeSettings"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/manage/quizzes/{quiz_id}/questions/{question_id}/answer": {
+            "put": {
+                "description": "Fix the correct answer of a question of one of the professor's quizzes, e.g. when the wrong option was marked correct (professors only). Attempts keep their scores until the quiz is regraded. The correction is recorded in the audit trail",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attempt-management"
+                ],
+                "summary": "Correct a question's answer key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "quiz_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Question ID",
+                        "name": "question_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Corrected answer",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CorrectAnswerKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Question"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/manage/quizzes/{quiz_id}/regrade": {
+            "post": {
+                "description": "Re-evaluate every completed attempt of a quiz against its current answer key. Manually overridden answers keep their points (professors only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attempt-management"
+                ],
+                "summary": "Regrade a quiz",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "quiz_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/quizzes": {
+            "get": {
+                "description": "Get a page of quizzes with optional filters, full-text search and sorting. The cursor of the next page is returned in the X-Next-Cursor header when more results exist",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quizzes"
+                ],
+                "summary": "List quizzes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "easy",
+                            "medium",
+                            "hard"
+                        ],
+                        "type": "string",
+                        "description": "Filter by difficulty level",
+                        "name": "difficulty",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "approved",
+                            "rejected"
+                        ],
+                        "type": "string",
+                        "description": "Filter by status (professors only)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by course ID",
+                        "name": "course_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by creator ID",
+                        "name": "creator_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Full-text search over title and description",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "newest",
+                            "popularity",
+                            "average_score",
+                            "title"
+                        ],
+                        "type": "string",
+                        "default": "newest",
+                        "description": "Sort order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "full",
+                            "summary"
+                        ],
+                        "type": "string",
+                        "default": "full",
+                        "description": "Response shape, summary leaves out questions. Students always get summaries",
+                        "name": "view",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size (max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the X-Next-Cursor header of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Quiz"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page, absent on the last page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Create a new quiz. Professors create approved quizzes, students create pending quizzes",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quizzes"
+                ],
+                "summary": "Create a new quiz",
+                "parameters": [
+                    {
+                        "description": "Quiz details",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateQuizRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Quiz"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/quizzes/{id}": {
+            "get": {
+                "description": "Get a quiz. Professors and the quiz creator get the full quiz, other students a summary without questions",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quizzes"
+                ],
+                "summary": "Get quiz by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Quiz"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Delete a quiz (creator or professor only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quizzes"
+                ],
+                "summary": "Delete a quiz",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/quizzes/{id}/{action}": {
+            "put": {
+                "description": "Approve or reject a pending quiz (professors only) using URL parameter",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quizzes"
+                ],
+                "summary": "Approve or reject a quiz",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "approve",
+                            "reject"
+                        ],
+                        "type": "string",
+                        "description": "Action (approve or reject)",
+                        "name": "action",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/review/items/{id}/grade": {
+            "post": {
+                "description": "Answer a due review item. The answer is checked and the item rescheduled with the SM-2 algorithm: a correct answer is graded with the given quality (3 to 5, default 4) and comes back after 1 day, then 6 days, then a growing interval; a wrong answer is graded 1 and comes back the next day. The correct answer and explanation are returned, so items of quizzes whose review policy does not reveal answers yet are rejected with 403. Supports the Idempotency-Key header",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "review"
+                ],
+                "summary": "Grade a review item",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Review item ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Answer",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.GradeReviewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/review/today": {
+            "get": {
+                "description": "Practice session of the questions the current student missed in completed attempts that are due for review by the end of their day (in their progress timezone), most overdue first. Questions are returned without their answer. Reviews are practice only and never affect leaderboards",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "review"
+                ],
+                "summary": "Get today's review session",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Items in the session (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.ReviewSession"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/sessions": {
+            "post": {
+                "description": "Open a live session of an approved quiz in the lobby state and get its join code (professors only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Open a live session",
+                "parameters": [
+                    {
+                        "description": "Quiz to host",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateSessionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LiveSessionView"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/sessions/join": {
+            "post": {
+                "description": "Join a running live session with its join code (students only, requires course completion). Students may join late; questions already closed count as unanswered",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Join a live session",
+                "parameters": [
+                    {
+                        "description": "Join code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.JoinSessionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LiveSessionView"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/sessions/{id}": {
+            "get": {
+                "description": "Get the state of a live session and its current question. Students only see the correct answer to a closed question if the quiz review policy reveals answers, and never see hints. Getting a finished session whose attempts were not all recorded resumes recording them",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Get a live session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LiveSessionView"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Cancel a running live session (host only). Its attempts are discarded and never reach the leaderboards",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Cancel a live session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/sessions/{id}/advance": {
+            "post": {
+                "description": "Move a live session to its next state (host only): the lobby or the standings open the next question, an open question closes and shows the standings, and closing the last question finishes the session and records every attempt",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Advance a live session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LiveSessionView"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/sessions/{id}/answer": {
+            "post": {
+                "description": "Answer the question currently open in a live session (participants only). The time to answer is measured by the server from the moment the question opened",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Answer the open question",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Answer",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SessionAnswerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/sessions/{id}/end": {
+            "post": {
+                "description": "Finish a running live session before its last question (host only). Attempts are recorded with the questions answered so far",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "End a live session early",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LiveSessionView"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/sessions/{id}/leaderboard": {
+            "get": {
+                "description": "Get the standings of a live session, ranked by score with equal scores sharing a rank. While a question is open, participants get the standings without that question; the host sees them live",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Get live session standings",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.LiveStanding"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/stream/quizzes/{quiz_id}": {
+            "get": {
+                "description": "Server-Sent Events stream of a quiz: attempts started and completed (with the new rank), leaderboard changes and quiz status changes. Browser clients may pass the JWT as the access_token query parameter. A \"lagged\" event is sent before closing the stream when the client falls behind; reconnect and refetch the leaderboard",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "streams"
+                ],
+                "summary": "Stream live quiz events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Quiz ID",
+                        "name": "quiz_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "JWT, for clients that cannot set the Authorization header",
+                        "name": "access_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.Event"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/stream/sessions/{session_id}": {
+            "get": {
+                "description": "Server-Sent Events stream of a live session for its host and participants: participants joining, questions opening and closing (with the correct answer and standings), answer counts, and the session finishing or being cancelled. Browser clients may pass the JWT as the access_token query parameter",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "streams"
+                ],
+                "summary": "Stream live session events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "JWT, for clients that cannot set the Authorization header",
+                        "name": "access_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.Event"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/users/me/progress": {
+            "get": {
+                "description": "Daily activity streak and weekly goal progress of the current student, computed from completed attempts with days in the student's timezone. Missed days are covered automatically by freeze tokens when that keeps the streak alive; a token is earned for each week the goal is met (max 2)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "progress"
+                ],
+                "summary": "Get my streak and weekly goal progress",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.ProgressReport"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Set the timezone used for day boundaries and the weekly goal of the current student",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "progress"
+                ],
+                "summary": "Update my progress settings",
+                "parameters": [
+                    {
+                        "description": "Settings to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateProgressSettingsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.StudentProgress"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/users/me/stats": {
+            "get": {
+                "description": "Progress dashboard of the current student over their counted attempts: overall, per category and per difficulty attempts, average percentage, accuracy and response time, a weekly trend, accuracy by question type and the weakest category and difficulty combinations",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "progress"
+                ],
+                "summary": "Get my performance stats",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Weeks covered by the trend (default 12, max 52)",
+                        "name": "weeks",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.StudentStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/users/profile": {
+            "get": {
+                "description": "Get the profile of the authenticated user, with their XP level and earned achievements",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user profile",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UserProfile"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/users/{id}/achievements": {
+            "get": {
+                "description": "Get the XP level and earned achievements of a user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "achievements"
+                ],
+                "summary": "Get a user's achievements",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UserAchievements"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/webhooks/course-completions": {
+            "post": {
+                "description": "Webhook for the course platform to push completion events, in the format of the course API completion response. The request must carry a Unix timestamp in X-Course-Timestamp, at most 5 minutes old, and the hex HMAC-SHA256 of \"\u003ctimestamp\u003e.\u003cbody\u003e\" with the shared secret in X-Course-Signature (optionally prefixed with \"sha256=\"). Completions are stored for good, unless a professor set the completion manually; events that are not completions are ignored",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "courses"
+                ],
+                "summary": "Receive a course completion",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Unix timestamp of the event",
+                        "name": "X-Course-Timestamp",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "HMAC-SHA256 signature",
+                        "name": "X-Course-Signature",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Completion event",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.CourseCompletionResponse"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handlers.ChallengeView": {
+            "type": "object",
+            "properties": {
+                "accepted_at": {
+                    "type": "string"
+                },
+                "challenger_attempt_id": {
+                    "type": "string"
+                },
+                "challenger_id": {
+                    "type": "string"
+                },
+                "challenger_name": {
+                    "type": "string"
+                },
+                "challenger_result": {
+                    "$ref": "#/definitions/models.ChallengeResult"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "opponent_attempt_id": {
+                    "type": "string"
+                },
+                "opponent_id": {
+                    "type": "string"
+                },
+                "opponent_name": {
+                    "type": "string"
+                },
+                "opponent_result": {
+                    "$ref": "#/definitions/models.ChallengeResult"
+                },
+                "outcome": {
+                    "enum": [
+                        "win",
+                        "loss",
+                        "draw"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ChallengeOutcome"
+                        }
+                    ]
+                },
+                "quiz_id": {
+                    "type": "string"
+                },
+                "quiz_title": {
+                    "type": "string"
+                },
+                "result_reason": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.ChallengeStatus"
+                },
+                "winner_id": {
+                    "description": "Unset on a draw",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CompleteAttemptRequest": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                }
+            }
+        },
+        "handlers.CorrectAnswerKeyRequest": {
+            "type": "object",
+            "required": [
+                "correct_answer",
+                "reason"
+            ],
+            "properties": {
+                "correct_answer": {
+                    "description": "\"true\"/\"false\", or the option index",
+                    "type": "string",
+                    "example": "1"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "Option 2 was marked correct by mistake"
+                }
+            }
+        },
+        "handlers.CreateChallengeRequest": {
+            "type": "object",
+            "required": [
+                "opponent_id",
+                "quiz_id"
+            ],
+            "properties": {
+                "expires_in_hours": {
+                    "description": "Default 72, max 168",
+                    "type": "integer",
+                    "example": 72
+                },
+                "opponent_id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439013"
+                },
+                "quiz_id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                }
+            }
+        },
+        "handlers.CreateQuestionRequest": {
+            "type": "object",
+            "required": [
+                "correct_answer",
+                "points",
+                "question_text",
+                "type"
+            ],
+            "properties": {
+                "correct_answer": {
+                    "type": "string",
+                    "example": "true"
+                },
+                "explanation": {
+                    "type": "string",
+                    "example": "Go checks types at compile time."
+                },
+                "hints": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Hint"
+                    }
+                },
+                "options": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "points": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 10
+                },
+                "question_text": {
+                    "type": "string",
+                    "example": "Is Go a statically typed language?"
+                },
+                "type": {
+                    "enum": [
+                        "true_false",
+                        "multiple_choice"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.QuestionType"
+                        }
+                    ],
+                    "example": "true_false"
+                }
+            }
+        },
+        "handlers.CreateQuizRequest": {
+            "type": "object",
+            "required": [
+                "category",
+                "course_id",
+                "difficulty_level",
+                "questions",
+                "title"
+            ],
+            "properties": {
+                "adaptive": {
+                    "description": "Questions become a pool served by ability",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.AdaptiveSettings"
+                        }
+                    ]
+                },
+                "category": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.QuizCategory"
+                        }
+                    ],
+                    "example": "programming"
+                },
+                "closes_at": {
+                    "type": "string",
+                    "example": "2024-06-30T23:59:00Z"
+                },
+                "course_id": {
+                    "type": "string",
+                    "example": "course123"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Basic concepts of Go programming language"
+                },
+                "difficulty_level": {
+                    "enum": [
+                        "easy",
+                        "medium",
+                        "hard"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.DifficultyLevel"
+                        }
+                    ],
+                    "example": "easy"
+                },
+                "lifelines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Lifeline"
+                    }
+                },
+                "practice": {
+                    "description": "Allows unranked practice attempts",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.PracticeSettings"
+                        }
+                    ]
+                },
+                "questions": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/handlers.CreateQuestionRequest"
+                    }
+                },
+                "review_policy": {
+                    "enum": [
+                        "immediately",
+                        "after_close",
+                        "never"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ReviewPolicy"
+                        }
+                    ],
+                    "example": "immediately"
+                },
+                "scoring": {
+                    "$ref": "#/definitions/models.ScoringPolicy"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Introduction to Go Programming"
+                }
+            }
+        },
+        "handlers.CreateSessionRequest": {
+            "type": "object",
+            "required": [
+                "quiz_id"
+            ],
+            "properties": {
+                "quiz_id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                }
+            }
+        },
+        "handlers.GradeReviewRequest": {
+            "type": "object",
+            "required": [
+                "answer"
+            ],
+            "properties": {
+                "answer": {
+                    "type": "string",
+                    "example": "1"
+                },
+                "quality": {
+                    "description": "How easy a correct answer felt: 3 hard, 4 good (default), 5 easy",
+                    "type": "integer",
+                    "example": 4
+                }
+            }
+        },
+        "handlers.InvalidateAttemptRequest": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "example": "Answers shared during the exam"
+                }
+            }
+        },
+        "handlers.JoinSessionRequest": {
+            "type": "object",
+            "required": [
+                "join_code"
+            ],
+            "properties": {
+                "join_code": {
+                    "type": "string",
+                    "example": "K7QX2M"
+                }
+            }
+        },
+        "handlers.LiveQuestion": {
+            "type": "object",
+            "properties": {
+                "closes_at": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "question": {
+                    "$ref": "#/definitions/models.Question"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.LiveSessionView": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "current": {
+                    "$ref": "#/definitions/handlers.LiveQuestion"
+                },
+                "current_question": {
+                    "description": "Index into the quiz questions, -1 before the first",
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "host_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "join_code": {
+                    "type": "string"
+                },
+                "participants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LiveParticipant"
+                    }
+                },
+                "question_started_at": {
+                    "type": "string"
+                },
+                "quiz_id": {
+                    "type": "string"
+                },
+                "quiz_title": {
+                    "type": "string"
+                },
+                "recorded_at": {
+                    "description": "Set once every attempt of a finished session is recorded",
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.LiveSessionStatus"
+                }
+            }
+        },
+        "handlers.LiveStanding": {
+            "type": "object",
+            "properties": {
+                "answered_count": {
+                    "type": "integer"
+                },
+                "correct_count": {
+                    "type": "integer"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "student_id": {
+                    "type": "string"
+                },
+                "student_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.LoginRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "student@example.com"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "password123"
+                }
+            }
+        },
+        "handlers.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
+                }
+            }
+        },
+        "handlers.OverrideScoreRequest": {
+            "type": "object",
+            "required": [
+                "points",
+                "reason"
+            ],
+            "properties": {
+                "points": {
+                    "type": "number",
+                    "minimum": 0,
+                    "example": 7.5
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "Accepted alternative wording"
+                }
+            }
+        },
+        "handlers.QuizLeaderboardPage": {
+            "type": "object",
+            "properties": {
+                "leaderboard": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LeaderboardEntry"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next_offset": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "quiz_id": {
+                    "type": "string"
+                },
+                "ranking": {
+                    "type": "string"
+                },
+                "total_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.RegisterRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "first_name",
+                "last_name",
+                "password",
+                "role"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "student@example.com"
+                },
+                "first_name": {
+                    "type": "string",
+                    "example": "John"
+                },
+                "last_name": {
+                    "type": "string",
+                    "example": "Doe"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 6,
+                    "example": "password123"
+                },
+                "role": {
+                    "enum": [
+                        "student",
+                        "professor"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.UserRole"
+                        }
+                    ],
+                    "example": "student"
+                }
+            }
+        },
+        "handlers.SessionAnswerRequest": {
+            "type": "object",
+            "required": [
+                "answer"
+            ],
+            "properties": {
+                "answer": {
+                    "type": "string",
+                    "example": "true"
+                }
+            }
+        },
+        "handlers.StartAttemptRequest": {
+            "type": "object",
+            "required": [
+                "quiz_id"
+            ],
+            "properties": {
+                "challenge_id": {
+                    "description": "Plays an accepted challenge",
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439014"
+                },
+                "mode": {
+                    "description": "Default ranked; practice must be enabled on the quiz",
+                    "enum": [
+                        "ranked",
+                        "practice"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.AttemptMode"
+                        }
+                    ],
+                    "example": "ranked"
+                },
+                "quiz_id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                }
+            }
+        },
+        "handlers.SubmitAnswerRequest": {
+            "type": "object",
+            "required": [
+                "answer",
+                "attempt_id",
+                "question_id",
+                "time_to_answer"
+            ],
+            "properties": {
+                "answer": {
+                    "type": "string",
+                    "example": "true"
+                },
+                "attempt_id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "question_id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439012"
+                },
+                "time_to_answer": {
+                    "description": "In seconds",
+                    "type": "integer",
+                    "example": 8
+                }
+            }
+        },
+        "handlers.UpdateCompletionRequest": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "example": "Completed the course before the platform migration"
+                }
+            }
+        },
+        "handlers.UpdateProgressSettingsRequest": {
+            "type": "object",
+            "properties": {
+                "timezone": {
+                    "description": "IANA timezone name",
+                    "type": "string",
+                    "example": "Europe/Paris"
+                },
+                "weekly_goal": {
+                    "description": "Quizzes per week, 1 to 50",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "handlers.UpsertAchievementRequest": {
+            "type": "object",
+            "required": [
+                "description",
+                "name"
+            ],
+            "properties": {
+                "active": {
+                    "description": "Defaults to true",
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Complete 5 hard science quizzes"
+                },
+                "icon": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Science Buff"
+                },
+                "rule": {
+                    "$ref": "#/definitions/models.AchievementRule"
+                },
+                "xp": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 80
+                }
+            }
+        },
+        "handlers.UseAssistRequest": {
+            "type": "object",
+            "required": [
+                "question_id",
+                "type"
+            ],
+            "properties": {
+                "question_id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439012"
+                },
+                "type": {
+                    "enum": [
+                        "hint",
+                        "fifty_fifty",
+                        "skip"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.AssistType"
+                        }
+                    ],
+                    "example": "hint"
+                }
+            }
+        },
+        "handlers.UserAchievements": {
+            "type": "object",
+            "properties": {
+                "achievements": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.EarnedAchievement"
+                    }
+                },
+                "level": {
+                    "$ref": "#/definitions/services.Level"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.UserProfile": {
+            "type": "object",
+            "required": [
+                "email",
+                "first_name",
+                "last_name",
+                "role"
+            ],
+            "properties": {
+                "achievements": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.EarnedAchievement"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "john.doe@example.com"
+                },
+                "first_name": {
+                    "type": "string",
+                    "example": "John"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "last_name": {
+                    "type": "string",
+                    "example": "Doe"
+                },
+                "level": {
+                    "$ref": "#/definitions/services.Level"
+                },
+                "role": {
+                    "enum": [
+                        "student",
+                        "professor"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.UserRole"
+                        }
+                    ],
+                    "example": "student"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "xp": {
+                    "description": "Experience earned from completed attempts and achievements",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.AbilityEstimate": {
+            "type": "object",
+            "properties": {
+                "answers": {
+                    "description": "Answers the estimate is based on",
+                    "type": "integer"
+                },
+                "standard_error": {
+                    "type": "number"
+                },
+                "theta": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.Achievement": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Score 100% on a quiz"
+                },
+                "icon": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "first_perfect_score"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Flawless"
+                },
+                "rule": {
+                    "$ref": "#/definitions/models.AchievementRule"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "xp": {
+                    "type": "integer",
+                    "example": 50
+                }
+            }
+        },
+        "models.AchievementMetric": {
+            "type": "string",
+            "enum": [
+                "completed_attempts",
+                "perfect_scores",
+                "streak_days",
+                "leaderboard_rank",
+                "attempt_percentage"
+            ],
+            "x-enum-varnames": [
+                "MetricCompletedAttempts",
+                "MetricPerfectScores",
+                "MetricStreakDays",
+                "MetricLeaderboardRank",
+                "MetricAttemptPercentage"
+            ]
+        },
+        "models.AchievementRule": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "$ref": "#/definitions/models.QuizCategory"
+                },
+                "difficulty": {
+                    "$ref": "#/definitions/models.DifficultyLevel"
+                },
+                "metric": {
+                    "enum": [
+                        "completed_attempts",
+                        "perfect_scores",
+                        "streak_days",
+                        "leaderboard_rank",
+                        "attempt_percentage"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.AchievementMetric"
+                        }
+                    ]
+                },
+                "threshold": {
+                    "type": "number",
+                    "example": 10
+                }
+            }
+        },
+        "models.AdaptiveSettings": {
+            "type": "object",
+            "properties": {
+                "calibrated_at": {
+                    "type": "string"
+                },
+                "question_count": {
+                    "type": "integer",
+                    "example": 10
+                }
+            }
+        },
+        "models.Answer": {
+            "type": "object",
+            "properties": {
+                "answered_at": {
+                    "type": "string"
+                },
+                "assists": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AssistUsage"
+                    }
+                },
+                "breakdown": {
+                    "$ref": "#/definitions/models.ScoreBreakdown"
+                },
+                "is_correct": {
+                    "type": "boolean"
+                },
+                "override": {
+                    "$ref": "#/definitions/models.ScoreOverride"
+                },
+                "points_earned": {
+                    "type": "number"
+                },
+                "question_id": {
+                    "type": "string"
+                },
+                "skipped": {
+                    "type": "boolean"
+                },
+                "student_answer": {},
+                "time_to_answer": {
+                    "description": "In seconds",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.AssistType": {
+            "type": "string",
+            "enum": [
+                "hint",
+                "fifty_fifty",
+                "skip"
+            ],
+            "x-enum-varnames": [
+                "AssistHint",
+                "AssistFiftyFifty",
+                "AssistSkip"
+            ]
+        },
+        "models.AssistUsage": {
+            "type": "object",
+            "properties": {
+                "hint_index": {
+                    "type": "integer"
+                },
+                "penalty": {
+                    "type": "number"
+                },
+                "question_id": {
+                    "type": "string"
+                },
+                "removed_options": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "type": {
+                    "$ref": "#/definitions/models.AssistType"
+                },
+                "used_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AttemptMode": {
+            "type": "string",
+            "enum": [
+                "ranked",
+                "practice"
+            ],
+            "x-enum-varnames": [
+                "ModeRanked",
+                "ModePractice"
+            ]
+        },
+        "models.AttemptReview": {
+            "type": "object",
+            "properties": {
+                "answers_visible": {
+                    "type": "boolean"
+                },
+                "attempt_id": {
+                    "type": "string"
+                },
+                "available_at": {
+                    "type": "string"
+                },
+                "completed_at": {
+                    "type": "string"
+                },
+                "max_score": {
+                    "type": "number"
+                },
+                "questions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.QuestionReview"
+                    }
+                },
+                "quiz_id": {
+                    "type": "string"
+                },
+                "quiz_title": {
+                    "type": "string"
+                },
+                "review_policy": {
+                    "$ref": "#/definitions/models.ReviewPolicy"
+                },
+                "time_taken": {
+                    "type": "integer"
+                },
+                "total_score": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.ChallengeOutcome": {
+            "type": "string",
+            "enum": [
+                "win",
+                "loss",
+                "draw"
+            ],
+            "x-enum-varnames": [
+                "OutcomeWin",
+                "OutcomeLoss",
+                "OutcomeDraw"
+            ]
+        },
+        "models.ChallengeResult": {
+            "type": "object",
+            "properties": {
+                "percentage": {
+                    "type": "number"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "time_taken": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ChallengeStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "active",
+                "finished",
+                "declined",
+                "expired"
+            ],
+            "x-enum-varnames": [
+                "ChallengePending",
+                "ChallengeActive",
+                "ChallengeFinished",
+                "ChallengeDeclined",
+                "ChallengeExpired"
+            ]
+        },
+        "models.CompletionSource": {
+            "type": "string",
+            "enum": [
+                "api",
+                "webhook",
+                "manual"
+            ],
+            "x-enum-comments": {
+                "CompletionFromAPI": "Checked with the course API",
+                "CompletionFromManual": "Granted or revoked by a professor",
+                "CompletionFromWebhook": "Pushed by the course platform"
+            },
+            "x-enum-descriptions": [
+                "Checked with the course API",
+                "Pushed by the course platform",
+                "Granted or revoked by a professor"
+            ],
+            "x-enum-varnames": [
+                "CompletionFromAPI",
+                "CompletionFromWebhook",
+                "CompletionFromManual"
+            ]
+        },
+        "models.CourseCompletion": {
+            "type": "object",
+            "properties": {
+                "completed": {
+                    "type": "boolean"
+                },
+                "completed_at": {
+                    "type": "string"
+                },
+                "course_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "source": {
+                    "$ref": "#/definitions/models.CompletionSource"
+                },
+                "student_id": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "updated_by": {
+                    "description": "Professor of a manual result",
+                    "type": "string"
+                }
+            }
+        },
+        "models.DifficultyLevel": {
+            "type": "string",
+            "enum": [
+                "easy",
+                "medium",
+                "hard"
+            ],
+            "x-enum-varnames": [
+                "LevelEasy",
+                "LevelMedium",
+                "LevelHard"
+            ]
+        },
+        "models.DifficultyWeights": {
+            "type": "object",
+            "properties": {
+                "easy": {
+                    "type": "number",
+                    "example": 1
+                },
+                "hard": {
+                    "type": "number",
+                    "example": 1.5
+                },
+                "medium": {
+                    "type": "number",
+                    "example": 1.25
+                }
+            }
+        },
+        "models.EarnedAchievement": {
+            "type": "object",
+            "properties": {
+                "attempt_id": {
+                    "description": "Attempt that earned the badge",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "earned_at": {
+                    "type": "string"
+                },
+                "icon": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "quiz_id": {
+                    "description": "Quiz whose attempt earned the badge",
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "xp": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Hint": {
+            "type": "object",
+            "properties": {
+                "penalty": {
+                    "type": "number",
+                    "example": 0.1
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Think about how Go programs are run."
+                }
+            }
+        },
+        "models.LeaderboardEntry": {
+            "type": "object",
+            "properties": {
+                "completed_at": {
+                    "type": "string"
+                },
+                "max_score": {
+                    "type": "number"
+                },
+                "percentage": {
+                    "type": "number"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "student_id": {
+                    "type": "string"
+                },
+                "student_name": {
+                    "type": "string"
+                },
+                "time_taken": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Lifeline": {
+            "type": "object",
+            "properties": {
+                "penalty": {
+                    "description": "Fraction of the points earned (of base points for skip)",
+                    "type": "number",
+                    "example": 0.25
+                },
+                "type": {
+                    "enum": [
+                        "fifty_fifty",
+                        "skip"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.AssistType"
+                        }
+                    ],
+                    "example": "fifty_fifty"
+                },
+                "uses": {
+                    "description": "Per attempt",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.LiveParticipant": {
+            "type": "object",
+            "properties": {
+                "attempt_id": {
+                    "type": "string"
+                },
+                "joined_at": {
+                    "type": "string"
+                },
+                "student_id": {
+                    "type": "string"
+                },
+                "student_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LiveSessionStatus": {
+            "type": "string",
+            "enum": [
+                "lobby",
+                "question",
+                "leaderboard",
+                "finished",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "SessionLobby",
+                "SessionQuestion",
+                "SessionLeaderboard",
+                "SessionFinished",
+                "SessionCancelled"
+            ]
+        },
+        "models.PerfectBonus": {
+            "type": "object",
+            "properties": {
+                "percent": {
+                    "type": "number",
+                    "example": 0.1
+                }
+            }
+        },
+        "models.PracticeSettings": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "skip_course_gating": {
+                    "description": "Practice does not require the course to be completed",
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "models.Question": {
+            "type": "object",
+            "required": [
+                "question_text",
+                "type"
+            ],
+            "properties": {
+                "correct_answer": {
+                    "description": "bool for T/F, int for MC (index)",
+                    "type": "string",
+                    "example": "true"
+                },
+                "explanation": {
+                    "type": "string",
+                    "example": "Go compiles to native machine code."
+                },
+                "hint_count": {
+                    "description": "Set instead of Hints when hints are hidden",
+                    "type": "integer"
+                },
+                "hints": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Hint"
+                    }
+                },
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439012"
+                },
+                "options": {
+                    "description": "For multiple choice",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "order": {
+                    "description": "Question order in quiz",
+                    "type": "integer",
+                    "example": 1
+                },
+                "points": {
+                    "description": "Base points for this question",
+                    "type": "integer",
+                    "example": 10
+                },
+                "question_text": {
+                    "type": "string",
+                    "example": "Is Go a compiled language?"
+                },
+                "time_limit": {
+                    "description": "In seconds, default 15",
+                    "type": "integer",
+                    "example": 15
+                },
+                "type": {
+                    "enum": [
+                        "true_false",
+                        "multiple_choice"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.QuestionType"
+                        }
+                    ],
+                    "example": "true_false"
+                }
+            }
+        },
+        "models.QuestionReview": {
+            "type": "object",
+            "properties": {
+                "answered": {
+                    "type": "boolean"
+                },
+                "correct_answer": {
+                    "type": "string"
+                },
+                "explanation": {
+                    "type": "string"
+                },
+                "is_correct": {
+                    "type": "boolean"
+                },
+                "options": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "points": {
+                    "type": "integer"
+                },
+                "points_earned": {
+                    "type": "number"
+                },
+                "question_id": {
+                    "type": "string"
+                },
+                "question_text": {
+                    "type": "string"
+                },
+                "student_answer": {
+                    "type": "string"
+                },
+                "time_to_answer": {
+                    "type": "integer"
+                },
+                "type": {
+                    "$ref": "#/definitions/models.QuestionType"
+                }
+            }
+        },
+        "models.QuestionType": {
+            "type": "string",
+            "enum": [
+                "true_false",
+                "multiple_choice"
+            ],
+            "x-enum-varnames": [
+                "QuestionTypeTrueFalse",
+                "QuestionTypeMultipleChoice"
+            ]
+        },
+        "models.Quiz": {
+            "type": "object",
+            "required": [
+                "category",
+                "course_id",
+                "difficulty_level",
+                "title"
+            ],
+            "properties": {
+                "adaptive": {
+                    "$ref": "#/definitions/models.AdaptiveSettings"
+                },
+                "approved_at": {
+                    "type": "string"
+                },
+                "approved_by": {
+                    "type": "string"
+                },
+                "attempt_count": {
+                    "type": "integer"
+                },
+                "average_score": {
+                    "description": "Average percentage of counted attempts",
+                    "type": "number"
+                },
+                "category": {
+                    "$ref": "#/definitions/models.QuizCategory"
+                },
+                "closes_at": {
+                    "type": "string"
+                },
+                "course_id": {
+                    "description": "External course reference",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "creator_id": {
+                    "type": "string"
+                },
+                "creator_role": {
+                    "$ref": "#/definitions/models.UserRole"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "difficulty_level": {
+                    "$ref": "#/definitions/models.DifficultyLevel"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lifelines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Lifeline"
+                    }
+                },
+                "practice": {
+                    "$ref": "#/definitions/models.PracticeSettings"
+                },
+                "questions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Question"
+                    }
+                },
+                "review_policy": {
+                    "$ref": "#/definitions/models.ReviewPolicy"
+                },
+                "scoring": {
+                    "$ref": "#/definitions/models.ScoringPolicy"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.QuizStatus"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.QuizAttempt": {
+            "type": "object",
+            "properties": {
+                "ability": {
+                    "$ref": "#/definitions/models.AbilityEstimate"
+                },
+                "adaptive": {
+                    "description": "Set on adaptive attempts: the questions served so far, in order, and the\nRasch ability estimate updated after every answer",
+                    "type": "boolean"
+                },
+                "answers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Answer"
+                    }
+                },
+                "assists": {
+                    "description": "Hints and lifelines used so far, including on questions not yet answered",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AssistUsage"
+                    }
+                },
+                "challenge_id": {
+                    "description": "Set when the attempt is a student's side of a head-to-head challenge",
+                    "type": "string"
+                },
+                "completed_at": {
+                    "type": "string"
+                },
+                "correct_count": {
+                    "description": "Computed server-side when the attempt is completed",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "incorrect_count": {
+                    "type": "integer"
+                },
+                "invalidated_at": {
+                    "description": "Set when a professor invalidates the attempt; invalidated attempts are excluded from leaderboards",
+                    "type": "string"
+                },
+                "invalidated_by": {
+                    "type": "string"
+                },
+                "invalidation_reason": {
+                    "type": "string"
+                },
+                "max_score": {
+                    "type": "number"
+                },
+                "mode": {
+                    "description": "Practice attempts give immediate feedback and are left out of\nleaderboards, achievements, quiz stats and gradebooks. Empty is ranked",
+                    "enum": [
+                        "ranked",
+                        "practice"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.AttemptMode"
+                        }
+                    ]
+                },
+                "new_achievements": {
+                    "description": "Only in the completion response",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.EarnedAchievement"
+                    }
+                },
+                "percentage": {
+                    "type": "number"
+                },
+                "perfect_bonus": {
+                    "type": "number"
+                },
+                "question_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "quiz_id": {
+                    "type": "string"
+                },
+                "scoring": {
+                    "description": "Scoring policy of the quiz when the attempt started",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ScoringPolicy"
+                        }
+                    ]
+                },
+                "scoring_version": {
+                    "type": "string"
+                },
+                "session_id": {
+                    "description": "Set when the attempt belongs to a live classroom session",
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "student_id": {
+                    "type": "string"
+                },
+                "time_taken": {
+                    "description": "In seconds",
+                    "type": "integer"
+                },
+                "total_score": {
+                    "type": "number"
+                },
+                "unanswered_count": {
+                    "type": "integer"
+                },
+                "xp_earned": {
+                    "description": "Rewards granted when the attempt was completed",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.QuizCategory": {
+            "type": "string",
+            "enum": [
+                "mathematics",
+                "science",
+                "history",
+                "literature",
+                "programming",
+                "languages"
+            ],
+            "x-enum-varnames": [
+                "CategoryMathematics",
+                "CategoryScience",
+                "CategoryHistory",
+                "CategoryLiterature",
+                "CategoryProgramming",
+                "CategoryLanguages"
+            ]
+        },
+        "models.QuizStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "approved",
+                "rejected"
+            ],
+            "x-enum-varnames": [
+                "StatusPending",
+                "StatusApproved",
+                "StatusRejected"
+            ]
+        },
+        "models.ReviewPolicy": {
+            "type": "string",
+            "enum": [
+                "immediately",
+                "after_close",
+                "never"
+            ],
+            "x-enum-varnames": [
+                "ReviewImmediately",
+                "ReviewAfterClose",
+                "ReviewNever"
+            ]
+        },
+        "models.ScoreBreakdown": {
+            "type": "object",
+            "properties": {
+                "assist_penalty": {
+                    "type": "number"
+                },
+                "base_points": {
+                    "type": "integer"
+                },
+                "difficulty_bonus": {
+                    "type": "number"
+                },
+                "difficulty_multiplier": {
+                    "type": "number"
+                },
+                "strategy_points": {
+                    "type": "number"
+                },
+                "streak_bonus": {
+                    "type": "number"
+                },
+                "streak_length": {
+                    "type": "integer"
+                },
+                "streak_multiplier": {
+                    "type": "number"
+                },
+                "total": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.ScoreOverride": {
+            "type": "object",
+            "properties": {
+                "original_points": {
+                    "type": "number"
+                },
+                "overridden_at": {
+                    "type": "string"
+                },
+                "points": {
+                    "type": "number"
+                },
+                "professor_id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ScoringModifiers": {
+            "type": "object",
+            "properties": {
+                "difficulty": {
+                    "$ref": "#/definitions/models.DifficultyWeights"
+                },
+                "perfect": {
+                    "$ref": "#/definitions/models.PerfectBonus"
+                },
+                "streak": {
+                    "$ref": "#/definitions/models.StreakBonus"
+                }
+            }
+        },
+        "models.ScoringPolicy": {
+            "type": "object",
+            "properties": {
+                "modifiers": {
+                    "$ref": "#/definitions/models.ScoringModifiers"
+                },
+                "params": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "strategy": {
+                    "type": "string",
+                    "example": "speed_decay"
+                }
+            }
+        },
+        "models.StreakBonus": {
+            "type": "object",
+            "properties": {
+                "max_multiplier": {
+                    "type": "number",
+                    "example": 1.5
+                },
+                "min_streak": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "step": {
+                    "type": "number",
+                    "example": 0.1
+                }
+            }
+        },
+        "models.StudentProgress": {
+            "type": "object",
+            "properties": {
+                "freeze_tokens": {
+                    "type": "integer"
+                },
+                "frozen_days": {
+                    "description": "Missed days covered by a freeze token, YYYY-MM-DD",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "student_id": {
+                    "type": "string"
+                },
+                "timezone": {
+                    "description": "IANA name; days start at local midnight",
+                    "type": "string",
+                    "example": "Europe/Paris"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "weekly_goal": {
+                    "description": "Quizzes to complete per week, weeks start on Monday",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "models.User": {
+            "type": "object",
+            "required": [
+                "email",
+                "first_name",
+                "last_name",
+                "role"
+            ],
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "john.doe@example.com"
+                },
+                "first_name": {
+                    "type": "string",
+                    "example": "John"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "last_name": {
+                    "type": "string",
+                    "example": "Doe"
+                },
+                "role": {
+                    "enum": [
+                        "student",
+                        "professor"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.UserRole"
+                        }
+                    ],
+                    "example": "student"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "xp": {
+                    "description": "Experience earned from completed attempts and achievements",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.UserRole": {
+            "type": "string",
+            "enum": [
+                "professor",
+                "student"
+            ],
+            "x-enum-varnames": [
+                "RoleProfessor",
+                "RoleStudent"
+            ]
+        },
+        "services.CalibrationReport": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "calibrated_at": {
+                    "type": "string"
+                },
+                "questions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.QuestionCalibration"
+                    }
+                },
+                "quiz_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.CategoryStats": {
+            "type": "object",
+            "properties": {
+                "accuracy": {
+                    "description": "Percentage of answers that were correct",
+                    "type": "number"
+                },
+                "answers": {
+                    "type": "integer"
+                },
+                "attempts": {
+                    "type": "integer"
+                },
+                "average_percentage": {
+                    "type": "number"
+                },
+                "average_response_time": {
+                    "description": "In seconds, skipped questions excluded",
+                    "type": "number"
+                },
+                "category": {
+                    "$ref": "#/definitions/models.QuizCategory"
+                },
+                "correct": {
+                    "type": "integer"
+                },
+                "trend": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.TrendPoint"
+                    }
+                }
+            }
+        },
+        "services.CourseCompletionResponse": {
+            "type": "object",
+            "properties": {
+                "completed": {
+                    "type": "boolean"
+                },
+                "completed_at": {
+                    "type": "string"
+                },
+                "course_id": {
+                    "type": "string"
+                },
+                "student_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.DifficultyStats": {
+            "type": "object",
+            "properties": {
+                "accuracy": {
+                    "description": "Percentage of answers that were correct",
+                    "type": "number"
+                },
+                "answers": {
+                    "type": "integer"
+                },
+                "attempts": {
+                    "type": "integer"
+                },
+                "average_percentage": {
+                    "type": "number"
+                },
+                "average_response_time": {
+                    "description": "In seconds, skipped questions excluded",
+                    "type": "number"
+                },
+                "correct": {
+                    "type": "integer"
+                },
+                "difficulty_level": {
+                    "$ref": "#/definitions/models.DifficultyLevel"
+                }
+            }
+        },
+        "services.Event": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "data": {},
+                "id": {
+                    "type": "integer"
+                },
+                "quiz_id": {
+                    "type": "string"
+                },
+                "session_id": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
                 }
             }
         },
-        "handlers.CreateQuizRequest": {
+        "services.ItemAnalysis": {
             "type": "object",
-            "required": [
-                "category",
-                "course_id",
-                "difficulty_level",
-                "questions",
-                "title"
-            ],
             "properties": {
-                "category": {
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/models.QuizCategory"
-                        }
-                    ],
-                    "example": "programming"
+                "attempts": {
+                    "description": "Completed attempts that are not invalidated",
+                    "type": "integer"
                 },
-                "course_id": {
-                    "type": "string",
-                    "example": "course123"
+                "cronbach_alpha": {
+                    "description": "Internal consistency, null with fewer than 2 questions or no score variance",
+                    "type": "number"
                 },
-                "description": {
-                    "type": "string",
-                    "example": "Basic concepts of Go programming language"
+                "flagged_count": {
+                    "description": "Questions with at least one flag",
+                    "type": "integer"
                 },
-                "difficulty_level": {
-                    "enum": [
-                        "easy",
-                        "medium",
-                        "hard"
-                    ],
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/models.DifficultyLevel"
-                        }
-                    ],
-                    "example": "easy"
+                "flags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
                 "questions": {
                     "type": "array",
-                    "minItems": 1,
                     "items": {
-                        "$ref": "#/definitions/handlers.CreateQuestionRequest"
+                        "$ref": "#/definitions/services.ItemStatistics"
                     }
                 },
-                "title": {
-                    "type": "string",
-                    "example": "Introduction to Go Programming"
+                "quiz_id": {
+                    "type": "string"
                 }
             }
         },
-        "handlers.LoginRequest": {
+        "services.ItemStatistics": {
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
-                "email": {
-                    "type": "string",
-                    "example": "student@example.com"
+                "answered": {
+                    "description": "Attempts that answered the question, skips excluded",
+                    "type": "integer"
                 },
-                "password": {
-                    "type": "string",
-                    "example": "password123"
-                }
-            }
-        },
-        "handlers.LoginResponse": {
-            "type": "object",
-            "properties": {
-                "token": {
-                    "type": "string",
-                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                "average_time_to_answer": {
+                    "description": "In seconds",
+                    "type": "number"
                 },
-                "user": {
-                    "$ref": "#/definitions/models.User"
+                "discrimination_index": {
+                    "description": "Upper minus lower 27% percent correct, from -1 to 1",
+                    "type": "number"
+                },
+                "flags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "option_distribution": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.OptionCount"
+                    }
+                },
+                "order": {
+                    "type": "integer"
+                },
+                "percent_correct": {
+                    "type": "number"
+                },
+                "point_biserial": {
+                    "description": "Correlation with the score on the other questions",
+                    "type": "number"
+                },
+                "question_id": {
+                    "type": "string"
+                },
+                "question_text": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/models.QuestionType"
+                },
+                "unanswered": {
+                    "description": "Attempts that skipped or never answered it",
+                    "type": "integer"
                 }
             }
         },
-        "handlers.RegisterRequest": {
+        "services.LeaderboardVerification": {
             "type": "object",
-            "required": [
-                "email",
-                "first_name",
-                "last_name",
-                "password",
-                "role"
-            ],
             "properties": {
-                "email": {
-                    "type": "string",
-                    "example": "student@example.com"
+                "consistent": {
+                    "type": "boolean"
                 },
-                "first_name": {
-                    "type": "string",
-                    "example": "John"
+                "expected": {
+                    "type": "integer"
                 },
-                "last_name": {
-                    "type": "string",
-                    "example": "Doe"
+                "extra": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "password": {
-                    "type": "string",
-                    "minLength": 6,
-                    "example": "password123"
+                "materialized": {
+                    "type": "integer"
                 },
-                "role": {
-                    "enum": [
-                        "student",
-                        "professor"
-                    ],
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/models.UserRole"
-                        }
-                    ],
-                    "example": "student"
+                "mismatched": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "missing": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "quiz_id": {
+                    "type": "string"
                 }
             }
         },
-        "handlers.StartAttemptRequest": {
+        "services.Level": {
             "type": "object",
-            "required": [
-                "quiz_id"
-            ],
             "properties": {
-                "quiz_id": {
-                    "type": "string",
-                    "example": "507f1f77bcf86cd799439011"
+                "level": {
+                    "type": "integer"
+                },
+                "level_xp": {
+                    "description": "XP at which the current level started",
+                    "type": "integer"
+                },
+                "next_level_xp": {
+                    "description": "XP needed for the next level",
+                    "type": "integer"
+                },
+                "xp": {
+                    "type": "integer"
                 }
             }
         },
-        "handlers.SubmitAnswerRequest": {
+        "services.OptionCount": {
             "type": "object",
-            "required": [
-                "answer",
-                "attempt_id",
-                "question_id",
-                "time_to_answer"
-            ],
             "properties": {
-                "answer": {
-                    "type": "string",
-                    "example": "true"
+                "correct": {
+                    "type": "boolean"
                 },
-                "attempt_id": {
-                    "type": "string",
-                    "example": "507f1f77bcf86cd799439011"
+                "count": {
+                    "type": "integer"
                 },
-                "question_id": {
-                    "type": "string",
-                    "example": "507f1f77bcf86cd799439012"
+                "option": {
+                    "type": "integer"
                 },
-                "time_to_answer": {
-                    "description": "In seconds",
-                    "type": "integer",
-                    "example": 8
+                "percentage": {
+                    "description": "Of the attempts that answered",
+                    "type": "number"
+                },
+                "text": {
+                    "type": "string"
                 }
             }
         },
-        "models.Answer": {
+        "services.ProgressReport": {
             "type": "object",
             "properties": {
-                "answered_at": {
-                    "type": "string"
-                },
-                "is_correct": {
+                "active_today": {
                     "type": "boolean"
                 },
-                "points_earned": {
-                    "type": "number"
+                "current_streak": {
+                    "description": "Days with a completed quiz; frozen days keep the streak alive without adding to it",
+                    "type": "integer"
                 },
-                "question_id": {
-                    "type": "string"
+                "freeze_tokens": {
+                    "type": "integer"
                 },
-                "student_answer": {},
-                "time_to_answer": {
-                    "description": "In seconds",
+                "frozen_days": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "goal_streak": {
+                    "description": "Consecutive weeks the goal was met",
+                    "type": "integer"
+                },
+                "longest_streak": {
+                    "description": "Within the last year",
                     "type": "integer"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "today": {
+                    "type": "string"
+                },
+                "weekly_goal": {
+                    "$ref": "#/definitions/services.WeekProgress"
+                },
+                "weeks": {
+                    "description": "Most recent first, including the current week",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.WeekProgress"
+                    }
                 }
             }
         },
-        "models.DifficultyLevel": {
-            "type": "string",
-            "enum": [
-                "easy",
-                "medium",
-                "hard"
-            ],
-            "x-enum-varnames": [
-                "LevelEasy",
-                "LevelMedium",
-                "LevelHard"
-            ]
-        },
-        "models.LeaderboardEntry": {
+        "services.QuestionCalibration": {
             "type": "object",
             "properties": {
-                "completed_at": {
-                    "type": "string"
-                },
-                "max_score": {
-                    "type": "number"
+                "calibrated": {
+                    "description": "False when the question had too few answers and keeps its previous difficulty",
+                    "type": "boolean"
                 },
-                "percentage": {
+                "difficulty": {
+                    "description": "Logits, higher is harder",
                     "type": "number"
                 },
-                "rank": {
+                "order": {
                     "type": "integer"
                 },
-                "score": {
+                "percent_correct": {
                     "type": "number"
                 },
-                "student_id": {
-                    "type": "string"
-                },
-                "student_name": {
+                "question_id": {
                     "type": "string"
                 },
-                "time_taken": {
+                "responses": {
                     "type": "integer"
                 }
             }
         },
-        "models.Question": {
+        "services.QuestionTypeStats": {
             "type": "object",
-            "required": [
-                "question_text",
-                "type"
-            ],
             "properties": {
-                "correct_answer": {
-                    "description": "bool for T/F, int for MC (index)",
-                    "type": "string",
-                    "example": "true"
-                },
-                "id": {
-                    "type": "string",
-                    "example": "507f1f77bcf86cd799439012"
-                },
-                "options": {
-                    "description": "For multiple choice",
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
-                },
-                "order": {
-                    "description": "Question order in quiz",
-                    "type": "integer",
-                    "example": 1
+                "accuracy": {
+                    "type": "number"
                 },
-                "points": {
-                    "description": "Base points for this question",
-                    "type": "integer",
-                    "example": 10
+                "answers": {
+                    "type": "integer"
                 },
-                "question_text": {
-                    "type": "string",
-                    "example": "Is Go a compiled language?"
+                "average_response_time": {
+                    "type": "number"
                 },
-                "time_limit": {
-                    "description": "In seconds, default 15",
-                    "type": "integer",
-                    "example": 15
+                "correct": {
+                    "type": "integer"
                 },
                 "type": {
-                    "enum": [
-                        "true_false",
-                        "multiple_choice"
-                    ],
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/models.QuestionType"
-                        }
-                    ],
-                    "example": "true_false"
+                    "$ref": "#/definitions/models.QuestionType"
                 }
             }
         },
-        "models.QuestionType": {
-            "type": "string",
-            "enum": [
-                "true_false",
-                "multiple_choice"
-            ],
-            "x-enum-varnames": [
-                "QuestionTypeTrueFalse",
-                "QuestionTypeMultipleChoice"
-            ]
-        },
-        "models.Quiz": {
+        "services.ReviewCard": {
             "type": "object",
-            "required": [
-                "category",
-                "course_id",
-                "difficulty_level",
-                "title"
-            ],
             "properties": {
-                "approved_at": {
+                "due_at": {
                     "type": "string"
                 },
-                "approved_by": {
+                "interval_days": {
+                    "type": "integer"
+                },
+                "item_id": {
                     "type": "string"
                 },
-                "category": {
-                    "$ref": "#/definitions/models.QuizCategory"
+                "lapses": {
+                    "type": "integer"
                 },
-                "course_id": {
-                    "description": "External course reference",
-                    "type": "string"
+                "question": {
+                    "$ref": "#/definitions/models.Question"
                 },
-                "created_at": {
+                "quiz_id": {
                     "type": "string"
                 },
-                "creator_id": {
+                "quiz_title": {
                     "type": "string"
                 },
-                "creator_role": {
-                    "$ref": "#/definitions/models.UserRole"
-                },
-                "description": {
+                "repetitions": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.ReviewSession": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "description": "Student's local day, YYYY-MM-DD",
                     "type": "string"
                 },
-                "difficulty_level": {
-                    "$ref": "#/definitions/models.DifficultyLevel"
-                },
-                "id": {
-                    "type": "string"
+                "due": {
+                    "description": "Items due by the end of the day, the session holds at most limit of them",
+                    "type": "integer"
                 },
-                "questions": {
+                "items": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Question"
+                        "$ref": "#/definitions/services.ReviewCard"
                     }
+                }
+            }
+        },
+        "services.StatsSummary": {
+            "type": "object",
+            "properties": {
+                "accuracy": {
+                    "description": "Percentage of answers that were correct",
+                    "type": "number"
                 },
-                "status": {
-                    "$ref": "#/definitions/models.QuizStatus"
+                "answers": {
+                    "type": "integer"
                 },
-                "title": {
-                    "type": "string"
+                "attempts": {
+                    "type": "integer"
                 },
-                "updated_at": {
-                    "type": "string"
+                "average_percentage": {
+                    "type": "number"
+                },
+                "average_response_time": {
+                    "description": "In seconds, skipped questions excluded",
+                    "type": "number"
+                },
+                "correct": {
+                    "type": "integer"
                 }
             }
         },
-        "models.QuizAttempt": {
+        "services.StudentStats": {
             "type": "object",
             "properties": {
-                "answers": {
+                "categories": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Answer"
+                        "$ref": "#/definitions/services.CategoryStats"
                     }
                 },
-                "completed_at": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
+                "difficulties": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.DifficultyStats"
+                    }
                 },
-                "max_score": {
-                    "type": "number"
+                "overall": {
+                    "$ref": "#/definitions/services.StatsSummary"
                 },
-                "quiz_id": {
-                    "type": "string"
+                "question_types": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.QuestionTypeStats"
+                    }
                 },
-                "started_at": {
+                "timezone": {
                     "type": "string"
                 },
-                "student_id": {
-                    "type": "string"
+                "trend": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.TrendPoint"
+                    }
                 },
-                "time_taken": {
-                    "description": "In seconds",
+                "trend_weeks": {
                     "type": "integer"
                 },
-                "total_score": {
-                    "type": "number"
+                "weakest_topics": {
+                    "description": "Lowest accuracy first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.TopicStats"
+                    }
                 }
             }
         },
-        "models.QuizCategory": {
-            "type": "string",
-            "enum": [
-                "mathematics",
-                "science",
-                "history",
-                "literature",
-                "programming",
-                "languages"
-            ],
-            "x-enum-varnames": [
-                "CategoryMathematics",
-                "CategoryScience",
-                "CategoryHistory",
-                "CategoryLiterature",
-                "CategoryProgramming",
-                "CategoryLanguages"
-            ]
-        },
-        "models.QuizStatus": {
-            "type": "string",
-            "enum": [
-                "pending",
-                "approved",
-                "rejected"
-            ],
-            "x-enum-varnames": [
-                "StatusPending",
-                "StatusApproved",
-                "StatusRejected"
-            ]
-        },
-        "models.User": {
+        "services.TopicStats": {
             "type": "object",
-            "required": [
-                "email",
-                "first_name",
-                "last_name",
-                "role"
-            ],
             "properties": {
-                "created_at": {
-                    "type": "string"
-                },
-                "email": {
-                    "type": "string",
-                    "example": "john.doe@example.com"
+                "accuracy": {
+                    "type": "number"
                 },
-                "first_name": {
-                    "type": "string",
-                    "example": "John"
+                "answers": {
+                    "type": "integer"
                 },
-                "id": {
-                    "type": "string",
-                    "example": "507f1f77bcf86cd799439011"
+                "category": {
+                    "$ref": "#/definitions/models.QuizCategory"
                 },
-                "last_name": {
-                    "type": "string",
-                    "example": "Doe"
+                "difficulty_level": {
+                    "$ref": "#/definitions/models.DifficultyLevel"
+                }
+            }
+        },
+        "services.TrendPoint": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
                 },
-                "role": {
-                    "enum": [
-                        "student",
-                        "professor"
-                    ],
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/models.UserRole"
-                        }
-                    ],
-                    "example": "student"
+                "average_percentage": {
+                    "type": "number"
                 },
-                "updated_at": {
+                "week_start": {
                     "type": "string"
                 }
             }
         },
-        "models.UserRole": {
-            "type": "string",
-            "enum": [
-                "professor",
-                "student"
-            ],
-            "x-enum-varnames": [
-                "RoleProfessor",
-                "RoleStudent"
-            ]
+        "services.WeekProgress": {
+            "type": "object",
+            "properties": {
+                "completed": {
+                    "type": "integer"
+                },
+                "met": {
+                    "type": "boolean"
+                },
+                "target": {
+                    "type": "integer"
+                },
+                "week_start": {
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"regexp"
+	"time"
+
+	"quizmasterapi/config"
+	"quizmasterapi/models"
+	"quizmasterapi/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Achievement keys are lowercase slugs, e.g. "first_perfect_score"
+var achievementKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_]{0,63}$`)
+
+// AchievementHandler handles achievement definitions and earned badges
+type AchievementHandler struct {
+	userCollection *mongo.Collection
+	achievements   *services.AchievementService
+}
+
+// NewAchievementHandler creates a new achievement handler
+func NewAchievementHandler(achievements *services.AchievementService) *AchievementHandler {
+	return &AchievementHandler{
+		userCollection: config.GetCollection("users"),
+		achievements:   achievements,
+	}
+}
+
+// UpsertAchievementRequest defines an achievement and the rule that awards it
+type UpsertAchievementRequest struct {
+	Name        string                 `json:"name" binding:"required" example:"Science Buff"`
+	Description string                 `json:"description" binding:"required" example:"Complete 5 hard science quizzes"`
+	Icon        string                 `json:"icon,omitempty"`
+	XP          int                    `json:"xp" binding:"min=0" example:"80"`
+	Rule        models.AchievementRule `json:"rule"`
+	Active      *bool                  `json:"active,omitempty"` // Defaults to true
+}
+
+// UserAchievements is the public view of a user's progress
+type UserAchievements struct {
+	UserID       primitive.ObjectID         `json:"user_id"`
+	Name         string                     `json:"name"`
+	Level        services.Level             `json:"level"`
+	Achievements []models.EarnedAchievement `json:"achievements"`
+}
+
+// GetAchievements godoc
+// @Summary      List achievements
+// @Description  List the achievements students can earn and their rules. Professors can include inactive ones with all=true
+// @Tags         achievements
+// @Produce      json
+// @Security     BearerAuth
+// @Param        all query bool false "Include inactive achievements (professors only)"
+// @Success      200 {array} models.Achievement
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /achievements [get]
+func (h *AchievementHandler) GetAchievements(c *gin.Context) {
+	userRole, _ := c.Get("user_role")
+	all := c.Query("all") == "true" && userRole.(models.UserRole) == models.RoleProfessor
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	achievements, err := h.achievements.Definitions(ctx, all)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch achievements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, achievements)
+}
+
+// UpsertAchievement godoc
+// @Summary      Create or update an achievement
+// @Description  Create or replace the achievement with the given key (professors only). Rules are evaluated whenever an attempt is completed; badges already awarded are kept
+// @Tags         achievements
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        key path string true "Achievement key, a lowercase slug"
+// @Param        request body UpsertAchievementRequest true "Achievement definition"
+// @Success      200 {object} models.Achievement
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /manage/achievements/{key} [put]
+func (h *AchievementHandler) UpsertAchievement(c *gin.Context) {
+	key := c.Param("key")
+	if !achievementKeyPattern.MatchString(key) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid achievement key, use lowercase letters, digits and underscores"})
+		return
+	}
+
+	var req UpsertAchievementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.ValidateAchievementRule(&req.Rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	achievement := models.Achievement{
+		Key:         key,
+		Name:        req.Name,
+		Description: req.Description,
+		Icon:        req.Icon,
+		XP:          req.XP,
+		Rule:        req.Rule,
+		Active:      req.Active == nil || *req.Active,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := h.achievements.Upsert(ctx, &achievement); err != nil {
+		log.Printf("UpsertAchievement: %v (key: %s)", err, key)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save achievement"})
+		return
+	}
+
+	c.JSON(http.StatusOK, achievement)
+}
+
+// GetUserAchievements godoc
+// @Summary      Get a user's achievements
+// @Description  Get the XP level and earned achievements of a user
+// @Tags         achievements
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "User ID"
+// @Success      200 {object} UserAchievements
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /users/{id}/achievements [get]
+func (h *AchievementHandler) GetUserAchievements(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	opts := options.FindOne().SetProjection(bson.M{"first_name": 1, "last_name": 1, "xp": 1})
+	if err := h.userCollection.FindOne(ctx, bson.M{"_id": userID}, opts).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	earned, err := h.achievements.Earned(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch achievements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, UserAchievements{
+		UserID:       userID,
+		Name:         user.FirstName + " " + user.LastName,
+		Level:        services.LevelForXP(user.XP),
+		Achievements: earned,
+	})
+}
@@ -3,7 +3,12 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"log"
+	"math/rand"
 	"net/http"
+	"sort"
+	"strconv"
 	"time"
 
 	"quizmasterapi/config"
@@ -17,6 +22,18 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// Live session attempts are answered and completed by the session, not the attempt endpoints
+const errLiveSessionAttempt = "This attempt belongs to a live session, answer through the session"
+
+// Adaptive attempts only accept answers to questions served by /attempts/:id/next
+const errQuestionNotServed = "This question has not been served yet, request it with /attempts/:id/next"
+
+// Ranked attempts can't be started or answered once the quiz closes, when its answers may be revealed
+const errQuizClosed = "This quiz is closed"
+
+// Practice shows the correct answer after each answer, which only immediate review allows
+const errPracticeReviewPolicy = "Practice requires review_policy immediately"
+
 // AttemptHandler handles quiz attempt-related requests
 type AttemptHandler struct {
 	collection     *mongo.Collection
@@ -24,27 +41,41 @@ type AttemptHandler struct {
 	userCollection *mongo.Collection
 	courseService  *services.CourseService
 	scoringService *services.ScoringService
+	statsService   *services.QuizStatsService
+	challenges     *services.ChallengeService
+	reviews        *services.ReviewService
+	leaderboards   *services.LeaderboardService
+	achievements   *services.AchievementService
+	events         *services.EventBus
 }
 
 // NewAttemptHandler creates a new attempt handler
-func NewAttemptHandler() *AttemptHandler {
+func NewAttemptHandler(courseService *services.CourseService, leaderboards *services.LeaderboardService, achievements *services.AchievementService, events *services.EventBus) *AttemptHandler {
 	return &AttemptHandler{
 		collection:     config.GetCollection("attempts"),
 		quizCollection: config.GetCollection("quizzes"),
 		userCollection: config.GetCollection("users"),
-		courseService:  services.NewCourseService(),
+		courseService:  courseService,
 		scoringService: services.NewScoringService(),
+		statsService:   services.NewQuizStatsService(),
+		challenges:     services.NewChallengeService(),
+		reviews:        services.NewReviewService(),
+		leaderboards:   leaderboards,
+		achievements:   achievements,
+		events:         events,
 	}
 }
 
 // StartAttemptRequest represents the request to start a quiz attempt
 type StartAttemptRequest struct {
-	QuizID string `json:"quiz_id" binding:"required" example:"507f1f77bcf86cd799439011"`
+	QuizID      string             `json:"quiz_id" binding:"required" example:"507f1f77bcf86cd799439011"`
+	ChallengeID string             `json:"challenge_id,omitempty" example:"507f1f77bcf86cd799439014"` // Plays an accepted challenge
+	Mode        models.AttemptMode `json:"mode,omitempty" enums:"ranked,practice" example:"ranked"`   // Default ranked; practice must be enabled on the quiz
 }
 
 // StartAttempt godoc
 // @Summary      Start a quiz attempt
-// @Description  Start attempting a quiz (students only, requires course completion)
+// @Description  Start attempting a quiz (students only, requires course completion). Practice attempts need the quiz to allow practice, skip the course check if the quiz allows it, reveal the correct answer after each answer (when the quiz review policy is immediately) and never count towards leaderboards
 // @Tags         attempts
 // @Accept       json
 // @Produce      json
@@ -70,10 +101,34 @@ func (h *AttemptHandler) StartAttempt(c *gin.Context) {
 		return
 	}
 
+	var challengeID *primitive.ObjectID
+	if req.ChallengeID != "" {
+		id, err := primitive.ObjectIDFromHex(req.ChallengeID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid challenge ID"})
+			return
+		}
+		challengeID = &id
+	}
+
+	mode := req.Mode
+	switch mode {
+	case "":
+		mode = models.ModeRanked
+	case models.ModeRanked, models.ModePractice:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mode, use ranked or practice"})
+		return
+	}
+	if mode == models.ModePractice && challengeID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Challenges can only be played in ranked mode"})
+		return
+	}
+
 	userID, _ := c.Get("user_id")
 	studentID := userID.(primitive.ObjectID)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
 	// Get quiz details
@@ -90,24 +145,47 @@ func (h *AttemptHandler) StartAttempt(c *gin.Context) {
 		return
 	}
 
-	// Check course completion
-	completed, err := h.courseService.CheckCourseCompletion(studentID.Hex(), quiz.CourseID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify course completion"})
+	practice := quiz.Practice
+	if practice == nil {
+		practice = &models.PracticeSettings{}
+	}
+	if mode == models.ModePractice && !practice.Enabled {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Practice is not enabled for this quiz"})
 		return
 	}
 
-	if !completed {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You must complete the required course before attempting this quiz"})
+	if mode == models.ModeRanked && quizClosed(&quiz, time.Now()) {
+		c.JSON(http.StatusForbidden, gin.H{"error": errQuizClosed})
 		return
 	}
 
+	// Check course completion, unless the quiz lets practice skip it
+	if mode == models.ModeRanked || !practice.SkipCourseGating {
+		completed, err := h.courseService.CheckCourseCompletion(ctx, studentID, quiz.CourseID)
+		if errors.Is(err, services.ErrCourseAPIUnavailable) {
+			log.Printf("StartAttempt: %v (course: %s)", err, quiz.CourseID)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Course completion can't be verified right now, please retry later"})
+			return
+		}
+		if err != nil {
+			log.Printf("StartAttempt: %v (course: %s)", err, quiz.CourseID)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify course completion"})
+			return
+		}
+
+		if !completed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You must complete the required course before attempting this quiz"})
+			return
+		}
+	}
+
 	// Check if student already has an ongoing attempt
 	var existingAttempt models.QuizAttempt
 	err = h.collection.FindOne(ctx, bson.M{
 		"quiz_id":      quizID,
 		"student_id":   studentID,
 		"completed_at": bson.M{"$exists": false},
+		"session_id":   bson.M{"$exists": false},
 	}).Decode(&existingAttempt)
 
 	if err == nil {
@@ -115,20 +193,40 @@ func (h *AttemptHandler) StartAttempt(c *gin.Context) {
 		return
 	}
 
-	// Calculate max score
-	maxScore := 0.0
-	for _, q := range quiz.Questions {
-		maxScore += float64(q.Points)
+	// Snapshot the scoring policy so later changes to the quiz don't affect this attempt
+	scoring, err := h.scoringService.ResolvePolicy(quiz.Scoring)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Quiz has an invalid scoring policy"})
+		return
+	}
+
+	// Calculate max score, including any bonuses
+	maxScore, err := h.scoringService.MaxScore(&quiz, scoring)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Quiz has an invalid scoring policy"})
+		return
 	}
 
 	// Create new attempt
 	attempt := models.QuizAttempt{
-		ID:        primitive.NewObjectID(),
-		QuizID:    quizID,
-		StudentID: studentID,
-		Answers:   []models.Answer{},
-		MaxScore:  maxScore,
-		StartedAt: time.Now(),
+		ID:          primitive.NewObjectID(),
+		QuizID:      quizID,
+		StudentID:   studentID,
+		Answers:     []models.Answer{},
+		MaxScore:    maxScore,
+		StartedAt:   time.Now(),
+		Mode:        mode,
+		Scoring:     scoring,
+		ChallengeID: challengeID,
+	}
+
+	// Adaptive attempts are served their questions one at a time; the max
+	// score depends on the questions served and is set on completion
+	if quiz.Adaptive != nil {
+		ability := services.EstimateAbility(nil)
+		attempt.Adaptive = true
+		attempt.MaxScore = 0
+		attempt.Ability = &ability
 	}
 
 	_, err = h.collection.InsertOne(ctx, attempt)
@@ -137,18 +235,191 @@ func (h *AttemptHandler) StartAttempt(c *gin.Context) {
 		return
 	}
 
+	if challengeID != nil {
+		if err := h.challenges.ClaimAttempt(ctx, *challengeID, quizID, studentID, attempt.ID); err != nil {
+			if _, delErr := h.collection.DeleteOne(ctx, bson.M{"_id": attempt.ID}); delErr != nil {
+				log.Printf("StartAttempt: %v (attempt: %s)", delErr, attempt.ID.Hex())
+			}
+			switch err {
+			case services.ErrChallengeNotFound:
+				c.JSON(http.StatusNotFound, gin.H{"error": "Challenge not found"})
+			case services.ErrChallengeNotActive, services.ErrChallengeWrongQuiz:
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Challenge is not active for this quiz"})
+			case services.ErrChallengeAlreadyUsed:
+				c.JSON(http.StatusConflict, gin.H{"error": "You already played this challenge"})
+			default:
+				log.Printf("StartAttempt: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start quiz attempt"})
+			}
+			return
+		}
+	}
+
 	// Return attempt info with questions (but not correct answers)
 	quizForAttempt := quiz
+	if attempt.Adaptive {
+		quizForAttempt.Questions = []models.Question{}
+	}
 	for i := range quizForAttempt.Questions {
-		quizForAttempt.Questions[i].CorrectAnswer = nil
+		quizForAttempt.Questions[i] = attemptQuestion(quizForAttempt.Questions[i])
 	}
 
+	h.events.Publish(services.EventAttemptStarted, quiz.ID, gin.H{
+		"attempt_id":   attempt.ID,
+		"student_id":   attempt.StudentID,
+		"student_name": h.studentName(ctx, attempt.StudentID),
+	})
+
 	c.JSON(http.StatusCreated, gin.H{
 		"attempt": attempt,
 		"quiz":    quizForAttempt,
 	})
 }
 
+// attemptQuestion returns a question as shown during an attempt, without its
+// answer, explanation or hints
+func attemptQuestion(question models.Question) models.Question {
+	question.CorrectAnswer = nil
+	question.Explanation = ""
+	question.HintCount = len(question.Hints)
+	question.Hints = nil
+	return question
+}
+
+// NextQuestion godoc
+// @Summary      Get the next adaptive question
+// @Description  Serve the next question of an adaptive attempt, the unseen question of the pool whose difficulty best matches the current ability estimate. The served question is returned again until it is answered or skipped; done is true once the attempt has seen all its questions
+// @Tags         attempts
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Attempt ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Router       /attempts/{id}/next [post]
+func (h *AttemptHandler) NextQuestion(c *gin.Context) {
+	attemptID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attempt ID"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	studentID := userID.(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var attempt models.QuizAttempt
+	err = h.collection.FindOne(ctx, bson.M{
+		"_id":        attemptID,
+		"student_id": studentID,
+	}).Decode(&attempt)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attempt not found"})
+		return
+	}
+
+	if attempt.CompletedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This attempt is already completed"})
+		return
+	}
+
+	if !attempt.Adaptive {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This attempt is not adaptive, its questions were returned when it started"})
+		return
+	}
+
+	var quiz models.Quiz
+	err = h.quizCollection.FindOne(ctx, bson.M{"_id": attempt.QuizID}).Decode(&quiz)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Quiz not found"})
+		return
+	}
+
+	if attempt.Mode != models.ModePractice && quizClosed(&quiz, time.Now()) {
+		c.JSON(http.StatusForbidden, gin.H{"error": errQuizClosed})
+		return
+	}
+
+	questionCount := services.AdaptiveQuestionCount(&quiz)
+	response := gin.H{
+		"question_count": questionCount,
+		"ability":        attempt.Ability,
+	}
+
+	// The last served question is returned again until it is answered
+	answered := make(map[primitive.ObjectID]bool, len(attempt.Answers))
+	for _, answer := range attempt.Answers {
+		answered[answer.QuestionID] = true
+	}
+	if served := len(attempt.QuestionIDs); served > 0 && !answered[attempt.QuestionIDs[served-1]] {
+		if question := findQuestion(&quiz, attempt.QuestionIDs[served-1]); question != nil {
+			response["done"] = false
+			response["number"] = served
+			response["question"] = attemptQuestion(*question)
+			c.JSON(http.StatusOK, response)
+			return
+		}
+	}
+
+	theta := 0.0
+	if attempt.Ability != nil {
+		theta = attempt.Ability.Theta
+	}
+	var question *models.Question
+	if len(attempt.QuestionIDs) < questionCount {
+		question = services.SelectNextQuestion(&quiz, attempt.QuestionIDs, theta)
+	}
+	if question == nil {
+		response["done"] = true
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	// Only serve if no other question was served concurrently
+	result, err := h.collection.UpdateOne(ctx, bson.M{
+		"_id":          attemptID,
+		"student_id":   studentID,
+		"completed_at": bson.M{"$exists": false},
+		"question_ids": servedQuestionsFilter(len(attempt.QuestionIDs)),
+	}, bson.M{"$push": bson.M{"question_ids": question.ID}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to serve question"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "The attempt changed while serving the question, please retry"})
+		return
+	}
+
+	response["done"] = false
+	response["number"] = len(attempt.QuestionIDs) + 1
+	response["question"] = attemptQuestion(*question)
+	c.JSON(http.StatusOK, response)
+}
+
+// servedQuestionsFilter matches adaptive attempts that were served exactly n questions
+func servedQuestionsFilter(n int) bson.M {
+	if n == 0 {
+		return bson.M{"$exists": false}
+	}
+	return bson.M{"$size": n}
+}
+
+// isServed reports whether a question was served to an adaptive attempt
+func isServed(attempt *models.QuizAttempt, questionID primitive.ObjectID) bool {
+	for _, id := range attempt.QuestionIDs {
+		if id == questionID {
+			return true
+		}
+	}
+	return false
+}
+
 // SubmitAnswerRequest represents a single answer submission
 type SubmitAnswerRequest struct {
 	AttemptID    string `json:"attempt_id" binding:"required" example:"507f1f77bcf86cd799439011"`
@@ -168,6 +439,7 @@ type SubmitAnswerRequest struct {
 // @Success      200 {object} map[string]interface{}
 // @Failure      400 {object} map[string]string
 // @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
 // @Failure      404 {object} map[string]string
 // @Router       /attempts/answer [post]
 func (h *AttemptHandler) SubmitAnswer(c *gin.Context) {
@@ -212,6 +484,11 @@ func (h *AttemptHandler) SubmitAnswer(c *gin.Context) {
 		return
 	}
 
+	if attempt.SessionID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errLiveSessionAttempt})
+		return
+	}
+
 	// Get quiz and question
 	var quiz models.Quiz
 	err = h.quizCollection.FindOne(ctx, bson.M{"_id": attempt.QuizID}).Decode(&quiz)
@@ -220,20 +497,23 @@ func (h *AttemptHandler) SubmitAnswer(c *gin.Context) {
 		return
 	}
 
-	var question *models.Question
-	for i := range quiz.Questions {
-		if quiz.Questions[i].ID == questionID {
-			question = &quiz.Questions[i]
-			break
-		}
+	if attempt.Mode != models.ModePractice && quizClosed(&quiz, time.Now()) {
+		c.JSON(http.StatusForbidden, gin.H{"error": errQuizClosed})
+		return
 	}
 
+	question := findQuestion(&quiz, questionID)
 	if question == nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Question not found in quiz"})
 		return
 	}
 
-	// Check if answer already submitted
+	if attempt.Adaptive && !isServed(&attempt, questionID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errQuestionNotServed})
+		return
+	}
+
+	// Check if answer already submitted (re-checked atomically when saving)
 	for _, ans := range attempt.Answers {
 		if ans.QuestionID == questionID {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Answer already submitted for this question"})
@@ -249,56 +529,77 @@ func (h *AttemptHandler) SubmitAnswer(c *gin.Context) {
 
 	// Check if answer is correct
 	// Convert both answers to string for comparison
-	isCorrect := false
-	correctAnswerStr := ""
+	isCorrect := req.Answer == correctAnswerString(question.CorrectAnswer)
 
-	switch v := question.CorrectAnswer.(type) {
-	case string:
-		correctAnswerStr = v
-	case bool:
-		if v {
-			correctAnswerStr = "true"
-		} else {
-			correctAnswerStr = "false"
-		}
-	case int:
-		correctAnswerStr = string(rune(v + '0'))
-	case float64:
-		correctAnswerStr = string(rune(int(v) + '0'))
-	}
-
-	isCorrect = req.Answer == correctAnswerStr
-
-	// Calculate score
-	pointsEarned := h.scoringService.CalculateScore(question.Points, req.TimeToAnswer, isCorrect)
-
-	// Create answer
+	// Create answer, carrying over hints and lifelines used on the question
 	answer := models.Answer{
 		QuestionID:    questionID,
 		StudentAnswer: req.Answer,
 		IsCorrect:     isCorrect,
 		TimeToAnswer:  req.TimeToAnswer,
-		PointsEarned:  pointsEarned,
 		AnsweredAt:    time.Now(),
+		Assists:       assistsForQuestion(attempt.Assists, questionID),
 	}
 
+	// Calculate score with the scoring policy snapshotted on the attempt
+	breakdown, err := h.scoringService.ScoreNextAnswer(&quiz, &attempt, question, &answer)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid scoring policy"})
+		return
+	}
+	pointsEarned := breakdown.Total
+	answer.PointsEarned = pointsEarned
+	answer.Breakdown = &breakdown
+
 	// Update attempt with new answer
 	update := bson.M{
 		"$push": bson.M{"answers": answer},
 		"$inc":  bson.M{"total_score": pointsEarned},
 	}
 
-	_, err = h.collection.UpdateOne(ctx, bson.M{"_id": attemptID}, update)
+	// Adaptive attempts update the ability estimate that picks the next question
+	var ability *models.AbilityEstimate
+	if attempt.Adaptive {
+		estimate := services.AttemptAbility(&quiz, append(attempt.Answers, answer))
+		ability = &estimate
+		update["$set"] = bson.M{"ability": ability}
+	}
+
+	result, err := h.collection.UpdateOne(ctx, answerFilter(attemptID, studentID, questionID), update)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save answer"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	if result.MatchedCount == 0 {
+		// Lost a race with another submission or with completion
+		completed, _ := h.collection.CountDocuments(ctx, bson.M{
+			"_id":          attemptID,
+			"completed_at": bson.M{"$exists": true},
+		})
+		if completed > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "This attempt is already completed"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Answer already submitted for this question"})
+		return
+	}
+
+	response := gin.H{
 		"is_correct":    isCorrect,
 		"points_earned": pointsEarned,
+		"breakdown":     breakdown,
 		"message":       "Answer submitted successfully",
-	})
+	}
+	if ability != nil {
+		response["ability"] = ability
+	}
+	// Practice gives feedback right away, unless the review policy withholds answers
+	if attempt.Mode == models.ModePractice && services.AnswersRevealed(&quiz, time.Now()) {
+		response["correct_answer"] = correctAnswerString(question.CorrectAnswer)
+		response["explanation"] = question.Explanation
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // CompleteAttemptRequest defines the request body for completing an attempt.
@@ -318,6 +619,7 @@ type CompleteAttemptRequest struct {
 // @Failure      400 {object} map[string]string
 // @Failure      401 {object} map[string]string
 // @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
 // @Router       /attempts/complete [put]
 func (h *AttemptHandler) CompleteAttempt(c *gin.Context) {
 	var req CompleteAttemptRequest
@@ -338,46 +640,187 @@ func (h *AttemptHandler) CompleteAttempt(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Get attempt
 	var attempt models.QuizAttempt
-	err = h.collection.FindOne(ctx, bson.M{
-		"_id":        objectID,
-		"student_id": studentID,
-	}).Decode(&attempt)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Attempt not found"})
-		return
+	var quiz models.Quiz
+
+	// The final score is recomputed from the stored answers. The update only
+	// applies if no answer was added since they were read; otherwise retry.
+	const maxCompletionTries = 3
+	for try := 1; ; try++ {
+		// Get attempt
+		err = h.collection.FindOne(ctx, bson.M{
+			"_id":        objectID,
+			"student_id": studentID,
+		}).Decode(&attempt)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attempt not found"})
+			return
+		}
+
+		if attempt.CompletedAt != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Attempt already completed"})
+			return
+		}
+
+		if attempt.SessionID != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errLiveSessionAttempt})
+			return
+		}
+
+		if quiz.ID.IsZero() {
+			err = h.quizCollection.FindOne(ctx, bson.M{"_id": attempt.QuizID}).Decode(&quiz)
+			if err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Quiz not found"})
+				return
+			}
+		}
+
+		filter := completionFilter(&attempt)
+
+		// Questions an adaptive attempt did not reach count as unanswered
+		if attempt.Adaptive {
+			services.FillAdaptiveQuestions(&quiz, &attempt)
+			ability := services.AttemptAbility(&quiz, attempt.Answers)
+			attempt.Ability = &ability
+		}
+
+		now := time.Now()
+		attempt.CompletedAt = &now
+		attempt.TimeTaken = int(now.Sub(attempt.StartedAt).Seconds())
+		if err := h.scoringService.ScoreAttempt(&quiz, &attempt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to score attempt"})
+			return
+		}
+
+		update := bson.M{"$set": completedAttemptFields(&attempt)}
+
+		result, err := h.collection.UpdateOne(ctx, filter, update)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete attempt"})
+			return
+		}
+		if result.MatchedCount > 0 {
+			break
+		}
+
+		if try == maxCompletionTries {
+			c.JSON(http.StatusConflict, gin.H{"error": "Attempt was modified concurrently, please retry"})
+			return
+		}
 	}
 
-	if attempt.CompletedAt != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Attempt already completed"})
+	if err := h.reviews.QueueMissed(ctx, &quiz, &attempt); err != nil {
+		log.Printf("CompleteAttempt: %v (attempt: %s)", err, attempt.ID.Hex())
+	}
+
+	// Practice attempts stop here: no quiz stats, leaderboard, rewards or events
+	if attempt.Mode == models.ModePractice {
+		c.JSON(http.StatusOK, attempt)
 		return
 	}
 
-	now := time.Now()
-	timeTaken := int(now.Sub(attempt.StartedAt).Seconds())
+	if err := h.statsService.RecordCompletion(ctx, attempt.QuizID, attempt.Percentage); err != nil {
+		log.Printf("CompleteAttempt: %v (quiz: %s)", err, attempt.QuizID.Hex())
+	}
+	if err := h.leaderboards.Record(ctx, &quiz, &attempt); err != nil {
+		log.Printf("CompleteAttempt: %v (attempt: %s)", err, attempt.ID.Hex())
+	}
+	if attempt.ChallengeID != nil {
+		if err := h.challenges.Resolve(ctx, *attempt.ChallengeID); err != nil {
+			log.Printf("CompleteAttempt: %v (challenge: %s)", err, attempt.ChallengeID.Hex())
+		}
+	}
+	awarded, xp, err := h.achievements.Evaluate(ctx, &attempt)
+	if err != nil {
+		log.Printf("CompleteAttempt: %v (attempt: %s)", err, attempt.ID.Hex())
+	}
+	attempt.XPEarned = xp
+	attempt.NewAchievements = awarded
+	h.publishCompletion(ctx, &attempt)
 
-	update := bson.M{
-		"$set": bson.M{
-			"completed_at": now,
-			"time_taken":   timeTaken,
-		},
+	c.JSON(http.StatusOK, attempt)
+}
+
+// answerFilter matches an attempt while it is in progress and has no answer
+// to the question, so concurrent submissions and completions cannot
+// double-count points
+func answerFilter(attemptID, studentID, questionID primitive.ObjectID) bson.M {
+	return bson.M{
+		"_id":                 attemptID,
+		"student_id":          studentID,
+		"completed_at":        bson.M{"$exists": false},
+		"answers.question_id": bson.M{"$ne": questionID},
 	}
+}
 
-	_, err = h.collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete attempt"})
-		return
+// completionFilter matches an attempt while it is in progress with exactly the
+// answers, and for adaptive attempts the served questions, it was read with,
+// so a completion never scores an attempt that changed since
+func completionFilter(attempt *models.QuizAttempt) bson.M {
+	filter := bson.M{
+		"_id":          attempt.ID,
+		"student_id":   attempt.StudentID,
+		"completed_at": bson.M{"$exists": false},
+		"answers":      bson.M{"$size": len(attempt.Answers)},
 	}
+	if attempt.Adaptive {
+		filter["question_ids"] = servedQuestionsFilter(len(attempt.QuestionIDs))
+	}
+	return filter
+}
+
+// completedAttemptFields returns the fields stored when a scored attempt is completed
+func completedAttemptFields(attempt *models.QuizAttempt) bson.M {
+	fields := bson.M{
+		"completed_at":     attempt.CompletedAt,
+		"time_taken":       attempt.TimeTaken,
+		"answers":          attempt.Answers,
+		"total_score":      attempt.TotalScore,
+		"max_score":        attempt.MaxScore,
+		"correct_count":    attempt.CorrectCount,
+		"incorrect_count":  attempt.IncorrectCount,
+		"unanswered_count": attempt.UnansweredCount,
+		"percentage":       attempt.Percentage,
+		"scoring_version":  attempt.ScoringVersion,
+	}
+	if attempt.Adaptive {
+		fields["question_ids"] = attempt.QuestionIDs
+		fields["ability"] = attempt.Ability
+	}
+	return fields
+}
 
-	// Get updated attempt
-	err = h.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&attempt)
+// publishCompletion announces a completed attempt with its rank on the quiz leaderboard
+func (h *AttemptHandler) publishCompletion(ctx context.Context, attempt *models.QuizAttempt) {
+	data := gin.H{
+		"attempt_id":   attempt.ID,
+		"student_id":   attempt.StudentID,
+		"student_name": h.studentName(ctx, attempt.StudentID),
+		"score":        attempt.TotalScore,
+		"max_score":    attempt.MaxScore,
+		"percentage":   attempt.Percentage,
+		"time_taken":   attempt.TimeTaken,
+	}
+
+	rank, total, err := h.leaderboards.Rank(ctx, attempt.QuizID, attempt.TotalScore, false)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve attempt"})
-		return
+		log.Printf("CompleteAttempt: %v (quiz: %s)", err, attempt.QuizID.Hex())
+	} else {
+		data["rank"] = rank
+		data["total_participants"] = total
 	}
 
-	c.JSON(http.StatusOK, attempt)
+	h.events.Publish(services.EventAttemptCompleted, attempt.QuizID, data)
+}
+
+// studentName returns a student's display name, or an empty string if unknown
+func (h *AttemptHandler) studentName(ctx context.Context, studentID primitive.ObjectID) string {
+	var user models.User
+	opts := options.FindOne().SetProjection(bson.M{"first_name": 1, "last_name": 1})
+	if err := h.userCollection.FindOne(ctx, bson.M{"_id": studentID}, opts).Decode(&user); err != nil {
+		return ""
+	}
+	return user.FirstName + " " + user.LastName
 }
 
 // GetAttemptByID godoc
@@ -454,3 +897,431 @@ func (h *AttemptHandler) GetMyAttempts(c *gin.Context) {
 
 	c.JSON(http.StatusOK, attempts)
 }
+
+// GetAttemptReview godoc
+// @Summary      Review a completed attempt
+// @Description  Get each question of a completed attempt with the student's answer, and the correct answer and explanation when the quiz review policy allows it
+// @Tags         attempts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Attempt ID"
+// @Success      200 {object} models.AttemptReview
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /attempts/{id}/review [get]
+func (h *AttemptHandler) GetAttemptReview(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attempt ID"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	studentID := userID.(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var attempt models.QuizAttempt
+	err = h.collection.FindOne(ctx, bson.M{
+		"_id":        objectID,
+		"student_id": studentID,
+	}).Decode(&attempt)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attempt not found"})
+		return
+	}
+
+	if attempt.CompletedAt == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Attempt must be completed before it can be reviewed"})
+		return
+	}
+
+	var quiz models.Quiz
+	err = h.quizCollection.FindOne(ctx, bson.M{"_id": attempt.QuizID}).Decode(&quiz)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Quiz not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, buildAttemptReview(&quiz, &attempt, time.Now(), false))
+}
+
+// buildAttemptReview joins an attempt with its quiz, hiding correct answers
+// and explanations unless the quiz review policy allows them at the given time.
+// revealAnswers bypasses the policy for staff views.
+func buildAttemptReview(quiz *models.Quiz, attempt *models.QuizAttempt, now time.Time, revealAnswers bool) models.AttemptReview {
+	quiz = services.AttemptQuiz(quiz, attempt)
+	policy := quiz.ReviewPolicy
+	if policy == "" {
+		policy = models.ReviewImmediately
+	}
+
+	review := models.AttemptReview{
+		AttemptID:    attempt.ID,
+		QuizID:       quiz.ID,
+		QuizTitle:    quiz.Title,
+		TotalScore:   attempt.TotalScore,
+		MaxScore:     attempt.MaxScore,
+		TimeTaken:    attempt.TimeTaken,
+		ReviewPolicy: policy,
+		Questions:    make([]models.QuestionReview, 0, len(quiz.Questions)),
+	}
+	if attempt.CompletedAt != nil {
+		review.CompletedAt = *attempt.CompletedAt
+	}
+
+	review.AnswersVisible = services.AnswersRevealed(quiz, now)
+	if policy == models.ReviewAfterClose {
+		review.AvailableAt = quiz.ClosesAt
+	}
+	if revealAnswers {
+		review.AnswersVisible = true
+	}
+
+	answers := make(map[primitive.ObjectID]models.Answer, len(attempt.Answers))
+	for _, ans := range attempt.Answers {
+		answers[ans.QuestionID] = ans
+	}
+
+	for _, q := range quiz.Questions {
+		item := models.QuestionReview{
+			QuestionID:   q.ID,
+			QuestionText: q.QuestionText,
+			Type:         q.Type,
+			Options:      q.Options,
+			Points:       q.Points,
+		}
+
+		if ans, ok := answers[q.ID]; ok {
+			item.Answered = true
+			item.StudentAnswer = ans.StudentAnswer
+			item.IsCorrect = ans.IsCorrect
+			item.PointsEarned = ans.PointsEarned
+			item.TimeToAnswer = ans.TimeToAnswer
+		}
+
+		if review.AnswersVisible {
+			item.CorrectAnswer = q.CorrectAnswer
+			item.Explanation = q.Explanation
+		}
+
+		review.Questions = append(review.Questions, item)
+	}
+
+	return review
+}
+
+// correctAnswerString converts a stored correct answer to the string form
+// submitted by clients ("true"/"false" or the option index)
+func correctAnswerString(correctAnswer interface{}) string {
+	switch v := correctAnswer.(type) {
+	case string:
+		return v
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	case int:
+		return strconv.Itoa(v)
+	case int32:
+		return strconv.Itoa(int(v))
+	case int64:
+		return strconv.Itoa(int(v))
+	case float64:
+		return strconv.Itoa(int(v))
+	}
+	return ""
+}
+
+// UseAssistRequest represents a request to reveal a hint or use a lifeline
+type UseAssistRequest struct {
+	QuestionID string            `json:"question_id" binding:"required" example:"507f1f77bcf86cd799439012"`
+	Type       models.AssistType `json:"type" binding:"required" enums:"hint,fifty_fifty,skip" example:"hint"`
+}
+
+// UseAssist godoc
+// @Summary      Use a hint or lifeline
+// @Description  Reveal the next hint of a question, remove half of the options, all wrong ones (fifty_fifty) or skip a question during an in-progress attempt. Penalties configured on the quiz are deducted from the question's points
+// @Tags         attempts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Attempt ID"
+// @Param        request body UseAssistRequest true "Question and assist type"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Router       /attempts/{id}/assist [post]
+func (h *AttemptHandler) UseAssist(c *gin.Context) {
+	var req UseAssistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	attemptID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attempt ID"})
+		return
+	}
+
+	questionID, err := primitive.ObjectIDFromHex(req.QuestionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question ID"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	studentID := userID.(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var attempt models.QuizAttempt
+	err = h.collection.FindOne(ctx, bson.M{
+		"_id":        attemptID,
+		"student_id": studentID,
+	}).Decode(&attempt)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attempt not found"})
+		return
+	}
+
+	if attempt.CompletedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This attempt is already completed"})
+		return
+	}
+
+	if attempt.SessionID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errLiveSessionAttempt})
+		return
+	}
+
+	var quiz models.Quiz
+	err = h.quizCollection.FindOne(ctx, bson.M{"_id": attempt.QuizID}).Decode(&quiz)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Quiz not found"})
+		return
+	}
+
+	question := findQuestion(&quiz, questionID)
+	if question == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Question not found in quiz"})
+		return
+	}
+
+	if attempt.Adaptive && !isServed(&attempt, questionID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errQuestionNotServed})
+		return
+	}
+
+	for _, ans := range attempt.Answers {
+		if ans.QuestionID == questionID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Answer already submitted for this question"})
+			return
+		}
+	}
+
+	used := assistsForQuestion(attempt.Assists, questionID)
+	usage := models.AssistUsage{
+		QuestionID: questionID,
+		Type:       req.Type,
+		UsedAt:     time.Now(),
+	}
+	response := gin.H{
+		"question_id": questionID,
+		"type":        req.Type,
+	}
+
+	// Only apply while the attempt is in progress and the question unanswered
+	filter := answerFilter(attemptID, studentID, questionID)
+
+	switch req.Type {
+	case models.AssistHint:
+		index := 0
+		for _, assist := range used {
+			if assist.Type == models.AssistHint {
+				index++
+			}
+		}
+		if index >= len(question.Hints) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No more hints available for this question"})
+			return
+		}
+
+		usage.HintIndex = index
+		usage.Penalty = question.Hints[index].Penalty
+		// Guard against revealing the same hint twice concurrently
+		filter["assists"] = bson.M{"$not": bson.M{"$elemMatch": bson.M{
+			"question_id": questionID,
+			"type":        models.AssistHint,
+			"hint_index":  index,
+		}}}
+
+		response["hint"] = question.Hints[index].Text
+		response["hint_index"] = index
+		response["remaining_hints"] = len(question.Hints) - index - 1
+
+	case models.AssistFiftyFifty, models.AssistSkip:
+		lifeline := findLifeline(&quiz, req.Type)
+		if lifeline == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "This lifeline is not available for this quiz"})
+			return
+		}
+		for _, assist := range used {
+			if assist.Type == req.Type {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Lifeline already used on this question"})
+				return
+			}
+		}
+		usesLeft := lifeline.Uses - len(assistsOfType(attempt.Assists, req.Type))
+		if usesLeft <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No uses left for this lifeline"})
+			return
+		}
+
+		usage.Penalty = lifeline.Penalty
+		// Guard against exceeding the allowed uses concurrently
+		filter["$expr"] = bson.M{"$lt": bson.A{
+			bson.M{"$size": bson.M{"$filter": bson.M{
+				"input": bson.M{"$ifNull": bson.A{"$assists", bson.A{}}},
+				"cond":  bson.M{"$eq": bson.A{"$$this.type", req.Type}},
+			}}},
+			lifeline.Uses,
+		}}
+
+		if req.Type == models.AssistFiftyFifty {
+			removed, ok := fiftyFiftyOptions(question)
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "50/50 is only available on multiple choice questions with at least 3 options"})
+				return
+			}
+			usage.RemovedOptions = removed
+			response["removed_options"] = removed
+		}
+		response["uses_left"] = usesLeft - 1
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Type must be 'hint', 'fifty_fifty' or 'skip'"})
+		return
+	}
+	response["penalty"] = usage.Penalty
+
+	update := bson.M{"$push": bson.M{"assists": usage}}
+
+	// A skip records an empty answer so the question can no longer be answered
+	if req.Type == models.AssistSkip {
+		answer := models.Answer{
+			QuestionID: questionID,
+			Skipped:    true,
+			AnsweredAt: usage.UsedAt,
+			Assists:    append(used, usage),
+		}
+		breakdown, err := h.scoringService.ScoreNextAnswer(&quiz, &attempt, question, &answer)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid scoring policy"})
+			return
+		}
+		answer.PointsEarned = breakdown.Total
+		answer.Breakdown = &breakdown
+
+		update["$push"] = bson.M{"assists": usage, "answers": answer}
+		update["$inc"] = bson.M{"total_score": answer.PointsEarned}
+		response["points_earned"] = answer.PointsEarned
+	}
+
+	result, err := h.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record assist"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "The attempt changed while applying the assist, please retry"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// quizClosed reports whether the quiz closed at the given time
+func quizClosed(quiz *models.Quiz, now time.Time) bool {
+	return quiz.ClosesAt != nil && !now.Before(*quiz.ClosesAt)
+}
+
+// findQuestion returns the question with the given ID, or nil
+func findQuestion(quiz *models.Quiz, questionID primitive.ObjectID) *models.Question {
+	for i := range quiz.Questions {
+		if quiz.Questions[i].ID == questionID {
+			return &quiz.Questions[i]
+		}
+	}
+	return nil
+}
+
+// findLifeline returns the quiz's configuration of a lifeline, or nil if it is not offered
+func findLifeline(quiz *models.Quiz, assistType models.AssistType) *models.Lifeline {
+	for i := range quiz.Lifelines {
+		if quiz.Lifelines[i].Type == assistType {
+			return &quiz.Lifelines[i]
+		}
+	}
+	return nil
+}
+
+// assistsForQuestion returns the assists used on one question
+func assistsForQuestion(assists []models.AssistUsage, questionID primitive.ObjectID) []models.AssistUsage {
+	var result []models.AssistUsage
+	for _, assist := range assists {
+		if assist.QuestionID == questionID {
+			result = append(result, assist)
+		}
+	}
+	return result
+}
+
+// assistsOfType returns the assists of one type
+func assistsOfType(assists []models.AssistUsage, assistType models.AssistType) []models.AssistUsage {
+	var result []models.AssistUsage
+	for _, assist := range assists {
+		if assist.Type == assistType {
+			result = append(result, assist)
+		}
+	}
+	return result
+}
+
+// fiftyFiftyOptions picks the wrong options to remove from a multiple choice
+// question: half of its options, rounded down, while always leaving at least
+// one wrong option besides the correct one. That removes 1 of 3 options, 2 of
+// 4 or 5 and 3 of 6; a question with 2 options has nothing to remove
+func fiftyFiftyOptions(question *models.Question) ([]int, bool) {
+	if question.Type != models.QuestionTypeMultipleChoice || len(question.Options) < 3 {
+		return nil, false
+	}
+
+	correct, err := strconv.Atoi(correctAnswerString(question.CorrectAnswer))
+	if err != nil {
+		return nil, false
+	}
+
+	wrong := make([]int, 0, len(question.Options)-1)
+	for i := range question.Options {
+		if i != correct {
+			wrong = append(wrong, i)
+		}
+	}
+	rand.Shuffle(len(wrong), func(i, j int) { wrong[i], wrong[j] = wrong[j], wrong[i] })
+
+	removed := wrong[:min(len(question.Options)/2, len(wrong)-1)]
+	if len(removed) == 0 {
+		return nil, false
+	}
+	sort.Ints(removed)
+	return removed, true
+}
@@ -0,0 +1,363 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"quizmasterapi/models"
+	"quizmasterapi/services"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// attemptDocument holds one attempt as MongoDB stores it and applies
+// conditional updates to it atomically, as MongoDB does for a single
+// document. It understands the query and update operators the attempt
+// handler uses on answers and completion
+type attemptDocument struct {
+	t   *testing.T
+	mu  sync.Mutex
+	doc bson.M
+}
+
+func newAttemptDocument(t *testing.T, attempt *models.QuizAttempt) *attemptDocument {
+	d := &attemptDocument{t: t}
+	d.doc = d.normalize(attempt)
+	return d
+}
+
+// normalize round-trips a value through BSON, as it would be stored
+func (d *attemptDocument) normalize(v interface{}) bson.M {
+	data, err := bson.Marshal(v)
+	if err != nil {
+		d.t.Fatalf("marshal: %v", err)
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		d.t.Fatalf("unmarshal: %v", err)
+	}
+	return doc
+}
+
+// Attempt returns the stored attempt
+func (d *attemptDocument) Attempt() models.QuizAttempt {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	data, err := bson.Marshal(d.doc)
+	if err != nil {
+		d.t.Fatalf("marshal: %v", err)
+	}
+	var attempt models.QuizAttempt
+	if err := bson.Unmarshal(data, &attempt); err != nil {
+		d.t.Fatalf("unmarshal: %v", err)
+	}
+	return attempt
+}
+
+// UpdateOne applies the update if the filter matches and reports whether it did
+func (d *attemptDocument) UpdateOne(filter, update bson.M) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for key, condition := range filter {
+		if !d.matches(key, condition) {
+			return false
+		}
+	}
+
+	for op, fields := range update {
+		for key, value := range d.normalize(fields) {
+			switch op {
+			case "$set":
+				d.doc[key] = value
+			case "$inc":
+				d.doc[key] = d.doc[key].(float64) + value.(float64)
+			case "$push":
+				items, _ := d.doc[key].(bson.A)
+				d.doc[key] = append(items, value)
+			default:
+				d.t.Fatalf("unsupported update operator %s", op)
+			}
+		}
+	}
+	return true
+}
+
+func (d *attemptDocument) matches(key string, condition interface{}) bool {
+	operators, ok := condition.(bson.M)
+	if !ok {
+		return d.doc[key] == condition
+	}
+
+	for op, operand := range operators {
+		switch op {
+		case "$exists":
+			_, exists := d.doc[key]
+			if exists != operand.(bool) {
+				return false
+			}
+		case "$size":
+			items, isArray := d.doc[key].(bson.A)
+			if !isArray || len(items) != operand.(int) {
+				return false
+			}
+		case "$ne":
+			// Only array element paths like answers.question_id are used
+			array, field, _ := strings.Cut(key, ".")
+			items, _ := d.doc[array].(bson.A)
+			for _, item := range items {
+				if item.(bson.M)[field] == operand {
+					return false
+				}
+			}
+		default:
+			d.t.Fatalf("unsupported query operator %s", op)
+		}
+	}
+	return true
+}
+
+func newConcurrencyQuiz() *models.Quiz {
+	quiz := &models.Quiz{ID: primitive.NewObjectID()}
+	for i := 0; i < 4; i++ {
+		quiz.Questions = append(quiz.Questions, models.Question{
+			ID:        primitive.NewObjectID(),
+			Points:    10,
+			TimeLimit: 30,
+		})
+	}
+	return quiz
+}
+
+func newInProgressAttempt(quiz *models.Quiz) *models.QuizAttempt {
+	return &models.QuizAttempt{
+		ID:        primitive.NewObjectID(),
+		QuizID:    quiz.ID,
+		StudentID: primitive.NewObjectID(),
+		Answers:   []models.Answer{},
+		StartedAt: time.Now().Add(-time.Minute),
+		Scoring:   &models.ScoringPolicy{Strategy: services.StrategyAccuracy},
+	}
+}
+
+// submitAnswer saves an answer the way SubmitAnswer does and reports whether it was saved
+func submitAnswer(store *attemptDocument, scoring *services.ScoringService, quiz *models.Quiz, question *models.Question) (bool, error) {
+	attempt := store.Attempt()
+	answer := models.Answer{QuestionID: question.ID, IsCorrect: true, TimeToAnswer: 5, AnsweredAt: time.Now()}
+	breakdown, err := scoring.ScoreNextAnswer(quiz, &attempt, question, &answer)
+	if err != nil {
+		return false, err
+	}
+	answer.PointsEarned = breakdown.Total
+
+	return store.UpdateOne(answerFilter(attempt.ID, attempt.StudentID, question.ID), bson.M{
+		"$push": bson.M{"answers": answer},
+		"$inc":  bson.M{"total_score": answer.PointsEarned},
+	}), nil
+}
+
+// completeAttempt completes the attempt the way CompleteAttempt does, retrying
+// when answers arrive between reading and completing it. It reports whether
+// this call completed the attempt
+func completeAttempt(store *attemptDocument, scoring *services.ScoringService, quiz *models.Quiz) (bool, error) {
+	for try := 1; try <= 3; try++ {
+		attempt := store.Attempt()
+		if attempt.CompletedAt != nil {
+			return false, nil
+		}
+		filter := completionFilter(&attempt)
+
+		now := time.Now()
+		attempt.CompletedAt = &now
+		attempt.TimeTaken = int(now.Sub(attempt.StartedAt).Seconds())
+		if err := scoring.ScoreAttempt(quiz, &attempt); err != nil {
+			return false, err
+		}
+		if store.UpdateOne(filter, bson.M{"$set": completedAttemptFields(&attempt)}) {
+			return true, nil
+		}
+	}
+	return false, fmt.Errorf("attempt kept changing while completing it")
+}
+
+func TestAnswerFilterRejectsDuplicateAnswers(t *testing.T) {
+	quiz := newConcurrencyQuiz()
+	store := newAttemptDocument(t, newInProgressAttempt(quiz))
+	scoring := services.NewScoringService()
+
+	for i, want := range []bool{true, false} {
+		saved, err := submitAnswer(store, scoring, quiz, &quiz.Questions[0])
+		if err != nil || saved != want {
+			t.Fatalf("submit %d: saved %v (%v), want %v", i, saved, err, want)
+		}
+	}
+	if answers := len(store.Attempt().Answers); answers != 1 {
+		t.Fatalf("stored %d answers, want 1", answers)
+	}
+}
+
+func TestCompletionFilterRejectsStaleAttempts(t *testing.T) {
+	quiz := newConcurrencyQuiz()
+	store := newAttemptDocument(t, newInProgressAttempt(quiz))
+	scoring := services.NewScoringService()
+
+	// An attempt read before an answer was saved no longer matches
+	stale := store.Attempt()
+	if _, err := submitAnswer(store, scoring, quiz, &quiz.Questions[0]); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	stale.CompletedAt = &now
+	if store.UpdateOne(completionFilter(&stale), bson.M{"$set": completedAttemptFields(&stale)}) {
+		t.Fatal("completed an attempt from a stale read")
+	}
+
+	// A completed attempt takes no more answers and can't be completed again
+	if completed, err := completeAttempt(store, scoring, quiz); err != nil || !completed {
+		t.Fatalf("complete: %v (%v), want completed", completed, err)
+	}
+	if saved, err := submitAnswer(store, scoring, quiz, &quiz.Questions[1]); err != nil || saved {
+		t.Fatalf("answer after completion: saved %v (%v), want rejected", saved, err)
+	}
+	if completed, err := completeAttempt(store, scoring, quiz); err != nil || completed {
+		t.Fatalf("second completion: %v (%v), want rejected", completed, err)
+	}
+}
+
+// TestConcurrentSubmitsAndCompletions sends duplicate answers to every
+// question while completing the attempt several times concurrently. Each
+// question must be answered at most once, the attempt completed once, and the
+// completed score must cover exactly the stored answers
+func TestConcurrentSubmitsAndCompletions(t *testing.T) {
+	for run := 0; run < 20; run++ {
+		quiz := newConcurrencyQuiz()
+		store := newAttemptDocument(t, newInProgressAttempt(quiz))
+		scoring := services.NewScoringService()
+
+		var mu sync.Mutex
+		saved := map[primitive.ObjectID]int{}
+		completions, conflicts := 0, 0
+
+		var wg sync.WaitGroup
+		for i := range quiz.Questions {
+			for dup := 0; dup < 5; dup++ {
+				wg.Add(1)
+				go func(question *models.Question) {
+					defer wg.Done()
+					ok, err := submitAnswer(store, scoring, quiz, question)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					if ok {
+						mu.Lock()
+						saved[question.ID]++
+						mu.Unlock()
+					}
+				}(&quiz.Questions[i])
+			}
+		}
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				completed, err := completeAttempt(store, scoring, quiz)
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case err != nil:
+					conflicts++
+				case completed:
+					completions++
+				}
+			}()
+		}
+		wg.Wait()
+
+		if completions > 1 {
+			t.Fatalf("run %d: attempt completed %d times", run, completions)
+		}
+		if completions == 0 {
+			// Every completion lost its retries to new answers; the client retries
+			if completed, err := completeAttempt(store, scoring, quiz); err != nil || !completed {
+				t.Fatalf("run %d: completion after %d conflicts: %v (%v)", run, conflicts, completed, err)
+			}
+		}
+
+		attempt := store.Attempt()
+		if attempt.CompletedAt == nil {
+			t.Fatalf("run %d: attempt not completed", run)
+		}
+		seen := map[primitive.ObjectID]bool{}
+		total := 0.0
+		for _, answer := range attempt.Answers {
+			if seen[answer.QuestionID] {
+				t.Fatalf("run %d: question %s answered twice", run, answer.QuestionID.Hex())
+			}
+			seen[answer.QuestionID] = true
+			total += answer.PointsEarned
+		}
+		for id, count := range saved {
+			if count != 1 || !seen[id] {
+				t.Fatalf("run %d: question %s saved %d times, stored %v", run, id.Hex(), count, seen[id])
+			}
+		}
+		if len(seen) != len(saved) {
+			t.Fatalf("run %d: %d answers stored, %d saved", run, len(seen), len(saved))
+		}
+		if attempt.TotalScore != total || attempt.CorrectCount != len(attempt.Answers) {
+			t.Fatalf("run %d: total %v with %d correct, want %v with %d", run, attempt.TotalScore, attempt.CorrectCount, total, len(attempt.Answers))
+		}
+	}
+}
+
+func TestFiftyFiftyOptions(t *testing.T) {
+	tests := []struct {
+		options   int
+		available bool
+		removed   int
+	}{
+		{options: 2, available: false},
+		{options: 3, available: true, removed: 1},
+		{options: 4, available: true, removed: 2},
+		{options: 5, available: true, removed: 2},
+		{options: 6, available: true, removed: 3},
+	}
+
+	for _, tc := range tests {
+		for correct := 0; correct < tc.options; correct++ {
+			question := &models.Question{
+				Type:          models.QuestionTypeMultipleChoice,
+				Options:       make([]string, tc.options),
+				CorrectAnswer: correct,
+			}
+			// Options are picked at random, so try a few times
+			for try := 0; try < 10; try++ {
+				removed, ok := fiftyFiftyOptions(question)
+				if ok != tc.available {
+					t.Fatalf("%d options: available %v, want %v", tc.options, ok, tc.available)
+				}
+				if len(removed) != tc.removed {
+					t.Fatalf("%d options: removed %v, want %d options", tc.options, removed, tc.removed)
+				}
+				seen := map[int]bool{}
+				for _, option := range removed {
+					if option == correct || option < 0 || option >= tc.options || seen[option] {
+						t.Fatalf("%d options, correct %d: removed %v", tc.options, correct, removed)
+					}
+					seen[option] = true
+				}
+			}
+		}
+	}
+
+	trueFalse := &models.Question{Type: models.QuestionTypeTrueFalse, Options: []string{"true", "false", "maybe"}, CorrectAnswer: "true"}
+	if _, ok := fiftyFiftyOptions(trueFalse); ok {
+		t.Fatal("50/50 available on a question that is not multiple choice")
+	}
+}
@@ -0,0 +1,1087 @@
+// Package handlers provides HTTP request handlers
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"quizmasterapi/config"
+	"quizmasterapi/models"
+	"quizmasterapi/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	auditEntityAttempt = "attempt"
+	auditEntityQuiz    = "quiz"
+)
+
+// Tries of an attempt adjustment that keeps conflicting with concurrent ones
+const maxAdjustTries = 3
+
+// errAttemptConflict is returned when an attempt keeps being adjusted concurrently
+var errAttemptConflict = errors.New("attempt was modified concurrently")
+
+// AttemptManagementHandler handles professor-facing attempt management requests
+type AttemptManagementHandler struct {
+	collection     *mongo.Collection
+	quizCollection *mongo.Collection
+	scoringService *services.ScoringService
+	auditService   *services.AuditService
+	statsService   *services.QuizStatsService
+	leaderboards   *services.LeaderboardService
+	achievements   *services.AchievementService
+	itemAnalysis   *services.ItemAnalysisService
+	adaptive       *services.AdaptiveService
+	challenges     *services.ChallengeService
+	events         *services.EventBus
+}
+
+// NewAttemptManagementHandler creates a new attempt management handler
+func NewAttemptManagementHandler(leaderboards *services.LeaderboardService, achievements *services.AchievementService, events *services.EventBus) *AttemptManagementHandler {
+	return &AttemptManagementHandler{
+		collection:     config.GetCollection("attempts"),
+		quizCollection: config.GetCollection("quizzes"),
+		scoringService: services.NewScoringService(),
+		auditService:   services.NewAuditService(),
+		statsService:   services.NewQuizStatsService(),
+		leaderboards:   leaderboards,
+		achievements:   achievements,
+		itemAnalysis:   services.NewItemAnalysisService(),
+		adaptive:       services.NewAdaptiveService(),
+		challenges:     services.NewChallengeService(),
+		events:         events,
+	}
+}
+
+// OverrideScoreRequest represents a manual change to the points of one answer
+type OverrideScoreRequest struct {
+	Points *float64 `json:"points" binding:"required,min=0" example:"7.5"`
+	Reason string   `json:"reason" binding:"required" example:"Accepted alternative wording"`
+}
+
+// CorrectAnswerKeyRequest represents the corrected answer of a question
+type CorrectAnswerKeyRequest struct {
+	CorrectAnswer string `json:"correct_answer" binding:"required" example:"1"` // "true"/"false", or the option index
+	Reason        string `json:"reason" binding:"required" example:"Option 2 was marked correct by mistake"`
+}
+
+// InvalidateAttemptRequest represents the request to invalidate an attempt
+type InvalidateAttemptRequest struct {
+	Reason string `json:"reason" binding:"required" example:"Answers shared during the exam"`
+}
+
+// ListAttempts godoc
+// @Summary      List student attempts
+// @Description  List attempts on the professor's quizzes, filtered by quiz and/or student (professors only)
+// @Tags         attempt-management
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        quiz_id query string false "Filter by quiz ID"
+// @Param        student_id query string false "Filter by student ID"
+// @Success      200 {array} models.QuizAttempt
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /manage/attempts [get]
+func (h *AttemptManagementHandler) ListAttempts(c *gin.Context) {
+	quizIDParam := c.Query("quiz_id")
+	studentIDParam := c.Query("student_id")
+	if quizIDParam == "" && studentIDParam == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "quiz_id or student_id is required"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	professorID := userID.(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+
+	if quizIDParam != "" {
+		quizID, err := primitive.ObjectIDFromHex(quizIDParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quiz ID"})
+			return
+		}
+		if _, ok := h.loadOwnedQuiz(ctx, c, quizID, professorID); !ok {
+			return
+		}
+		filter["quiz_id"] = quizID
+	} else {
+		// Restrict to attempts on quizzes the professor owns
+		quizIDs, err := h.ownedQuizIDs(ctx, professorID)
+		if err != nil {
+			log.Printf("ListAttempts: Failed to fetch owned quizzes for %s - %v", professorID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attempts"})
+			return
+		}
+		filter["quiz_id"] = bson.M{"$in": quizIDs}
+	}
+
+	if studentIDParam != "" {
+		studentID, err := primitive.ObjectIDFromHex(studentIDParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid student ID"})
+			return
+		}
+		filter["student_id"] = studentID
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}})
+	cursor, err := h.collection.Find(ctx, filter, opts)
+	if err != nil {
+		log.Printf("ListAttempts: Failed to fetch attempts - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attempts"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	attempts := make([]models.QuizAttempt, 0)
+	if err := cursor.All(ctx, &attempts); err != nil {
+		log.Printf("ListAttempts: Failed to decode attempts - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode attempts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, attempts)
+}
+
+// GetAttempt godoc
+// @Summary      View a student attempt
+// @Description  View a student's attempt with answers, correct answers and audit trail (professors only)
+// @Tags         attempt-management
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Attempt ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /manage/attempts/{id} [get]
+func (h *AttemptManagementHandler) GetAttempt(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	attempt, quiz, ok := h.loadOwnedAttempt(ctx, c)
+	if !ok {
+		return
+	}
+
+	auditTrail, err := h.auditService.ListForEntity(ctx, auditEntityAttempt, attempt.ID)
+	if err != nil {
+		log.Printf("GetAttempt: Failed to fetch audit trail for %s - %v", attempt.ID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit trail"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"attempt":     attempt,
+		"review":      buildAttemptReview(quiz, attempt, time.Now(), true),
+		"audit_trail": auditTrail,
+	})
+}
+
+// OverrideAnswerScore godoc
+// @Summary      Override an answer's points
+// @Description  Manually set the points of one answer in a completed attempt, with a reason. Points can go up to the most the question scores under the attempt's scoring policy, bonuses included (professors only)
+// @Tags         attempt-management
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Attempt ID"
+// @Param        question_id path string true "Question ID"
+// @Param        request body OverrideScoreRequest true "New points and reason"
+// @Success      200 {object} models.QuizAttempt
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /manage/attempts/{id}/answers/{question_id} [put]
+func (h *AttemptManagementHandler) OverrideAnswerScore(c *gin.Context) {
+	var req OverrideScoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	questionID, err := primitive.ObjectIDFromHex(c.Param("question_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question ID"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	professorID := userID.(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var attempt *models.QuizAttempt
+	var quiz *models.Quiz
+	var previousPoints, previousTotal, previousPercentage float64
+
+	// The update only applies if the attempt was not adjusted since it was
+	// read, e.g. by a regrade; otherwise retry
+	for try := 1; ; try++ {
+		var ok bool
+		attempt, quiz, ok = h.loadOwnedAttempt(ctx, c)
+		if !ok {
+			return
+		}
+
+		if attempt.CompletedAt == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Only completed attempts can be adjusted"})
+			return
+		}
+
+		question := findQuestion(quiz, questionID)
+		if question == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Question not found in quiz"})
+			return
+		}
+
+		// Modifiers can earn more than the base points, up to what the scoring engine awards at best
+		maxPoints, err := h.scoringService.MaxQuestionScore(services.AttemptQuiz(quiz, attempt), attempt.Scoring, question)
+		if err != nil {
+			log.Printf("OverrideAnswerScore: Failed to score attempt %s - %v", attempt.ID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to score attempt"})
+			return
+		}
+		if *req.Points > maxPoints {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Points cannot exceed %.2f, the most this question can score", maxPoints)})
+			return
+		}
+
+		answerIndex := -1
+		for i, ans := range attempt.Answers {
+			if ans.QuestionID == questionID {
+				answerIndex = i
+				break
+			}
+		}
+		if answerIndex < 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No answer submitted for this question"})
+			return
+		}
+
+		answer := &attempt.Answers[answerIndex]
+		originalPoints := answer.PointsEarned
+		if answer.Override != nil {
+			originalPoints = answer.Override.OriginalPoints
+		}
+		previousPoints = answer.PointsEarned
+		previousTotal = attempt.TotalScore
+		previousPercentage = attempt.Percentage
+
+		answer.PointsEarned = *req.Points
+		answer.Override = &models.ScoreOverride{
+			Points:         *req.Points,
+			OriginalPoints: originalPoints,
+			Reason:         req.Reason,
+			ProfessorID:    professorID,
+			OverriddenAt:   time.Now(),
+		}
+		if err := h.scoringService.ScoreAttempt(quiz, attempt); err != nil {
+			log.Printf("OverrideAnswerScore: Failed to score attempt %s - %v", attempt.ID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to score attempt"})
+			return
+		}
+
+		saved, err := h.saveAdjustedAttempt(ctx, attempt, bson.M{
+			"answers":     attempt.Answers,
+			"total_score": attempt.TotalScore,
+			"percentage":  attempt.Percentage,
+		})
+		if err != nil {
+			log.Printf("OverrideAnswerScore: Failed to update attempt %s - %v", attempt.ID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to override score"})
+			return
+		}
+		if saved {
+			break
+		}
+
+		if try == maxAdjustTries {
+			c.JSON(http.StatusConflict, gin.H{"error": "Attempt was modified concurrently, please retry"})
+			return
+		}
+	}
+	h.adjustQuizStats(ctx, attempt, attempt.Percentage-previousPercentage)
+	h.recordLeaderboard(ctx, quiz, attempt)
+	h.events.Publish(services.EventLeaderboardChanged, attempt.QuizID, gin.H{"reason": models.AuditScoreOverride})
+
+	h.recordAudit(ctx, models.AuditLog{
+		ActorID:    professorID,
+		Action:     models.AuditScoreOverride,
+		EntityType: auditEntityAttempt,
+		EntityID:   attempt.ID,
+		QuizID:     attempt.QuizID,
+		StudentID:  attempt.StudentID,
+		Reason:     req.Reason,
+		Changes: map[string]interface{}{
+			"question_id":        questionID,
+			"points_before":      previousPoints,
+			"points_after":       *req.Points,
+			"total_score_before": previousTotal,
+			"total_score_after":  attempt.TotalScore,
+		},
+	})
+
+	c.JSON(http.StatusOK, attempt)
+}
+
+// InvalidateAttempt godoc
+// @Summary      Invalidate an attempt
+// @Description  Invalidate an attempt, e.g. for cheating, removing it from stats and leaderboards. The XP and achievements it earned are taken back, and an attempt played for a challenge forfeits it (professors only)
+// @Tags         attempt-management
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Attempt ID"
+// @Param        request body InvalidateAttemptRequest true "Reason for invalidation"
+// @Success      200 {object} models.QuizAttempt
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /manage/attempts/{id}/invalidate [put]
+func (h *AttemptManagementHandler) InvalidateAttempt(c *gin.Context) {
+	var req InvalidateAttemptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	professorID := userID.(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	attempt, _, ok := h.loadOwnedAttempt(ctx, c)
+	if !ok {
+		return
+	}
+
+	if attempt.InvalidatedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Attempt is already invalidated"})
+		return
+	}
+
+	now := time.Now()
+	result, err := h.collection.UpdateOne(ctx, bson.M{
+		"_id":            attempt.ID,
+		"invalidated_at": bson.M{"$exists": false},
+	}, bson.M{
+		"$set": bson.M{
+			"invalidated_at":      now,
+			"invalidated_by":      professorID,
+			"invalidation_reason": req.Reason,
+		},
+	})
+	if err != nil {
+		log.Printf("InvalidateAttempt: Failed to update attempt %s - %v", attempt.ID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to invalidate attempt"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Attempt is already invalidated"})
+		return
+	}
+
+	attempt.InvalidatedAt = &now
+	attempt.InvalidatedBy = professorID
+	attempt.InvalidationReason = req.Reason
+
+	if attempt.CompletedAt != nil && attempt.Mode != models.ModePractice {
+		if err := h.statsService.RemoveAttempt(ctx, attempt.QuizID, attempt.Percentage); err != nil {
+			log.Printf("InvalidateAttempt: %v (quiz: %s)", err, attempt.QuizID.Hex())
+		}
+	}
+	if err := h.leaderboards.Remove(ctx, attempt); err != nil {
+		log.Printf("InvalidateAttempt: %v (attempt: %s)", err, attempt.ID.Hex())
+	}
+	if _, err := h.achievements.Revoke(ctx, attempt); err != nil {
+		log.Printf("InvalidateAttempt: %v (attempt: %s)", err, attempt.ID.Hex())
+	}
+	attempt.XPEarned = 0
+	// An invalidated attempt forfeits its challenge, even one it already won
+	if attempt.ChallengeID != nil {
+		if err := h.challenges.Resettle(ctx, *attempt.ChallengeID); err != nil {
+			log.Printf("InvalidateAttempt: %v (challenge: %s)", err, attempt.ChallengeID.Hex())
+		}
+	}
+	h.events.Publish(services.EventLeaderboardChanged, attempt.QuizID, gin.H{"reason": models.AuditAttemptInvalidated})
+
+	h.recordAudit(ctx, models.AuditLog{
+		ActorID:    professorID,
+		Action:     models.AuditAttemptInvalidated,
+		EntityType: auditEntityAttempt,
+		EntityID:   attempt.ID,
+		QuizID:     attempt.QuizID,
+		StudentID:  attempt.StudentID,
+		Reason:     req.Reason,
+	})
+
+	c.JSON(http.StatusOK, attempt)
+}
+
+// RegradeQuiz godoc
+// @Summary      Regrade a quiz
+// @Description  Re-evaluate every completed attempt of a quiz against its current answer key. Manually overridden answers keep their points (professors only)
+// @Tags         attempt-management
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        quiz_id path string true "Quiz ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /manage/quizzes/{quiz_id}/regrade [post]
+func (h *AttemptManagementHandler) RegradeQuiz(c *gin.Context) {
+	quizID, err := primitive.ObjectIDFromHex(c.Param("quiz_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quiz ID"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	professorID := userID.(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	quiz, ok := h.loadOwnedQuiz(ctx, c, quizID, professorID)
+	if !ok {
+		return
+	}
+
+	cursor, err := h.collection.Find(ctx, bson.M{
+		"quiz_id":      quizID,
+		"completed_at": bson.M{"$exists": true},
+	})
+	if err != nil {
+		log.Printf("RegradeQuiz: Failed to fetch attempts for quiz %s - %v", quizID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attempts"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	processed, changed := 0, 0
+	for cursor.Next(ctx) {
+		var attempt models.QuizAttempt
+		if err := cursor.Decode(&attempt); err != nil {
+			log.Printf("RegradeQuiz: Failed to decode attempt - %v", err)
+			continue
+		}
+		processed++
+
+		regraded, err := h.regradeAttempt(ctx, quiz, &attempt, professorID)
+		if errors.Is(err, errAttemptConflict) {
+			log.Printf("RegradeQuiz: Attempt %s keeps changing concurrently", attempt.ID.Hex())
+			c.JSON(http.StatusConflict, gin.H{"error": "Attempts were modified concurrently, please retry the regrade"})
+			return
+		}
+		if err != nil {
+			log.Printf("RegradeQuiz: %v (attempt: %s)", err, attempt.ID.Hex())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to regrade attempts"})
+			return
+		}
+		if regraded {
+			changed++
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		log.Printf("RegradeQuiz: Cursor error for quiz %s - %v", quizID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to regrade attempts"})
+		return
+	}
+
+	if changed > 0 {
+		h.events.Publish(services.EventLeaderboardChanged, quizID, gin.H{"reason": models.AuditAttemptRegraded})
+	}
+
+	log.Printf("RegradeQuiz: Regraded quiz %s - %d attempts processed, %d changed", quizID.Hex(), processed, changed)
+	c.JSON(http.StatusOK, gin.H{
+		"quiz_id":            quizID,
+		"attempts_processed": processed,
+		"attempts_changed":   changed,
+	})
+}
+
+// CorrectAnswerKey godoc
+// @Summary      Correct a question's answer key
+// @Description  Fix the correct answer of a question of one of the professor's quizzes, e.g. when the wrong option was marked correct (professors only). Attempts keep their scores until the quiz is regraded. The correction is recorded in the audit trail
+// @Tags         attempt-management
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        quiz_id path string true "Quiz ID"
+// @Param        question_id path string true "Question ID"
+// @Param        request body CorrectAnswerKeyRequest true "Corrected answer"
+// @Success      200 {object} models.Question
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /manage/quizzes/{quiz_id}/questions/{question_id}/answer [put]
+func (h *AttemptManagementHandler) CorrectAnswerKey(c *gin.Context) {
+	quizID, err := primitive.ObjectIDFromHex(c.Param("quiz_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quiz ID"})
+		return
+	}
+
+	questionID, err := primitive.ObjectIDFromHex(c.Param("question_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question ID"})
+		return
+	}
+
+	var req CorrectAnswerKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	professorID := userID.(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	quiz, ok := h.loadOwnedQuiz(ctx, c, quizID, professorID)
+	if !ok {
+		return
+	}
+
+	question := findQuestion(quiz, questionID)
+	if question == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Question not found in quiz"})
+		return
+	}
+
+	if !validAnswerKey(question, req.CorrectAnswer) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "correct_answer must be true or false, or the index of an option"})
+		return
+	}
+
+	previousAnswer := correctAnswerString(question.CorrectAnswer)
+	_, err = h.quizCollection.UpdateOne(ctx, bson.M{
+		"_id":           quizID,
+		"questions._id": questionID,
+	}, bson.M{
+		"$set": bson.M{
+			"questions.$.correct_answer": req.CorrectAnswer,
+			"updated_at":                 time.Now(),
+		},
+	})
+	if err != nil {
+		log.Printf("CorrectAnswerKey: Failed to update quiz %s - %v", quizID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to correct answer key"})
+		return
+	}
+	question.CorrectAnswer = req.CorrectAnswer
+
+	h.recordAudit(ctx, models.AuditLog{
+		ActorID:    professorID,
+		Action:     models.AuditAnswerKeyCorrected,
+		EntityType: auditEntityQuiz,
+		EntityID:   quizID,
+		QuizID:     quizID,
+		Reason:     req.Reason,
+		Changes: map[string]interface{}{
+			"question_id":           questionID,
+			"correct_answer_before": previousAnswer,
+			"correct_answer_after":  req.CorrectAnswer,
+		},
+	})
+
+	c.JSON(http.StatusOK, question)
+}
+
+// RebuildQuizLeaderboard godoc
+// @Summary      Rebuild a quiz leaderboard
+// @Description  Recompute the materialized leaderboard of a quiz from its attempts, e.g. after a failed update (professors only)
+// @Tags         attempt-management
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        quiz_id path string true "Quiz ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /manage/quizzes/{quiz_id}/leaderboard/rebuild [post]
+func (h *AttemptManagementHandler) RebuildQuizLeaderboard(c *gin.Context) {
+	quizID, err := primitive.ObjectIDFromHex(c.Param("quiz_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quiz ID"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	professorID := userID.(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	if _, ok := h.loadOwnedQuiz(ctx, c, quizID, professorID); !ok {
+		return
+	}
+
+	entries, err := h.leaderboards.Rebuild(ctx, &quizID)
+	if err != nil {
+		log.Printf("RebuildQuizLeaderboard: Failed to rebuild quiz %s - %v", quizID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rebuild leaderboard"})
+		return
+	}
+
+	h.events.Publish(services.EventLeaderboardChanged, quizID, gin.H{"reason": "rebuild"})
+
+	log.Printf("RebuildQuizLeaderboard: Rebuilt quiz %s with %d entries", quizID.Hex(), entries)
+	c.JSON(http.StatusOK, gin.H{
+		"quiz_id": quizID,
+		"entries": entries,
+	})
+}
+
+// VerifyQuizLeaderboard godoc
+// @Summary      Verify a quiz leaderboard
+// @Description  Compare the materialized leaderboard of a quiz with a full recomputation from its attempts (professors only)
+// @Tags         attempt-management
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        quiz_id path string true "Quiz ID"
+// @Success      200 {object} services.LeaderboardVerification
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /manage/quizzes/{quiz_id}/leaderboard/verify [get]
+func (h *AttemptManagementHandler) VerifyQuizLeaderboard(c *gin.Context) {
+	quizID, err := primitive.ObjectIDFromHex(c.Param("quiz_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quiz ID"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	professorID := userID.(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	if _, ok := h.loadOwnedQuiz(ctx, c, quizID, professorID); !ok {
+		return
+	}
+
+	report, err := h.leaderboards.Verify(ctx, quizID)
+	if err != nil {
+		log.Printf("VerifyQuizLeaderboard: Failed to verify quiz %s - %v", quizID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify leaderboard"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetItemAnalysis godoc
+// @Summary      Get quiz item analysis
+// @Description  Per question statistics computed from the completed, non invalidated attempts of a quiz (professors only): percent correct, option distribution for multiple choice, average time to answer, discrimination index (top vs bottom 27%) and point-biserial correlation, plus the quiz's Cronbach's alpha. Questions with suspicious statistics are flagged once the quiz has 10 attempts
+// @Tags         attempt-management
+// @Produce      json
+// @Security     BearerAuth
+// @Param        quiz_id path string true "Quiz ID"
+// @Success      200 {object} services.ItemAnalysis
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /manage/quizzes/{quiz_id}/item-analysis [get]
+func (h *AttemptManagementHandler) GetItemAnalysis(c *gin.Context) {
+	quizID, err := primitive.ObjectIDFromHex(c.Param("quiz_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quiz ID"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	professorID := userID.(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	quiz, ok := h.loadOwnedQuiz(ctx, c, quizID, professorID)
+	if !ok {
+		return
+	}
+
+	analysis, err := h.itemAnalysis.Analyze(ctx, quiz)
+	if err != nil {
+		log.Printf("GetItemAnalysis: Failed to analyze quiz %s - %v", quizID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute item analysis"})
+		return
+	}
+
+	c.JSON(http.StatusOK, analysis)
+}
+
+// CalibrateQuiz godoc
+// @Summary      Calibrate adaptive question difficulties
+// @Description  Estimate the Rasch difficulty of each question of an adaptive quiz from the answers of its completed attempts, and use it to select questions from now on (professors only). Questions with fewer than 10 answers keep their previous difficulty, or the default of the quiz's difficulty level
+// @Tags         attempt-management
+// @Produce      json
+// @Security     BearerAuth
+// @Param        quiz_id path string true "Quiz ID"
+// @Success      200 {object} services.CalibrationReport
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /manage/quizzes/{quiz_id}/calibrate [post]
+func (h *AttemptManagementHandler) CalibrateQuiz(c *gin.Context) {
+	quizID, err := primitive.ObjectIDFromHex(c.Param("quiz_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quiz ID"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	professorID := userID.(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	quiz, ok := h.loadOwnedQuiz(ctx, c, quizID, professorID)
+	if !ok {
+		return
+	}
+
+	if quiz.Adaptive == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only adaptive quizzes can be calibrated"})
+		return
+	}
+
+	report, err := h.adaptive.Calibrate(ctx, quiz)
+	if err != nil {
+		log.Printf("CalibrateQuiz: Failed to calibrate quiz %s - %v", quizID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calibrate quiz"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// UpdatePracticeSettings godoc
+// @Summary      Update quiz practice settings
+// @Description  Allow or forbid practice attempts of a quiz, and choose whether they skip the course completion check (professors only). Practice attempts never count towards leaderboards. Practice can only be enabled on quizzes whose review policy is immediately, since it shows the correct answer after each answer
+// @Tags         attempt-management
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        quiz_id path string true "Quiz ID"
+// @Param        request body models.PracticeSettings true "Practice settings"
+// @Success      200 {object} models.PracticeSettings
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /manage/quizzes/{quiz_id}/practice [put]
+func (h *AttemptManagementHandler) UpdatePracticeSettings(c *gin.Context) {
+	quizID, err := primitive.ObjectIDFromHex(c.Param("quiz_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quiz ID"})
+		return
+	}
+
+	var req models.PracticeSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	professorID := userID.(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	quiz, ok := h.loadOwnedQuiz(ctx, c, quizID, professorID)
+	if !ok {
+		return
+	}
+
+	// Practice shows the correct answer after each answer
+	if req.Enabled && quiz.ReviewPolicy != "" && quiz.ReviewPolicy != models.ReviewImmediately {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errPracticeReviewPolicy})
+		return
+	}
+
+	_, err = h.quizCollection.UpdateOne(ctx, bson.M{"_id": quizID}, bson.M{"$set": bson.M{
+		"practice":   req,
+		"updated_at": time.Now(),
+	}})
+	if err != nil {
+		log.Printf("UpdatePracticeSettings: Failed to update quiz %s - %v", quizID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update practice settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, req)
+}
+
+// regradeAttempt re-evaluates a completed attempt against the quiz's answer
+// key and saves it if its score changed. The update only applies if the
+// attempt was not adjusted since it was read; otherwise it is reloaded and
+// regraded again
+func (h *AttemptManagementHandler) regradeAttempt(ctx context.Context, quiz *models.Quiz, attempt *models.QuizAttempt, professorID primitive.ObjectID) (bool, error) {
+	for try := 1; ; try++ {
+		previousTotal := attempt.TotalScore
+		previousPercentage := attempt.Percentage
+		correctnessChanged := regradeAnswers(quiz, attempt.Answers)
+		if err := h.scoringService.ScoreAttempt(quiz, attempt); err != nil {
+			// Left as is, like attempts whose score did not change
+			log.Printf("RegradeQuiz: Failed to score attempt %s - %v", attempt.ID.Hex(), err)
+			return false, nil
+		}
+		if !correctnessChanged && attempt.TotalScore == previousTotal {
+			return false, nil
+		}
+
+		saved, err := h.saveAdjustedAttempt(ctx, attempt, bson.M{
+			"answers":          attempt.Answers,
+			"total_score":      attempt.TotalScore,
+			"max_score":        attempt.MaxScore,
+			"correct_count":    attempt.CorrectCount,
+			"incorrect_count":  attempt.IncorrectCount,
+			"unanswered_count": attempt.UnansweredCount,
+			"percentage":       attempt.Percentage,
+			"scoring_version":  attempt.ScoringVersion,
+		})
+		if err != nil {
+			return false, err
+		}
+		if saved {
+			h.adjustQuizStats(ctx, attempt, attempt.Percentage-previousPercentage)
+			h.recordLeaderboard(ctx, quiz, attempt)
+			h.recordAudit(ctx, models.AuditLog{
+				ActorID:    professorID,
+				Action:     models.AuditAttemptRegraded,
+				EntityType: auditEntityAttempt,
+				EntityID:   attempt.ID,
+				QuizID:     attempt.QuizID,
+				StudentID:  attempt.StudentID,
+				Changes: map[string]interface{}{
+					"total_score_before": previousTotal,
+					"total_score_after":  attempt.TotalScore,
+				},
+			})
+			return true, nil
+		}
+
+		if try == maxAdjustTries {
+			return false, errAttemptConflict
+		}
+		attemptID := attempt.ID
+		*attempt = models.QuizAttempt{}
+		if err := h.collection.FindOne(ctx, bson.M{"_id": attemptID}).Decode(attempt); err != nil {
+			return false, fmt.Errorf("failed to reload attempt: %w", err)
+		}
+	}
+}
+
+// saveAdjustedAttempt stores the adjusted fields of a completed attempt and
+// bumps its revision. It reports false, leaving the attempt unchanged, if the
+// attempt was adjusted concurrently since it was read
+func (h *AttemptManagementHandler) saveAdjustedAttempt(ctx context.Context, attempt *models.QuizAttempt, fields bson.M) (bool, error) {
+	// Attempts never adjusted have no revision yet
+	revision := interface{}(attempt.Revision)
+	if attempt.Revision == 0 {
+		revision = bson.M{"$exists": false}
+	}
+
+	result, err := h.collection.UpdateOne(ctx, bson.M{
+		"_id":      attempt.ID,
+		"revision": revision,
+	}, bson.M{
+		"$set": fields,
+		"$inc": bson.M{"revision": 1},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to update attempt: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return false, nil
+	}
+	attempt.Revision++
+	return true, nil
+}
+
+// regradeAnswers re-evaluates the correctness of answers in place against the quiz's
+// current answer key and reports whether any answer changed. Overridden answers are
+// left untouched; points are recomputed afterwards by the scoring service.
+func regradeAnswers(quiz *models.Quiz, answers []models.Answer) bool {
+	questions := make(map[primitive.ObjectID]*models.Question, len(quiz.Questions))
+	for i := range quiz.Questions {
+		questions[quiz.Questions[i].ID] = &quiz.Questions[i]
+	}
+
+	changed := false
+	for i := range answers {
+		answer := &answers[i]
+		question, ok := questions[answer.QuestionID]
+		if !ok || answer.Override != nil {
+			continue
+		}
+
+		studentAnswer, _ := answer.StudentAnswer.(string)
+		isCorrect := studentAnswer == correctAnswerString(question.CorrectAnswer)
+		if isCorrect != answer.IsCorrect {
+			answer.IsCorrect = isCorrect
+			changed = true
+		}
+	}
+	return changed
+}
+
+// validAnswerKey reports whether an answer is a possible correct answer of the question
+func validAnswerKey(question *models.Question, answer string) bool {
+	if question.Type == models.QuestionTypeTrueFalse {
+		return answer == "true" || answer == "false"
+	}
+	index, err := strconv.Atoi(answer)
+	return err == nil && index >= 0 && index < len(question.Options)
+}
+
+// loadOwnedQuiz fetches a quiz and checks that the professor created or approved it.
+// It writes the error response and returns false on failure.
+func (h *AttemptManagementHandler) loadOwnedQuiz(ctx context.Context, c *gin.Context, quizID, professorID primitive.ObjectID) (*models.Quiz, bool) {
+	var quiz models.Quiz
+	if err := h.quizCollection.FindOne(ctx, bson.M{"_id": quizID}).Decode(&quiz); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Quiz not found"})
+		return nil, false
+	}
+
+	if quiz.CreatorID != professorID && quiz.ApprovedBy != professorID {
+		log.Printf("AttemptManagement: Professor %s does not own quiz %s", professorID.Hex(), quizID.Hex())
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only manage attempts on your own quizzes"})
+		return nil, false
+	}
+
+	return &quiz, true
+}
+
+// loadOwnedAttempt fetches the attempt named by the :id path parameter together with
+// its quiz, checking quiz ownership. It writes the error response and returns false on failure.
+func (h *AttemptManagementHandler) loadOwnedAttempt(ctx context.Context, c *gin.Context) (*models.QuizAttempt, *models.Quiz, bool) {
+	attemptID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attempt ID"})
+		return nil, nil, false
+	}
+
+	userID, _ := c.Get("user_id")
+	professorID := userID.(primitive.ObjectID)
+
+	var attempt models.QuizAttempt
+	if err := h.collection.FindOne(ctx, bson.M{"_id": attemptID}).Decode(&attempt); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attempt not found"})
+		return nil, nil, false
+	}
+
+	quiz, ok := h.loadOwnedQuiz(ctx, c, attempt.QuizID, professorID)
+	if !ok {
+		return nil, nil, false
+	}
+
+	return &attempt, quiz, true
+}
+
+// ownedQuizIDs returns the IDs of quizzes the professor created or approved
+func (h *AttemptManagementHandler) ownedQuizIDs(ctx context.Context, professorID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+	cursor, err := h.quizCollection.Find(ctx, bson.M{
+		"$or": []bson.M{
+			{"creator_id": professorID},
+			{"approved_by": professorID},
+		},
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var quizzes []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &quizzes); err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, len(quizzes))
+	for i, q := range quizzes {
+		ids[i] = q.ID
+	}
+	return ids, nil
+}
+
+// adjustQuizStats applies a percentage change of a completed, still counted ranked attempt to its quiz stats
+func (h *AttemptManagementHandler) adjustQuizStats(ctx context.Context, attempt *models.QuizAttempt, delta float64) {
+	if attempt.CompletedAt == nil || attempt.InvalidatedAt != nil || attempt.Mode == models.ModePractice {
+		return
+	}
+	if err := h.statsService.AdjustPercentage(ctx, attempt.QuizID, delta); err != nil {
+		log.Printf("AttemptManagement: %v (quiz: %s)", err, attempt.QuizID.Hex())
+	}
+}
+
+// recordLeaderboard refreshes the leaderboard row of a counted attempt after its score changed
+func (h *AttemptManagementHandler) recordLeaderboard(ctx context.Context, quiz *models.Quiz, attempt *models.QuizAttempt) {
+	if attempt.CompletedAt == nil || attempt.InvalidatedAt != nil {
+		return
+	}
+	if err := h.leaderboards.Record(ctx, quiz, attempt); err != nil {
+		log.Printf("AttemptManagement: %v (attempt: %s)", err, attempt.ID.Hex())
+	}
+}
+
+// recordAudit writes an audit entry, logging rather than failing the request on error
+func (h *AttemptManagementHandler) recordAudit(ctx context.Context, entry models.AuditLog) {
+	if err := h.auditService.Record(ctx, entry); err != nil {
+		log.Printf("AttemptManagement: %v (action: %s, entity: %s)", err, entry.Action, entry.EntityID.Hex())
+	}
+}
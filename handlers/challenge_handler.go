@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"quizmasterapi/config"
+	"quizmasterapi/models"
+	"quizmasterapi/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultChallengeHours = 72
+	maxChallengeHours     = 7 * 24
+)
+
+// ChallengeHandler handles head-to-head challenges between students
+type ChallengeHandler struct {
+	collection       *mongo.Collection
+	quizCollection   *mongo.Collection
+	userCollection   *mongo.Collection
+	challengeService *services.ChallengeService
+}
+
+// NewChallengeHandler creates a new challenge handler
+func NewChallengeHandler() *ChallengeHandler {
+	return &ChallengeHandler{
+		collection:       config.GetCollection("challenges"),
+		quizCollection:   config.GetCollection("quizzes"),
+		userCollection:   config.GetCollection("users"),
+		challengeService: services.NewChallengeService(),
+	}
+}
+
+// CreateChallengeRequest represents a student challenging a classmate
+type CreateChallengeRequest struct {
+	QuizID         string `json:"quiz_id" binding:"required" example:"507f1f77bcf86cd799439011"`
+	OpponentID     string `json:"opponent_id" binding:"required" example:"507f1f77bcf86cd799439013"`
+	ExpiresInHours int    `json:"expires_in_hours,omitempty" example:"72"` // Default 72, max 168
+}
+
+// ChallengeView is a challenge with its outcome for the requesting student
+type ChallengeView struct {
+	models.Challenge
+	Outcome models.ChallengeOutcome `json:"outcome,omitempty" enums:"win,loss,draw"`
+}
+
+// CreateChallenge godoc
+// @Summary      Challenge a classmate
+// @Description  Challenge another student to a quiz. The challenge stays pending until the opponent accepts it, and must be played before it expires
+// @Tags         challenges
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body CreateChallengeRequest true "Quiz and opponent"
+// @Success      201 {object} ChallengeView
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Router       /challenges [post]
+func (h *ChallengeHandler) CreateChallenge(c *gin.Context) {
+	var req CreateChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quizID, err := primitive.ObjectIDFromHex(req.QuizID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quiz ID"})
+		return
+	}
+
+	opponentID, err := primitive.ObjectIDFromHex(req.OpponentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid opponent ID"})
+		return
+	}
+
+	if req.ExpiresInHours == 0 {
+		req.ExpiresInHours = defaultChallengeHours
+	}
+	if req.ExpiresInHours < 1 || req.ExpiresInHours > maxChallengeHours {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_in_hours must be between 1 and 168"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	challengerID := userID.(primitive.ObjectID)
+
+	if opponentID == challengerID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot challenge yourself"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var quiz models.Quiz
+	opts := options.FindOne().SetProjection(bson.M{"title": 1, "status": 1})
+	if err := h.quizCollection.FindOne(ctx, bson.M{"_id": quizID}, opts).Decode(&quiz); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Quiz not found"})
+		return
+	}
+
+	if quiz.Status != models.StatusApproved {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Quiz is not available for attempts"})
+		return
+	}
+
+	var challenger, opponent models.User
+	if err := h.userCollection.FindOne(ctx, bson.M{"_id": challengerID}).Decode(&challenger); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	err = h.userCollection.FindOne(ctx, bson.M{"_id": opponentID, "role": models.RoleStudent}).Decode(&opponent)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Opponent not found"})
+		return
+	}
+
+	if err := h.challengeService.ExpireDue(ctx, challengerID); err != nil {
+		log.Printf("CreateChallenge: %v", err)
+	}
+
+	// One open challenge per pair of students and quiz, whoever sent it
+	open, err := h.collection.CountDocuments(ctx, bson.M{
+		"quiz_id": quizID,
+		"status":  bson.M{"$in": []models.ChallengeStatus{models.ChallengePending, models.ChallengeActive}},
+		"$or": bson.A{
+			bson.M{"challenger_id": challengerID, "opponent_id": opponentID},
+			bson.M{"challenger_id": opponentID, "opponent_id": challengerID},
+		},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create challenge"})
+		return
+	}
+	if open > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "There is already an open challenge between you on this quiz"})
+		return
+	}
+
+	now := time.Now()
+	challenge := models.Challenge{
+		ID:             primitive.NewObjectID(),
+		QuizID:         quizID,
+		QuizTitle:      quiz.Title,
+		ChallengerID:   challengerID,
+		ChallengerName: challenger.FirstName + " " + challenger.LastName,
+		OpponentID:     opponentID,
+		OpponentName:   opponent.FirstName + " " + opponent.LastName,
+		Status:         models.ChallengePending,
+		ExpiresAt:      now.Add(time.Duration(req.ExpiresInHours) * time.Hour),
+		CreatedAt:      now,
+	}
+
+	if _, err := h.collection.InsertOne(ctx, challenge); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create challenge"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, ChallengeView{Challenge: challenge})
+}
+
+// GetChallenges godoc
+// @Summary      List my challenges
+// @Description  List the challenges the current student sent or received, newest first
+// @Tags         challenges
+// @Produce      json
+// @Security     BearerAuth
+// @Param        status query string false "Filter by status" Enums(pending, active, finished, declined, expired)
+// @Param        limit query int false "Number of challenges (max 100)" default(20)
+// @Success      200 {array} ChallengeView
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /challenges [get]
+func (h *ChallengeHandler) GetChallenges(c *gin.Context) {
+	limit, err := parsePageLimit(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	studentID := userID.(primitive.ObjectID)
+
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"challenger_id": studentID},
+			bson.M{"opponent_id": studentID},
+		},
+	}
+	if status := c.Query("status"); status != "" {
+		switch models.ChallengeStatus(status) {
+		case models.ChallengePending, models.ChallengeActive, models.ChallengeFinished, models.ChallengeDeclined, models.ChallengeExpired:
+			filter["status"] = status
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status filter"})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Settle overdue challenges first so they show up under the right status
+	if err := h.challengeService.ExpireDue(ctx, studentID); err != nil {
+		log.Printf("GetChallenges: %v", err)
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := h.collection.Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch challenges"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var challenges []models.Challenge
+	if err := cursor.All(ctx, &challenges); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode challenges"})
+		return
+	}
+
+	views := make([]ChallengeView, 0, len(challenges))
+	for i := range challenges {
+		views = append(views, ChallengeView{
+			Challenge: challenges[i],
+			Outcome:   services.ChallengeOutcomeFor(&challenges[i], studentID),
+		})
+	}
+
+	c.JSON(http.StatusOK, views)
+}
+
+// GetChallengeByID godoc
+// @Summary      Get a challenge
+// @Description  Get one of the current student's challenges with its result
+// @Tags         challenges
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Challenge ID"
+// @Success      200 {object} ChallengeView
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /challenges/{id} [get]
+func (h *ChallengeHandler) GetChallengeByID(c *gin.Context) {
+	challengeID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid challenge ID"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	studentID := userID.(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := h.challengeService.ExpireDue(ctx, studentID); err != nil {
+		log.Printf("GetChallengeByID: %v", err)
+	}
+
+	var challenge models.Challenge
+	err = h.collection.FindOne(ctx, bson.M{
+		"_id": challengeID,
+		"$or": bson.A{
+			bson.M{"challenger_id": studentID},
+			bson.M{"opponent_id": studentID},
+		},
+	}).Decode(&challenge)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Challenge not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ChallengeView{
+		Challenge: challenge,
+		Outcome:   services.ChallengeOutcomeFor(&challenge, studentID),
+	})
+}
+
+// RespondToChallenge godoc
+// @Summary      Accept or decline a challenge
+// @Description  Accept or decline a pending challenge (opponent only). Once accepted, both students start their attempt with the challenge_id
+// @Tags         challenges
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Challenge ID"
+// @Param        action path string true "Action" Enums(accept, decline)
+// @Success      200 {object} ChallengeView
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /challenges/{id}/{action} [put]
+func (h *ChallengeHandler) RespondToChallenge(c *gin.Context) {
+	challengeID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid challenge ID"})
+		return
+	}
+
+	now := time.Now()
+	set := bson.M{}
+	switch c.Param("action") {
+	case "accept":
+		set["status"] = models.ChallengeActive
+		set["accepted_at"] = now
+	case "decline":
+		set["status"] = models.ChallengeDeclined
+		set["finished_at"] = now
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid action. Use 'accept' or 'decline'"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	studentID := userID.(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var challenge models.Challenge
+	err = h.collection.FindOneAndUpdate(ctx, bson.M{
+		"_id":         challengeID,
+		"opponent_id": studentID,
+		"status":      models.ChallengePending,
+		"expires_at":  bson.M{"$gt": now},
+	}, bson.M{"$set": set}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&challenge)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No pending challenge found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update challenge"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ChallengeView{Challenge: challenge})
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"quizmasterapi/config"
+	"quizmasterapi/models"
+	"quizmasterapi/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	auditEntityCourseCompletion = "course_completion"
+
+	// Headers of a signed completion webhook
+	WebhookTimestampHeader = "X-Course-Timestamp"
+	WebhookSignatureHeader = "X-Course-Signature"
+
+	// Largest webhook body read
+	maxWebhookBodyBytes = 64 << 10
+)
+
+// CourseHandler handles course completion webhooks and manual completions
+type CourseHandler struct {
+	courses        *services.CourseService
+	auditService   *services.AuditService
+	userCollection *mongo.Collection
+}
+
+// NewCourseHandler creates a new course handler
+func NewCourseHandler(courses *services.CourseService) *CourseHandler {
+	return &CourseHandler{
+		courses:        courses,
+		auditService:   services.NewAuditService(),
+		userCollection: config.GetCollection("users"),
+	}
+}
+
+// UpdateCompletionRequest represents the reason for a manual completion change
+type UpdateCompletionRequest struct {
+	Reason string `json:"reason" binding:"required" example:"Completed the course before the platform migration"`
+}
+
+// CompletionWebhook godoc
+// @Summary      Receive a course completion
+// @Description  Webhook for the course platform to push completion events, in the format of the course API completion response. The request must carry a Unix timestamp in X-Course-Timestamp, at most 5 minutes old, and the hex HMAC-SHA256 of "<timestamp>.<body>" with the shared secret in X-Course-Signature (optionally prefixed with "sha256="). Completions are stored for good, unless a professor set the completion manually; events that are not completions are ignored
+// @Tags         courses
+// @Accept       json
+// @Produce      json
+// @Param        X-Course-Timestamp header string true "Unix timestamp of the event"
+// @Param        X-Course-Signature header string true "HMAC-SHA256 signature"
+// @Param        request body services.CourseCompletionResponse true "Completion event"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Failure      503 {object} map[string]string
+// @Router       /webhooks/course-completions [post]
+func (h *CourseHandler) CompletionWebhook(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBodyBytes+1))
+	if err != nil || len(body) > maxWebhookBodyBytes {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook body"})
+		return
+	}
+
+	err = h.courses.VerifyWebhook(c.GetHeader(WebhookTimestampHeader), c.GetHeader(WebhookSignatureHeader), body, time.Now())
+	if errors.Is(err, services.ErrWebhookDisabled) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Completion webhooks are not configured"})
+		return
+	}
+	if err != nil {
+		log.Printf("CompletionWebhook: %v", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid webhook signature"})
+		return
+	}
+
+	var event services.CourseCompletionResponse
+	if err := json.Unmarshal(body, &event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook payload"})
+		return
+	}
+	studentID, err := primitive.ObjectIDFromHex(event.StudentID)
+	if err != nil || event.CourseID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "student_id and course_id are required"})
+		return
+	}
+	if !event.Completed {
+		c.JSON(http.StatusOK, gin.H{"status": "ignored"})
+		return
+	}
+
+	completedAt := time.Now()
+	if event.CompletedAt != "" {
+		completedAt, err = time.Parse(time.RFC3339, event.CompletedAt)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "completed_at must be an RFC 3339 timestamp"})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	recorded, err := h.courses.RecordWebhookCompletion(ctx, studentID, event.CourseID, completedAt)
+	if err != nil {
+		log.Printf("CompletionWebhook: %v (course: %s, student: %s)", err, event.CourseID, event.StudentID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record completion"})
+		return
+	}
+	if !recorded {
+		c.JSON(http.StatusOK, gin.H{"status": "ignored"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "recorded"})
+}
+
+// UpdateCompletion godoc
+// @Summary      Grant or revoke a course completion
+// @Description  Manually grant or revoke the completion of a course by a student (professors only), e.g. to fix a wrong result of the course platform. The manual result overrides the course API and completion webhooks until changed again, and is recorded in the audit trail
+// @Tags         courses
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        course_id path string true "External course ID"
+// @Param        student_id path string true "Student ID"
+// @Param        action path string true "grant or revoke"
+// @Param        request body UpdateCompletionRequest true "Reason"
+// @Success      200 {object} models.CourseCompletion
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /manage/courses/{course_id}/completions/{student_id}/{action} [put]
+func (h *CourseHandler) UpdateCompletion(c *gin.Context) {
+	courseID := c.Param("course_id")
+	studentID, err := primitive.ObjectIDFromHex(c.Param("student_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid student ID"})
+		return
+	}
+
+	var completed bool
+	var action models.AuditAction
+	switch c.Param("action") {
+	case "grant":
+		completed, action = true, models.AuditCompletionGranted
+	case "revoke":
+		completed, action = false, models.AuditCompletionRevoked
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Action must be 'grant' or 'revoke'"})
+		return
+	}
+
+	var req UpdateCompletionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	professorID := userID.(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := h.userCollection.CountDocuments(ctx, bson.M{"_id": studentID, "role": models.RoleStudent})
+	if err != nil {
+		log.Printf("UpdateCompletion: %v (student: %s)", err, studentID.Hex())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update course completion"})
+		return
+	}
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Student not found"})
+		return
+	}
+
+	completion, err := h.courses.SetCompletion(ctx, studentID, courseID, completed, professorID, req.Reason)
+	if err != nil {
+		log.Printf("UpdateCompletion: %v (course: %s, student: %s)", err, courseID, studentID.Hex())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update course completion"})
+		return
+	}
+
+	entry := models.AuditLog{
+		ActorID:    professorID,
+		Action:     action,
+		EntityType: auditEntityCourseCompletion,
+		EntityID:   completion.ID,
+		StudentID:  studentID,
+		Reason:     req.Reason,
+		Changes:    map[string]interface{}{"course_id": courseID, "completed": completed},
+	}
+	if err := h.auditService.Record(ctx, entry); err != nil {
+		log.Printf("UpdateCompletion: %v (action: %s, entity: %s)", err, entry.Action, entry.EntityID.Hex())
+	}
+
+	c.JSON(http.StatusOK, completion)
+}
@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"quizmasterapi/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Rows written between two flushes of a streamed export
+const gradebookFlushRows = 200
+
+// Characters kept from the course ID in export file names
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// GradebookHandler handles course gradebook exports
+type GradebookHandler struct {
+	gradebook *services.GradebookService
+}
+
+// NewGradebookHandler creates a new gradebook handler
+func NewGradebookHandler() *GradebookHandler {
+	return &GradebookHandler{
+		gradebook: services.NewGradebookService(),
+	}
+}
+
+// gradebookExport writes the rows of one export format
+type gradebookExport interface {
+	header(quizzes []services.GradebookQuiz) error
+	row(row *services.GradebookRow) error
+	flush() error
+	close() error
+}
+
+// ExportGradebook godoc
+// @Summary      Export a course gradebook
+// @Description  Export the students × quizzes score matrix of a course (professors only), covering the approved quizzes of the course the professor created or approved. The score of each quiz is the best, last or average score of the student's completed, non invalidated attempts; missing quizzes count as 0 in the total. The export is streamed
+// @Tags         attempt-management
+// @Produce      json
+// @Produce      text/csv
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security     BearerAuth
+// @Param        course_id path string true "External course ID"
+// @Param        format query string false "Export format" Enums(csv, xlsx, json) default(csv)
+// @Param        rule query string false "Score selected per quiz" Enums(best, last, average) default(best)
+// @Success      200 {file} file
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /manage/courses/{course_id}/gradebook [get]
+func (h *GradebookHandler) ExportGradebook(c *gin.Context) {
+	courseID := c.Param("course_id")
+
+	rule, err := services.ParseGradeRule(c.Query("rule"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	format := c.DefaultQuery("format", "csv")
+	fileName := "gradebook-" + unsafeFileNameChars.ReplaceAllString(courseID, "_")
+	var export gradebookExport
+	var contentType string
+	switch format {
+	case "csv":
+		contentType = "text/csv; charset=utf-8"
+		export = &csvGradebook{writer: csv.NewWriter(c.Writer)}
+	case "xlsx":
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		export = &xlsxGradebook{writer: c.Writer, sheetName: "Course " + courseID}
+	case "json":
+		contentType = "application/json; charset=utf-8"
+		export = &jsonGradebook{writer: c.Writer, courseID: courseID, rule: rule}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format, use csv, xlsx or json"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	professorID := userID.(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Minute)
+	defer cancel()
+
+	quizzes, err := h.gradebook.Quizzes(ctx, courseID, professorID)
+	if errors.Is(err, services.ErrNoCourseQuizzes) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No approved quizzes of yours in this course"})
+		return
+	}
+	if err != nil {
+		log.Printf("ExportGradebook: %v (course: %s)", err, courseID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build gradebook"})
+		return
+	}
+
+	// From here the response is streamed, errors can only cut it short
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, fileName, format))
+	c.Status(http.StatusOK)
+
+	if err := export.header(quizzes); err != nil {
+		log.Printf("ExportGradebook: Failed to write header - %v (course: %s)", err, courseID)
+		return
+	}
+
+	written := 0
+	err = h.gradebook.Rows(ctx, quizzes, rule, func(row *services.GradebookRow) error {
+		if err := export.row(row); err != nil {
+			return err
+		}
+		written++
+		if written%gradebookFlushRows == 0 {
+			if err := export.flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("ExportGradebook: Export interrupted after %d rows - %v (course: %s)", written, err, courseID)
+		return
+	}
+
+	if err := export.close(); err != nil {
+		log.Printf("ExportGradebook: Failed to finish export - %v (course: %s)", err, courseID)
+	}
+}
+
+// gradebookScoreCells returns the cells of a row after the student columns
+func gradebookScoreCells(row *services.GradebookRow) []interface{} {
+	cells := make([]interface{}, 0, len(row.Scores)+3)
+	for _, score := range row.Scores {
+		if score == nil {
+			cells = append(cells, nil)
+			continue
+		}
+		cells = append(cells, *score)
+	}
+	return append(cells, row.Total, row.MaxTotal, row.Percentage)
+}
+
+func gradebookHeader(quizzes []services.GradebookQuiz) []string {
+	header := []string{"Student ID", "Last Name", "First Name", "Email"}
+	for _, quiz := range quizzes {
+		header = append(header, fmt.Sprintf("%s (/%s)", quiz.Title, strconv.FormatFloat(quiz.MaxScore, 'f', -1, 64)))
+	}
+	return append(header, "Total", "Max Total", "Percentage")
+}
+
+type csvGradebook struct {
+	writer *csv.Writer
+}
+
+func (g *csvGradebook) header(quizzes []services.GradebookQuiz) error {
+	header := gradebookHeader(quizzes)
+	for i := range header {
+		header[i] = csvText(header[i])
+	}
+	return g.writer.Write(header)
+}
+
+func (g *csvGradebook) row(row *services.GradebookRow) error {
+	record := []string{row.StudentID.Hex(), csvText(row.LastName), csvText(row.FirstName), csvText(row.Email)}
+	for _, cell := range gradebookScoreCells(row) {
+		if cell == nil {
+			record = append(record, "")
+			continue
+		}
+		record = append(record, strconv.FormatFloat(cell.(float64), 'f', -1, 64))
+	}
+	return g.writer.Write(record)
+}
+
+// csvText keeps spreadsheets from evaluating a text cell, such as a quiz
+// title or a name chosen by a user, as a formula
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func (g *csvGradebook) flush() error {
+	g.writer.Flush()
+	return g.writer.Error()
+}
+
+func (g *csvGradebook) close() error {
+	return g.flush()
+}
+
+type xlsxGradebook struct {
+	writer    http.ResponseWriter
+	sheetName string
+	xlsx      *services.XLSXWriter
+}
+
+func (g *xlsxGradebook) header(quizzes []services.GradebookQuiz) error {
+	xlsx, err := services.NewXLSXWriter(g.writer, g.sheetName)
+	if err != nil {
+		return err
+	}
+	g.xlsx = xlsx
+
+	header := gradebookHeader(quizzes)
+	cells := make([]interface{}, len(header))
+	for i, title := range header {
+		cells[i] = title
+	}
+	return g.xlsx.WriteRow(cells)
+}
+
+func (g *xlsxGradebook) row(row *services.GradebookRow) error {
+	cells := append([]interface{}{row.StudentID.Hex(), row.LastName, row.FirstName, row.Email}, gradebookScoreCells(row)...)
+	return g.xlsx.WriteRow(cells)
+}
+
+func (g *xlsxGradebook) flush() error {
+	return g.xlsx.Flush()
+}
+
+func (g *xlsxGradebook) close() error {
+	return g.xlsx.Close()
+}
+
+// jsonGradebook writes {"course_id", "rule", "quizzes", "students": [...]}
+// one student at a time
+type jsonGradebook struct {
+	writer   http.ResponseWriter
+	courseID string
+	rule     services.GradeRule
+	rows     int
+}
+
+func (g *jsonGradebook) header(quizzes []services.GradebookQuiz) error {
+	head, err := json.Marshal(gin.H{"course_id": g.courseID, "rule": g.rule, "quizzes": quizzes})
+	if err != nil {
+		return err
+	}
+	// Reopen the object to append the students array
+	_, err = fmt.Fprintf(g.writer, `%s,"students":[`, head[:len(head)-1])
+	return err
+}
+
+func (g *jsonGradebook) row(row *services.GradebookRow) error {
+	data, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	if g.rows > 0 {
+		if _, err := g.writer.Write([]byte(",")); err != nil {
+			return err
+		}
+	}
+	g.rows++
+	_, err = g.writer.Write(data)
+	return err
+}
+
+func (g *jsonGradebook) flush() error {
+	return nil
+}
+
+func (g *jsonGradebook) close() error {
+	_, err := g.writer.Write([]byte("]}"))
+	return err
+}
@@ -3,42 +3,63 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"quizmasterapi/config"
 	"quizmasterapi/models"
+	"quizmasterapi/services"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// LeaderboardHandler handles leaderboard-related requests
+// LeaderboardHandler handles leaderboard-related requests. Leaderboards are
+// read from the materialized leaderboard_entries collection
 type LeaderboardHandler struct {
-	attemptCollection *mongo.Collection
-	userCollection    *mongo.Collection
+	entryCollection    *mongo.Collection
+	leaderboardService *services.LeaderboardService
+	cache              *services.LeaderboardCache
 }
 
 // NewLeaderboardHandler creates a new leaderboard handler
-func NewLeaderboardHandler() *LeaderboardHandler {
+func NewLeaderboardHandler(leaderboardService *services.LeaderboardService) *LeaderboardHandler {
 	return &LeaderboardHandler{
-		attemptCollection: config.GetCollection("attempts"),
-		userCollection:    config.GetCollection("users"),
+		entryCollection:    leaderboardService.Collection(),
+		leaderboardService: leaderboardService,
+		cache:              leaderboardService.Cache(),
 	}
 }
 
+// QuizLeaderboardPage is one page of a quiz leaderboard
+type QuizLeaderboardPage struct {
+	QuizID     string                    `json:"quiz_id"`
+	Ranking    string                    `json:"ranking"`
+	TotalCount int                       `json:"total_count"`
+	Offset     int                       `json:"offset"`
+	Limit      int                       `json:"limit"`
+	NextOffset *int                      `json:"next_offset,omitempty"`
+	Entries    []models.LeaderboardEntry `json:"leaderboard"`
+}
+
 // GetQuizLeaderboard godoc
 // @Summary      Get quiz leaderboard
-// @Description  Get the leaderboard for a specific quiz, showing rankings of all students
+// @Description  Get a page of the leaderboard for a specific quiz. Attempts with the same score share a rank; ties are ordered by time taken
 // @Tags         leaderboards
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
 // @Param        quiz_id path string true "Quiz ID"
-// @Success      200 {array} models.LeaderboardEntry
+// @Param        ranking query string false "Tie handling: standard (1,1,3) or dense (1,1,2)" Enums(standard, dense) default(standard)
+// @Param        offset query int false "Number of entries to skip" default(0)
+// @Param        limit query int false "Page size (max 100)" default(20)
+// @Success      200 {object} QuizLeaderboardPage
 // @Failure      400 {object} map[string]string
 // @Failure      401 {object} map[string]string
 // @Failure      500 {object} map[string]string
@@ -51,70 +72,118 @@ func (h *LeaderboardHandler) GetQuizLeaderboard(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	// Find all completed attempts for this quiz, sorted by score (desc) and time (asc)
-	filter := bson.M{
-		"quiz_id":      objectID,
-		"completed_at": bson.M{"$exists": true},
+	ranking := c.DefaultQuery("ranking", "standard")
+	rankOperator := "$rank"
+	switch ranking {
+	case "standard":
+	case "dense":
+		rankOperator = "$denseRank"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ranking must be 'standard' or 'dense'"})
+		return
 	}
 
-	opts := options.Find().SetSort(bson.D{
-		primitive.E{Key: "total_score", Value: -1},
-		primitive.E{Key: "time_taken", Value: 1},
-	})
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "offset must be a non-negative integer"})
+		return
+	}
 
-	cursor, err := h.attemptCollection.Find(ctx, filter, opts)
+	limit, err := parsePageLimit(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch leaderboard"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	defer cursor.Close(ctx)
 
-	var attempts []models.QuizAttempt
-	if err := cursor.All(ctx, &attempts); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode attempts"})
+	cacheKey := fmt.Sprintf("%s:%d:%d", ranking, offset, limit)
+	if page, ok := h.cache.Get(objectID, cacheKey); ok {
+		c.JSON(http.StatusOK, page)
 		return
 	}
 
-	// Build leaderboard with user information
-	leaderboard := make([]models.LeaderboardEntry, 0, len(attempts))
-	rank := 1
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-	for _, attempt := range attempts {
-		// Get user info
-		var user models.User
-		err := h.userCollection.FindOne(ctx, bson.M{"_id": attempt.StudentID}).Decode(&user)
-		if err != nil {
-			continue // Skip if user not found
-		}
+	// Rank every counted attempt by score, ordering ties by time taken,
+	// then count and page the results and resolve student names in one query
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"quiz_id": objectID}}},
+		{{Key: "$setWindowFields", Value: bson.M{
+			"sortBy": bson.M{"score": -1},
+			"output": bson.M{"rank": bson.M{rankOperator: bson.M{}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{
+			primitive.E{Key: "rank", Value: 1},
+			primitive.E{Key: "time_taken", Value: 1},
+			primitive.E{Key: "_id", Value: 1},
+		}}},
+		{{Key: "$facet", Value: bson.M{
+			"total": bson.A{bson.M{"$count": "count"}},
+			"entries": bson.A{
+				bson.M{"$skip": offset},
+				bson.M{"$limit": limit},
+				bson.M{"$lookup": bson.M{
+					"from":         "users",
+					"localField":   "student_id",
+					"foreignField": "_id",
+					"as":           "student",
+				}},
+				bson.M{"$unwind": bson.M{"path": "$student", "preserveNullAndEmptyArrays": true}},
+				bson.M{"$project": bson.M{
+					"rank":       1,
+					"student_id": 1,
+					"student_name": bson.M{"$ifNull": bson.A{
+						bson.M{"$concat": bson.A{"$student.first_name", " ", "$student.last_name"}},
+						"Unknown student",
+					}},
+					"score":        1,
+					"max_score":    1,
+					"percentage":   1,
+					"time_taken":   1,
+					"completed_at": 1,
+				}},
+			},
+		}}},
+	}
 
-		percentage := 0.0
-		if attempt.MaxScore > 0 {
-			percentage = (attempt.TotalScore / attempt.MaxScore) * 100
-		}
+	cursor, err := h.entryCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch leaderboard"})
+		return
+	}
+	defer cursor.Close(ctx)
 
-		entry := models.LeaderboardEntry{
-			Rank:        rank,
-			StudentID:   attempt.StudentID,
-			StudentName: user.FirstName + " " + user.LastName,
-			Score:       attempt.TotalScore,
-			MaxScore:    attempt.MaxScore,
-			Percentage:  percentage,
-			TimeTaken:   attempt.TimeTaken,
-			CompletedAt: *attempt.CompletedAt,
-		}
+	var results []struct {
+		Total []struct {
+			Count int `bson:"count"`
+		} `bson:"total"`
+		Entries []models.LeaderboardEntry `bson:"entries"`
+	}
+	if err := cursor.All(ctx, &results); err != nil || len(results) != 1 {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode leaderboard"})
+		return
+	}
 
-		leaderboard = append(leaderboard, entry)
-		rank++
+	page := QuizLeaderboardPage{
+		QuizID:  quizID,
+		Ranking: ranking,
+		Offset:  offset,
+		Limit:   limit,
+		Entries: results[0].Entries,
+	}
+	if page.Entries == nil {
+		page.Entries = []models.LeaderboardEntry{}
+	}
+	if len(results[0].Total) > 0 {
+		page.TotalCount = results[0].Total[0].Count
+	}
+	if next := offset + len(page.Entries); next < page.TotalCount {
+		page.NextOffset = &next
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"quiz_id":     quizID,
-		"total_count": len(leaderboard),
-		"leaderboard": leaderboard,
-	})
+	h.cache.Set(objectID, cacheKey, page)
+
+	c.JSON(http.StatusOK, page)
 }
 
 // GetMyRank godoc
@@ -125,6 +194,7 @@ func (h *LeaderboardHandler) GetQuizLeaderboard(c *gin.Context) {
 // @Produce      json
 // @Security     BearerAuth
 // @Param        quiz_id path string true "Quiz ID"
+// @Param        ranking query string false "Tie handling: standard (1,1,3) or dense (1,1,2)" Enums(standard, dense) default(standard)
 // @Success      200 {object} map[string]interface{}
 // @Failure      400 {object} map[string]string
 // @Failure      401 {object} map[string]string
@@ -139,6 +209,12 @@ func (h *LeaderboardHandler) GetMyRank(c *gin.Context) {
 		return
 	}
 
+	ranking := c.DefaultQuery("ranking", "standard")
+	if ranking != "standard" && ranking != "dense" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ranking must be 'standard' or 'dense'"})
+		return
+	}
+
 	userID, _ := c.Get("user_id")
 	studentID := userID.(primitive.ObjectID)
 
@@ -146,158 +222,238 @@ func (h *LeaderboardHandler) GetMyRank(c *gin.Context) {
 	defer cancel()
 
 	// Get user's best attempt
-	filter := bson.M{
-		"quiz_id":      objectID,
-		"student_id":   studentID,
-		"completed_at": bson.M{"$exists": true},
-	}
-
-	opts := options.FindOne().SetSort(bson.D{
-		primitive.E{Key: "total_score", Value: -1},
-		primitive.E{Key: "time_taken", Value: 1},
-	})
-
-	var userAttempt models.QuizAttempt
-	err = h.attemptCollection.FindOne(ctx, filter, opts).Decode(&userAttempt)
-	if err != nil {
+	best, err := h.leaderboardService.BestEntry(ctx, objectID, studentID)
+	if err == mongo.ErrNoDocuments {
 		c.JSON(http.StatusNotFound, gin.H{"error": "No completed attempts found"})
 		return
 	}
-
-	// Count how many attempts have better scores
-	betterScoreFilter := bson.M{
-		"quiz_id":      objectID,
-		"completed_at": bson.M{"$exists": true},
-		"$or": []bson.M{
-			{"total_score": bson.M{"$gt": userAttempt.TotalScore}},
-			{
-				"total_score": userAttempt.TotalScore,
-				"time_taken":  bson.M{"$lt": userAttempt.TimeTaken},
-			},
-		},
-	}
-
-	betterCount, err := h.attemptCollection.CountDocuments(ctx, betterScoreFilter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate rank"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attempts"})
 		return
 	}
 
-	rank := int(betterCount) + 1
-
-	// Get total participants
-	totalFilter := bson.M{
-		"quiz_id":      objectID,
-		"completed_at": bson.M{"$exists": true},
-	}
-	totalCount, err := h.attemptCollection.CountDocuments(ctx, totalFilter)
+	// Attempts with the same score share a rank, matching the quiz leaderboard
+	rank, totalCount, err := h.leaderboardService.Rank(ctx, objectID, best.Score, ranking == "dense")
 	if err != nil {
-		totalCount = 0
-	}
-
-	percentage := 0.0
-	if userAttempt.MaxScore > 0 {
-		percentage = (userAttempt.TotalScore / userAttempt.MaxScore) * 100
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate rank"})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"quiz_id":            quizID,
 		"rank":               rank,
 		"total_participants": totalCount,
-		"score":              userAttempt.TotalScore,
-		"max_score":          userAttempt.MaxScore,
-		"percentage":         percentage,
-		"time_taken":         userAttempt.TimeTaken,
+		"score":              best.Score,
+		"max_score":          best.MaxScore,
+		"percentage":         best.Percentage,
+		"time_taken":         best.TimeTaken,
 	})
 }
 
+// Prior weight, in attempts, given to the overall average by the bayesian metric
+const bayesianPriorAttempts = 5
+
+// globalLeaderboardMetrics maps the metric query values to the field each one ranks by
+var globalLeaderboardMetrics = map[string]string{
+	"average":  "avg_score",
+	"total":    "total_score",
+	"weighted": "weighted_avg_score",
+	"bayesian": "bayesian_score",
+}
+
+// GlobalLeaderboardEntry is one student's row on the global leaderboard
+type GlobalLeaderboardEntry struct {
+	Rank          int                `bson:"rank" json:"rank"`
+	StudentID     primitive.ObjectID `bson:"_id" json:"student_id"`
+	StudentName   string             `bson:"student_name" json:"student_name"`
+	AvgScore      float64            `bson:"avg_score" json:"avg_score"`
+	WeightedScore float64            `bson:"weighted_avg_score" json:"weighted_avg_score"`
+	BayesianScore float64            `bson:"bayesian_score" json:"bayesian_score"`
+	TotalAttempts int                `bson:"total_attempts" json:"total_attempts"`
+	TotalScore    float64            `bson:"total_score" json:"total_score"`
+}
+
 // GetGlobalLeaderboard godoc
 // @Summary      Get global leaderboard
-// @Description  Get the top performing students across all quizzes (top 50)
+// @Description  Get the top performing students across quizzes, optionally scoped to a period, category, difficulty or course. Students with the same score share a rank
 // @Tags         leaderboards
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
-// @Success      200 {array} map[string]interface{}
+// @Param        period query string false "Time window, term starts at TERM_START" Enums(all, daily, weekly, monthly, term) default(all)
+// @Param        category query string false "Only count quizzes of this category"
+// @Param        difficulty query string false "Only count quizzes of this difficulty" Enums(easy, medium, hard)
+// @Param        course_id query string false "Only count quizzes of this course"
+// @Param        min_attempts query int false "Minimum completed attempts to be ranked" default(1)
+// @Param        metric query string false "Ranking metric" Enums(average, total, weighted, bayesian) default(weighted)
+// @Param        limit query int false "Number of students (max 100)" default(50)
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
 // @Failure      401 {object} map[string]string
 // @Failure      500 {object} map[string]string
 // @Router       /leaderboards/global [get]
 func (h *LeaderboardHandler) GetGlobalLeaderboard(c *gin.Context) {
+	period := strings.ToLower(c.DefaultQuery("period", "all"))
+	since, err := periodStart(period, time.Now().UTC())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	metric := strings.ToLower(c.DefaultQuery("metric", "weighted"))
+	metricField, ok := globalLeaderboardMetrics[metric]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "metric must be one of: average, total, weighted, bayesian"})
+		return
+	}
+
+	minAttempts, err := strconv.Atoi(c.DefaultQuery("min_attempts", "1"))
+	if err != nil || minAttempts < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "min_attempts must be a positive integer"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	filter := bson.M{}
+	if since != nil {
+		filter["completed_at"] = bson.M{"$gte": *since}
+	}
+	if category := strings.ToLower(c.Query("category")); category != "" && category != "all" {
+		filter["category"] = category
+	}
+	if difficulty := strings.ToLower(c.Query("difficulty")); difficulty != "" && difficulty != "all" {
+		filter["difficulty_level"] = difficulty
+	}
+	if courseID := c.Query("course_id"); courseID != "" {
+		filter["course_id"] = courseID
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Aggregate pipeline to calculate average performance per student
+	weights := services.DefaultDifficultyWeights
+
+	// Aggregate pipeline to calculate performance per student, weighting
+	// each attempt by the difficulty of its quiz
 	pipeline := mongo.Pipeline{
-		{{Key: "$match", Value: bson.M{"completed_at": bson.M{"$exists": true}}}},
-		{{Key: "$group", Value: bson.M{
-			"_id": "$student_id",
-			"avg_score": bson.M{"$avg": bson.M{
-				"$multiply": bson.A{
-					bson.M{"$divide": bson.A{"$total_score", "$max_score"}},
-					100,
+		{{Key: "$match", Value: filter}},
+		{{Key: "$addFields", Value: bson.M{
+			"weight": bson.M{"$switch": bson.M{
+				"branches": bson.A{
+					bson.M{"case": bson.M{"$eq": bson.A{"$difficulty_level", models.LevelEasy}}, "then": weights.Easy},
+					bson.M{"case": bson.M{"$eq": bson.A{"$difficulty_level", models.LevelMedium}}, "then": weights.Medium},
+					bson.M{"case": bson.M{"$eq": bson.A{"$difficulty_level", models.LevelHard}}, "then": weights.Hard},
 				},
+				"default": 1,
 			}},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":            "$student_id",
+			"avg_score":      bson.M{"$avg": "$percentage"},
+			"percentage_sum": bson.M{"$sum": "$percentage"},
+			"weighted_sum":   bson.M{"$sum": bson.M{"$multiply": bson.A{"$percentage", "$weight"}}},
+			"weight_sum":     bson.M{"$sum": "$weight"},
 			"total_attempts": bson.M{"$sum": 1},
-			"total_score":    bson.M{"$sum": "$total_score"},
+			"total_score":    bson.M{"$sum": "$score"},
+		}}},
+		// The overall average across every counted attempt, used as the bayesian prior
+		{{Key: "$setWindowFields", Value: bson.M{
+			"output": bson.M{
+				"all_percentage_sum": bson.M{"$sum": "$percentage_sum"},
+				"all_attempts":       bson.M{"$sum": "$total_attempts"},
+			},
+		}}},
+		{{Key: "$addFields", Value: bson.M{
+			"weighted_avg_score": bson.M{"$divide": bson.A{"$weighted_sum", "$weight_sum"}},
+			"bayesian_score": bson.M{"$divide": bson.A{
+				bson.M{"$add": bson.A{
+					bson.M{"$multiply": bson.A{
+						bayesianPriorAttempts,
+						bson.M{"$divide": bson.A{"$all_percentage_sum", "$all_attempts"}},
+					}},
+					"$percentage_sum",
+				}},
+				bson.M{"$add": bson.A{bayesianPriorAttempts, "$total_attempts"}},
+			}},
+		}}},
+		{{Key: "$match", Value: bson.M{"total_attempts": bson.M{"$gte": minAttempts}}}},
+		{{Key: "$setWindowFields", Value: bson.M{
+			"sortBy": bson.M{metricField: -1},
+			"output": bson.M{"rank": bson.M{"$rank": bson.M{}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{
+			primitive.E{Key: "rank", Value: 1},
+			primitive.E{Key: "total_attempts", Value: -1},
+			primitive.E{Key: "_id", Value: 1},
+		}}},
+		{{Key: "$limit", Value: limit}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "users",
+			"localField":   "_id",
+			"foreignField": "_id",
+			"as":           "student",
+		}}},
+		{{Key: "$unwind", Value: bson.M{"path": "$student", "preserveNullAndEmptyArrays": true}}},
+		{{Key: "$addFields", Value: bson.M{
+			"student_name": bson.M{"$ifNull": bson.A{
+				bson.M{"$concat": bson.A{"$student.first_name", " ", "$student.last_name"}},
+				"Unknown student",
+			}},
 		}}},
-		{{Key: "$sort", Value: bson.D{primitive.E{Key: "avg_score", Value: -1}}}},
-		{{Key: "$limit", Value: 50}},
 	}
 
-	cursor, err := h.attemptCollection.Aggregate(ctx, pipeline)
+	cursor, err := h.entryCollection.Aggregate(ctx, pipeline)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate leaderboard"})
 		return
 	}
 	defer cursor.Close(ctx)
 
-	type GlobalEntry struct {
-		StudentID     primitive.ObjectID `bson:"_id" json:"student_id"`
-		AvgScore      float64            `bson:"avg_score" json:"avg_score"`
-		TotalAttempts int                `bson:"total_attempts" json:"total_attempts"`
-		TotalScore    float64            `bson:"total_score" json:"total_score"`
-	}
-
-	var results []GlobalEntry
-	if err := cursor.All(ctx, &results); err != nil {
+	leaderboard := make([]GlobalLeaderboardEntry, 0, limit)
+	if err := cursor.All(ctx, &leaderboard); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode results"})
 		return
 	}
 
-	// Enrich with user information
-	type GlobalLeaderboardEntry struct {
-		Rank          int                `json:"rank"`
-		StudentID     primitive.ObjectID `json:"student_id"`
-		StudentName   string             `json:"student_name"`
-		AvgScore      float64            `json:"avg_score"`
-		TotalAttempts int                `json:"total_attempts"`
-		TotalScore    float64            `json:"total_score"`
-	}
-
-	leaderboard := make([]GlobalLeaderboardEntry, 0, len(results))
-	rank := 1
-
-	for _, result := range results {
-		var user models.User
-		err := h.userCollection.FindOne(ctx, bson.M{"_id": result.StudentID}).Decode(&user)
-		if err != nil {
-			continue
-		}
+	c.JSON(http.StatusOK, gin.H{
+		"period":       period,
+		"since":        since,
+		"metric":       metric,
+		"min_attempts": minAttempts,
+		"leaderboard":  leaderboard,
+	})
+}
 
-		entry := GlobalLeaderboardEntry{
-			Rank:          rank,
-			StudentID:     result.StudentID,
-			StudentName:   user.FirstName + " " + user.LastName,
-			AvgScore:      result.AvgScore,
-			TotalAttempts: result.TotalAttempts,
-			TotalScore:    result.TotalScore,
+// periodStart returns the start of a leaderboard period, or nil for all time.
+// Periods are calendar based in UTC; weeks start on Monday
+func periodStart(period string, now time.Time) (*time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	var start time.Time
+	switch period {
+	case "all":
+		return nil, nil
+	case "daily":
+		start = today
+	case "weekly":
+		start = today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	case "monthly":
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case "term":
+		if config.AppConfig.TermStart == nil {
+			return nil, errors.New("term leaderboards are not available, no term start is configured")
 		}
-
-		leaderboard = append(leaderboard, entry)
-		rank++
+		start = *config.AppConfig.TermStart
+	default:
+		return nil, errors.New("period must be one of: all, daily, weekly, monthly, term")
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"leaderboard": leaderboard,
-	})
+	return &start, nil
 }
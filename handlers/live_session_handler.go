@@ -0,0 +1,1078 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"quizmasterapi/config"
+	"quizmasterapi/models"
+	"quizmasterapi/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// Join codes avoid characters that are easily confused when read off a projector
+	joinCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	joinCodeLength   = 6
+	maxJoinCodeTries = 5
+
+	// Allowance for network latency when an answer arrives just after the time limit
+	liveAnswerGrace = time.Second
+
+	// Recording the attempts of a finished session is not bound to the request
+	// that finished it; an interrupted run is resumed on the next GET
+	finishSessionTimeout = 2 * time.Minute
+)
+
+// Statuses in which a session is still running
+var activeSessionStatuses = []models.LiveSessionStatus{
+	models.SessionLobby,
+	models.SessionQuestion,
+	models.SessionLeaderboard,
+}
+
+// LiveSessionHandler handles professor-hosted live quiz sessions
+type LiveSessionHandler struct {
+	collection        *mongo.Collection
+	attemptCollection *mongo.Collection
+	quizCollection    *mongo.Collection
+	userCollection    *mongo.Collection
+	courseService     *services.CourseService
+	scoringService    *services.ScoringService
+	statsService      *services.QuizStatsService
+	reviews           *services.ReviewService
+	leaderboards      *services.LeaderboardService
+	achievements      *services.AchievementService
+	events            *services.EventBus
+
+	// Sessions whose attempts are being recorded by this instance
+	finishing sync.Map
+}
+
+// NewLiveSessionHandler creates a new live session handler
+func NewLiveSessionHandler(courseService *services.CourseService, leaderboards *services.LeaderboardService, achievements *services.AchievementService, events *services.EventBus) *LiveSessionHandler {
+	return &LiveSessionHandler{
+		collection:        config.GetCollection("live_sessions"),
+		attemptCollection: config.GetCollection("attempts"),
+		quizCollection:    config.GetCollection("quizzes"),
+		userCollection:    config.GetCollection("users"),
+		courseService:     courseService,
+		scoringService:    services.NewScoringService(),
+		statsService:      services.NewQuizStatsService(),
+		reviews:           services.NewReviewService(),
+		leaderboards:      leaderboards,
+		achievements:      achievements,
+		events:            events,
+	}
+}
+
+// CreateSessionRequest represents the request to open a live session
+type CreateSessionRequest struct {
+	QuizID string `json:"quiz_id" binding:"required" example:"507f1f77bcf86cd799439011"`
+}
+
+// JoinSessionRequest represents a student joining a live session
+type JoinSessionRequest struct {
+	JoinCode string `json:"join_code" binding:"required" example:"K7QX2M"`
+}
+
+// SessionAnswerRequest represents an answer to the open question of a live session
+type SessionAnswerRequest struct {
+	Answer string `json:"answer" binding:"required" example:"true"`
+}
+
+// LiveQuestion is the question currently shown in a live session
+type LiveQuestion struct {
+	Index    int             `json:"index"`
+	Total    int             `json:"total"`
+	Question models.Question `json:"question"`
+	ClosesAt *time.Time      `json:"closes_at,omitempty"`
+}
+
+// LiveSessionView is a live session together with its current question
+type LiveSessionView struct {
+	models.LiveSession
+	QuizTitle string        `json:"quiz_title"`
+	Question  *LiveQuestion `json:"current"`
+}
+
+// LiveStanding is a participant's position in a live session
+type LiveStanding struct {
+	Rank          int                `json:"rank"`
+	StudentID     primitive.ObjectID `json:"student_id"`
+	StudentName   string             `json:"student_name"`
+	Score         float64            `json:"score"`
+	CorrectCount  int                `json:"correct_count"`
+	AnsweredCount int                `json:"answered_count"`
+}
+
+// CreateSession godoc
+// @Summary      Open a live session
+// @Description  Open a live session of an approved quiz in the lobby state and get its join code (professors only)
+// @Tags         sessions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body CreateSessionRequest true "Quiz to host"
+// @Success      201 {object} LiveSessionView
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /sessions [post]
+func (h *LiveSessionHandler) CreateSession(c *gin.Context) {
+	var req CreateSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quizID, err := primitive.ObjectIDFromHex(req.QuizID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quiz ID"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	hostID := userID.(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var quiz models.Quiz
+	if err := h.quizCollection.FindOne(ctx, bson.M{"_id": quizID}).Decode(&quiz); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Quiz not found"})
+		return
+	}
+
+	if quiz.Status != models.StatusApproved {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only approved quizzes can be hosted"})
+		return
+	}
+
+	if quizClosed(&quiz, time.Now()) {
+		c.JSON(http.StatusForbidden, gin.H{"error": errQuizClosed})
+		return
+	}
+
+	if len(quiz.Questions) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Quiz has no questions"})
+		return
+	}
+
+	session := models.LiveSession{
+		ID:              primitive.NewObjectID(),
+		QuizID:          quizID,
+		HostID:          hostID,
+		Status:          models.SessionLobby,
+		CurrentQuestion: -1,
+		Participants:    []models.LiveParticipant{},
+		CreatedAt:       time.Now(),
+	}
+
+	// Join codes only need to be unique among running sessions
+	for try := 1; ; try++ {
+		session.JoinCode = newJoinCode()
+		count, err := h.collection.CountDocuments(ctx, bson.M{
+			"join_code": session.JoinCode,
+			"status":    bson.M{"$in": activeSessionStatuses},
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+			return
+		}
+		if count == 0 {
+			break
+		}
+		if try == maxJoinCodeTries {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Could not allocate a join code, please retry"})
+			return
+		}
+	}
+
+	if _, err := h.collection.InsertOne(ctx, session); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+
+	log.Printf("CreateSession: Opened session %s (code %s) for quiz %s", session.ID.Hex(), session.JoinCode, quizID.Hex())
+	c.JSON(http.StatusCreated, newLiveSessionView(&session, &quiz, true))
+}
+
+// JoinSession godoc
+// @Summary      Join a live session
+// @Description  Join a running live session with its join code (students only, requires course completion). Students may join late; questions already closed count as unanswered
+// @Tags         sessions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body JoinSessionRequest true "Join code"
+// @Success      200 {object} LiveSessionView
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /sessions/join [post]
+func (h *LiveSessionHandler) JoinSession(c *gin.Context) {
+	var req JoinSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	studentID := userID.(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var session models.LiveSession
+	err := h.collection.FindOne(ctx, bson.M{
+		"join_code": strings.ToUpper(strings.TrimSpace(req.JoinCode)),
+		"status":    bson.M{"$in": activeSessionStatuses},
+	}).Decode(&session)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No running session with this join code"})
+		return
+	}
+
+	quiz, ok := h.loadSessionQuiz(ctx, c, &session)
+	if !ok {
+		return
+	}
+
+	// Joining again returns the session as is
+	if findParticipant(&session, studentID) != nil {
+		c.JSON(http.StatusOK, newLiveSessionView(&session, quiz, false))
+		return
+	}
+
+	if quizClosed(quiz, time.Now()) {
+		c.JSON(http.StatusForbidden, gin.H{"error": errQuizClosed})
+		return
+	}
+
+	completed, err := h.courseService.CheckCourseCompletion(ctx, studentID, quiz.CourseID)
+	if errors.Is(err, services.ErrCourseAPIUnavailable) {
+		log.Printf("JoinSession: %v (course: %s)", err, quiz.CourseID)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Course completion can't be verified right now, please retry later"})
+		return
+	}
+	if err != nil {
+		log.Printf("JoinSession: %v (course: %s)", err, quiz.CourseID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify course completion"})
+		return
+	}
+
+	if !completed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You must complete the required course before joining this session"})
+		return
+	}
+
+	scoring, err := h.scoringService.ResolvePolicy(quiz.Scoring)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Quiz has an invalid scoring policy"})
+		return
+	}
+
+	maxScore, err := h.scoringService.MaxScore(quiz, scoring)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Quiz has an invalid scoring policy"})
+		return
+	}
+
+	now := time.Now()
+	attempt := models.QuizAttempt{
+		ID:        primitive.NewObjectID(),
+		QuizID:    quiz.ID,
+		StudentID: studentID,
+		SessionID: &session.ID,
+		Answers:   []models.Answer{},
+		MaxScore:  maxScore,
+		StartedAt: now,
+		Scoring:   scoring,
+	}
+
+	if _, err := h.attemptCollection.InsertOne(ctx, attempt); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join session"})
+		return
+	}
+
+	participant := models.LiveParticipant{
+		StudentID:   studentID,
+		StudentName: h.studentName(ctx, studentID),
+		AttemptID:   attempt.ID,
+		JoinedAt:    now,
+	}
+
+	// The filter rejects a second join racing this one and sessions that ended meanwhile
+	result, err := h.collection.UpdateOne(ctx, bson.M{
+		"_id":                     session.ID,
+		"status":                  bson.M{"$in": activeSessionStatuses},
+		"participants.student_id": bson.M{"$ne": studentID},
+	}, bson.M{"$push": bson.M{"participants": participant}})
+	if err != nil || result.MatchedCount == 0 {
+		if _, delErr := h.attemptCollection.DeleteOne(ctx, bson.M{"_id": attempt.ID}); delErr != nil {
+			log.Printf("JoinSession: %v (attempt: %s)", delErr, attempt.ID.Hex())
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join session"})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": "Session has ended or you already joined, please refresh"})
+		return
+	}
+	session.Participants = append(session.Participants, participant)
+
+	h.events.PublishSession(services.EventSessionParticipantJoined, session.ID, session.QuizID, gin.H{
+		"student_id":        participant.StudentID,
+		"student_name":      participant.StudentName,
+		"participant_count": len(session.Participants),
+	})
+
+	c.JSON(http.StatusOK, newLiveSessionView(&session, quiz, false))
+}
+
+// GetSession godoc
+// @Summary      Get a live session
+// @Description  Get the state of a live session and its current question. Students only see the correct answer to a closed question if the quiz review policy reveals answers, and never see hints. Getting a finished session whose attempts were not all recorded resumes recording them
+// @Tags         sessions
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Session ID"
+// @Success      200 {object} LiveSessionView
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /sessions/{id} [get]
+func (h *LiveSessionHandler) GetSession(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, isHost, ok := h.loadSessionMember(ctx, c)
+	if !ok {
+		return
+	}
+
+	quiz, ok := h.loadSessionQuiz(ctx, c, session)
+	if !ok {
+		return
+	}
+
+	// Resume recording the attempts if finishing the session was interrupted
+	if session.Status == models.SessionFinished && session.RecordedAt == nil {
+		go h.finishSession(*session, quiz)
+	}
+
+	c.JSON(http.StatusOK, newLiveSessionView(session, quiz, isHost))
+}
+
+// AdvanceSession godoc
+// @Summary      Advance a live session
+// @Description  Move a live session to its next state (host only): the lobby or the standings open the next question, an open question closes and shows the standings, and closing the last question finishes the session and records every attempt
+// @Tags         sessions
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Session ID"
+// @Success      200 {object} LiveSessionView
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Router       /sessions/{id}/advance [post]
+func (h *LiveSessionHandler) AdvanceSession(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	session, ok := h.loadHostedSession(ctx, c)
+	if !ok {
+		return
+	}
+
+	quiz, ok := h.loadSessionQuiz(ctx, c, session)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	set := bson.M{}
+	switch session.Status {
+	case models.SessionLobby, models.SessionLeaderboard:
+		set["status"] = models.SessionQuestion
+		set["current_question"] = session.CurrentQuestion + 1
+		set["question_started_at"] = now
+		if session.StartedAt == nil {
+			set["started_at"] = now
+		}
+	case models.SessionQuestion:
+		if session.CurrentQuestion == len(quiz.Questions)-1 {
+			set["status"] = models.SessionFinished
+			set["finished_at"] = now
+		} else {
+			set["status"] = models.SessionLeaderboard
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Session has ended"})
+		return
+	}
+
+	// Only one advance applies per state, even if the host double-clicks
+	if !h.transition(ctx, c, session, set) {
+		return
+	}
+
+	switch session.Status {
+	case models.SessionQuestion:
+		question := quiz.Questions[session.CurrentQuestion]
+		closesAt := session.QuestionStartedAt.Add(time.Duration(question.TimeLimit) * time.Second)
+		h.events.PublishSession(services.EventSessionQuestionOpened, session.ID, session.QuizID, LiveQuestion{
+			Index:    session.CurrentQuestion,
+			Total:    len(quiz.Questions),
+			Question: hiddenQuestion(question),
+			ClosesAt: &closesAt,
+		})
+	case models.SessionLeaderboard:
+		h.publishQuestionClosed(ctx, session, quiz)
+	case models.SessionFinished:
+		h.publishQuestionClosed(ctx, session, quiz)
+		h.finishSession(*session, quiz)
+	}
+
+	c.JSON(http.StatusOK, newLiveSessionView(session, quiz, true))
+}
+
+// EndSession godoc
+// @Summary      End a live session early
+// @Description  Finish a running live session before its last question (host only). Attempts are recorded with the questions answered so far
+// @Tags         sessions
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Session ID"
+// @Success      200 {object} LiveSessionView
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Router       /sessions/{id}/end [post]
+func (h *LiveSessionHandler) EndSession(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	session, ok := h.loadHostedSession(ctx, c)
+	if !ok {
+		return
+	}
+
+	if session.Status == models.SessionLobby {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Session has not started, cancel it instead"})
+		return
+	}
+	if !isActiveSession(session) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Session has ended"})
+		return
+	}
+
+	quiz, ok := h.loadSessionQuiz(ctx, c, session)
+	if !ok {
+		return
+	}
+
+	wasOpen := session.Status == models.SessionQuestion
+	if !h.transition(ctx, c, session, bson.M{"status": models.SessionFinished, "finished_at": time.Now()}) {
+		return
+	}
+
+	if wasOpen {
+		h.publishQuestionClosed(ctx, session, quiz)
+	}
+	h.finishSession(*session, quiz)
+
+	c.JSON(http.StatusOK, newLiveSessionView(session, quiz, true))
+}
+
+// CancelSession godoc
+// @Summary      Cancel a live session
+// @Description  Cancel a running live session (host only). Its attempts are discarded and never reach the leaderboards
+// @Tags         sessions
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Session ID"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Router       /sessions/{id} [delete]
+func (h *LiveSessionHandler) CancelSession(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	session, ok := h.loadHostedSession(ctx, c)
+	if !ok {
+		return
+	}
+
+	if !isActiveSession(session) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Session has ended"})
+		return
+	}
+
+	if !h.transition(ctx, c, session, bson.M{"status": models.SessionCancelled, "finished_at": time.Now()}) {
+		return
+	}
+
+	result, err := h.attemptCollection.DeleteMany(ctx, bson.M{
+		"session_id":   session.ID,
+		"completed_at": bson.M{"$exists": false},
+	})
+	if err != nil {
+		log.Printf("CancelSession: %v (session: %s)", err, session.ID.Hex())
+	} else {
+		log.Printf("CancelSession: Cancelled session %s, discarded %d attempts", session.ID.Hex(), result.DeletedCount)
+	}
+
+	h.events.PublishSession(services.EventSessionCancelled, session.ID, session.QuizID, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session cancelled"})
+}
+
+// SubmitSessionAnswer godoc
+// @Summary      Answer the open question
+// @Description  Answer the question currently open in a live session (participants only). The time to answer is measured by the server from the moment the question opened
+// @Tags         sessions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Session ID"
+// @Param        request body SessionAnswerRequest true "Answer"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /sessions/{id}/answer [post]
+func (h *LiveSessionHandler) SubmitSessionAnswer(c *gin.Context) {
+	var req SessionAnswerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	receivedAt := time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	session, isHost, ok := h.loadSessionMember(ctx, c)
+	if !ok {
+		return
+	}
+
+	if isHost {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only participants can answer"})
+		return
+	}
+
+	if session.Status != models.SessionQuestion || session.QuestionStartedAt == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No question is open"})
+		return
+	}
+
+	quiz, ok := h.loadSessionQuiz(ctx, c, session)
+	if !ok {
+		return
+	}
+
+	question := &quiz.Questions[session.CurrentQuestion]
+	elapsed := receivedAt.Sub(*session.QuestionStartedAt)
+	limit := time.Duration(question.TimeLimit) * time.Second
+	if elapsed > limit+liveAnswerGrace {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Time limit exceeded"})
+		return
+	}
+
+	timeToAnswer := int(elapsed.Seconds())
+	if timeToAnswer > question.TimeLimit {
+		timeToAnswer = question.TimeLimit
+	}
+
+	participant := findParticipant(session, c.MustGet("user_id").(primitive.ObjectID))
+
+	var attempt models.QuizAttempt
+	if err := h.attemptCollection.FindOne(ctx, bson.M{"_id": participant.AttemptID}).Decode(&attempt); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attempt not found"})
+		return
+	}
+
+	isCorrect := req.Answer == correctAnswerString(question.CorrectAnswer)
+	answer := models.Answer{
+		QuestionID:    question.ID,
+		StudentAnswer: req.Answer,
+		IsCorrect:     isCorrect,
+		TimeToAnswer:  timeToAnswer,
+		AnsweredAt:    receivedAt,
+	}
+
+	breakdown, err := h.scoringService.ScoreNextAnswer(quiz, &attempt, question, &answer)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid scoring policy"})
+		return
+	}
+	answer.PointsEarned = breakdown.Total
+	answer.Breakdown = &breakdown
+
+	// Same guard as self-paced answers: one answer per question, never after completion
+	result, err := h.attemptCollection.UpdateOne(ctx, bson.M{
+		"_id":                 attempt.ID,
+		"completed_at":        bson.M{"$exists": false},
+		"answers.question_id": bson.M{"$ne": question.ID},
+	}, bson.M{
+		"$push": bson.M{"answers": answer},
+		"$inc":  bson.M{"total_score": answer.PointsEarned},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save answer"})
+		return
+	}
+
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Answer already submitted for this question"})
+		return
+	}
+
+	answered, err := h.attemptCollection.CountDocuments(ctx, bson.M{
+		"session_id":          session.ID,
+		"answers.question_id": question.ID,
+	})
+	if err != nil {
+		log.Printf("SubmitSessionAnswer: %v (session: %s)", err, session.ID.Hex())
+	} else {
+		h.events.PublishSession(services.EventSessionAnswerReceived, session.ID, session.QuizID, gin.H{
+			"question_index":    session.CurrentQuestion,
+			"answered_count":    answered,
+			"participant_count": len(session.Participants),
+		})
+	}
+
+	// The outcome is revealed to everyone when the question closes
+	c.JSON(http.StatusOK, gin.H{
+		"time_to_answer": timeToAnswer,
+		"message":        "Answer submitted successfully",
+	})
+}
+
+// GetSessionLeaderboard godoc
+// @Summary      Get live session standings
+// @Description  Get the standings of a live session, ranked by score with equal scores sharing a rank. While a question is open, participants get the standings without that question; the host sees them live
+// @Tags         sessions
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Session ID"
+// @Success      200 {array} LiveStanding
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /sessions/{id}/leaderboard [get]
+func (h *LiveSessionHandler) GetSessionLeaderboard(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	session, isHost, ok := h.loadSessionMember(ctx, c)
+	if !ok {
+		return
+	}
+
+	// Only the host sees how participants did on the open question
+	var hidden *primitive.ObjectID
+	if session.Status == models.SessionQuestion && !isHost {
+		quiz, ok := h.loadSessionQuiz(ctx, c, session)
+		if !ok {
+			return
+		}
+		hidden = &quiz.Questions[session.CurrentQuestion].ID
+	}
+
+	standings, err := h.standings(ctx, session, hidden)
+	if err != nil {
+		log.Printf("GetSessionLeaderboard: %v (session: %s)", err, session.ID.Hex())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch standings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, standings)
+}
+
+// transition applies a state change to a session, provided nobody else changed
+// its state first, and updates the session in place
+func (h *LiveSessionHandler) transition(ctx context.Context, c *gin.Context, session *models.LiveSession, set bson.M) bool {
+	var updated models.LiveSession
+	err := h.collection.FindOneAndUpdate(ctx, bson.M{
+		"_id":              session.ID,
+		"status":           session.Status,
+		"current_question": session.CurrentQuestion,
+	}, bson.M{"$set": set}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Session changed concurrently, please refresh"})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update session"})
+		return false
+	}
+
+	*session = updated
+	return true
+}
+
+// finishSession completes and scores every attempt of a finished session, so
+// its results count like any other attempt. It can run again after being
+// interrupted: completed attempts are skipped, and the session is marked
+// recorded once no attempt is left
+func (h *LiveSessionHandler) finishSession(session models.LiveSession, quiz *models.Quiz) {
+	if _, running := h.finishing.LoadOrStore(session.ID, true); running {
+		return
+	}
+	defer h.finishing.Delete(session.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), finishSessionTimeout)
+	defer cancel()
+
+	cursor, err := h.attemptCollection.Find(ctx, bson.M{
+		"session_id":   session.ID,
+		"completed_at": bson.M{"$exists": false},
+	})
+	if err != nil {
+		log.Printf("FinishSession: %v (session: %s)", err, session.ID.Hex())
+		return
+	}
+
+	var attempts []models.QuizAttempt
+	if err := cursor.All(ctx, &attempts); err != nil {
+		log.Printf("FinishSession: %v (session: %s)", err, session.ID.Hex())
+		return
+	}
+
+	recorded, failed := 0, 0
+	for i := range attempts {
+		attempt := &attempts[i]
+		attempt.CompletedAt = session.FinishedAt
+		attempt.TimeTaken = liveTimeTaken(quiz, attempt, session.CurrentQuestion)
+		if err := h.scoringService.ScoreAttempt(quiz, attempt); err != nil {
+			log.Printf("FinishSession: %v (attempt: %s)", err, attempt.ID.Hex())
+			failed++
+			continue
+		}
+
+		// The session no longer accepts answers, so the stored answers are final
+		result, err := h.attemptCollection.UpdateOne(ctx, bson.M{
+			"_id":          attempt.ID,
+			"completed_at": bson.M{"$exists": false},
+		}, bson.M{"$set": completedAttemptFields(attempt)})
+		if err != nil {
+			log.Printf("FinishSession: attempt %s not completed: %v", attempt.ID.Hex(), err)
+			failed++
+			continue
+		}
+		if result.MatchedCount == 0 {
+			// Completed meanwhile by another instance
+			continue
+		}
+
+		if err := h.statsService.RecordCompletion(ctx, attempt.QuizID, attempt.Percentage); err != nil {
+			log.Printf("FinishSession: %v (quiz: %s)", err, attempt.QuizID.Hex())
+		}
+		if err := h.leaderboards.Record(ctx, quiz, attempt); err != nil {
+			log.Printf("FinishSession: %v (attempt: %s)", err, attempt.ID.Hex())
+		}
+		if err := h.reviews.QueueMissed(ctx, quiz, attempt); err != nil {
+			log.Printf("FinishSession: %v (attempt: %s)", err, attempt.ID.Hex())
+		}
+		if _, _, err := h.achievements.Evaluate(ctx, attempt); err != nil {
+			log.Printf("FinishSession: %v (attempt: %s)", err, attempt.ID.Hex())
+		}
+		recorded++
+	}
+
+	if failed == 0 {
+		if _, err := h.collection.UpdateOne(ctx, bson.M{"_id": session.ID}, bson.M{"$set": bson.M{"recorded_at": time.Now()}}); err != nil {
+			log.Printf("FinishSession: %v (session: %s)", err, session.ID.Hex())
+		}
+	}
+
+	standings, err := h.standings(ctx, &session, nil)
+	if err != nil {
+		log.Printf("FinishSession: %v (session: %s)", err, session.ID.Hex())
+	}
+	h.events.PublishSession(services.EventSessionFinished, session.ID, session.QuizID, gin.H{"standings": standings})
+	if recorded > 0 {
+		h.events.Publish(services.EventLeaderboardChanged, session.QuizID, gin.H{"reason": "live_session"})
+	}
+
+	log.Printf("FinishSession: Finished session %s, recorded %d attempts, %d failed", session.ID.Hex(), recorded, failed)
+}
+
+// publishQuestionClosed announces the question that just closed along with
+// the standings. Its answer is only revealed if the quiz review policy allows
+func (h *LiveSessionHandler) publishQuestionClosed(ctx context.Context, session *models.LiveSession, quiz *models.Quiz) {
+	question := quiz.Questions[session.CurrentQuestion]
+	standings, err := h.standings(ctx, session, nil)
+	if err != nil {
+		log.Printf("AdvanceSession: %v (session: %s)", err, session.ID.Hex())
+	}
+
+	event := gin.H{
+		"question_index": session.CurrentQuestion,
+		"question_id":    question.ID,
+		"standings":      standings,
+	}
+	if services.AnswersRevealed(quiz, time.Now()) {
+		event["correct_answer"] = question.CorrectAnswer
+		event["explanation"] = question.Explanation
+	}
+	h.events.PublishSession(services.EventSessionQuestionClosed, session.ID, session.QuizID, event)
+}
+
+// standings ranks the participants of a session by their running score.
+// Answers to the hidden question, if any, are left out so that the standings
+// do not tell who answered an open question correctly
+func (h *LiveSessionHandler) standings(ctx context.Context, session *models.LiveSession, hidden *primitive.ObjectID) ([]LiveStanding, error) {
+	opts := options.Find().SetProjection(bson.M{
+		"student_id":            1,
+		"total_score":           1,
+		"answers.question_id":   1,
+		"answers.is_correct":    1,
+		"answers.points_earned": 1,
+	})
+	cursor, err := h.attemptCollection.Find(ctx, bson.M{"session_id": session.ID}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var attempts []models.QuizAttempt
+	if err := cursor.All(ctx, &attempts); err != nil {
+		return nil, err
+	}
+
+	names := make(map[primitive.ObjectID]string, len(session.Participants))
+	for _, p := range session.Participants {
+		names[p.StudentID] = p.StudentName
+	}
+
+	standings := make([]LiveStanding, 0, len(attempts))
+	for _, attempt := range attempts {
+		score, correct, answered := attempt.TotalScore, 0, 0
+		for _, answer := range attempt.Answers {
+			if hidden != nil && answer.QuestionID == *hidden {
+				score -= answer.PointsEarned
+				continue
+			}
+			answered++
+			if answer.IsCorrect {
+				correct++
+			}
+		}
+		standings = append(standings, LiveStanding{
+			StudentID:     attempt.StudentID,
+			StudentName:   names[attempt.StudentID],
+			Score:         score,
+			CorrectCount:  correct,
+			AnsweredCount: answered,
+		})
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		if standings[i].Score != standings[j].Score {
+			return standings[i].Score > standings[j].Score
+		}
+		return standings[i].StudentName < standings[j].StudentName
+	})
+	for i := range standings {
+		if i > 0 && standings[i].Score == standings[i-1].Score {
+			standings[i].Rank = standings[i-1].Rank
+		} else {
+			standings[i].Rank = i + 1
+		}
+	}
+
+	return standings, nil
+}
+
+// loadSessionQuiz fetches the quiz of a session, responding with an error if it is gone
+func (h *LiveSessionHandler) loadSessionQuiz(ctx context.Context, c *gin.Context, session *models.LiveSession) (*models.Quiz, bool) {
+	var quiz models.Quiz
+	if err := h.quizCollection.FindOne(ctx, bson.M{"_id": session.QuizID}).Decode(&quiz); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Quiz not found"})
+		return nil, false
+	}
+	if session.CurrentQuestion >= len(quiz.Questions) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Quiz questions changed during the session"})
+		return nil, false
+	}
+	return &quiz, true
+}
+
+// loadSession fetches the session named by the :id route parameter
+func (h *LiveSessionHandler) loadSession(ctx context.Context, c *gin.Context) (*models.LiveSession, bool) {
+	sessionID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return nil, false
+	}
+
+	var session models.LiveSession
+	if err := h.collection.FindOne(ctx, bson.M{"_id": sessionID}).Decode(&session); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return nil, false
+	}
+	return &session, true
+}
+
+// loadHostedSession fetches a session hosted by the current user
+func (h *LiveSessionHandler) loadHostedSession(ctx context.Context, c *gin.Context) (*models.LiveSession, bool) {
+	session, ok := h.loadSession(ctx, c)
+	if !ok {
+		return nil, false
+	}
+
+	userID, _ := c.Get("user_id")
+	if session.HostID != userID.(primitive.ObjectID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the host can control this session"})
+		return nil, false
+	}
+	return session, true
+}
+
+// loadSessionMember fetches a session the current user hosts or takes part in
+func (h *LiveSessionHandler) loadSessionMember(ctx context.Context, c *gin.Context) (*models.LiveSession, bool, bool) {
+	session, ok := h.loadSession(ctx, c)
+	if !ok {
+		return nil, false, false
+	}
+
+	userID, _ := c.Get("user_id")
+	if session.HostID == userID.(primitive.ObjectID) {
+		return session, true, true
+	}
+	if findParticipant(session, userID.(primitive.ObjectID)) == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You have not joined this session"})
+		return nil, false, false
+	}
+	return session, false, true
+}
+
+// studentName returns a student's display name, or an empty string if unknown
+func (h *LiveSessionHandler) studentName(ctx context.Context, studentID primitive.ObjectID) string {
+	var user models.User
+	opts := options.FindOne().SetProjection(bson.M{"first_name": 1, "last_name": 1})
+	if err := h.userCollection.FindOne(ctx, bson.M{"_id": studentID}, opts).Decode(&user); err != nil {
+		return ""
+	}
+	return user.FirstName + " " + user.LastName
+}
+
+// newLiveSessionView builds the response for a session. Participants never
+// see hints, and only see the answer to a closed question if the quiz review
+// policy reveals it
+func newLiveSessionView(session *models.LiveSession, quiz *models.Quiz, isHost bool) LiveSessionView {
+	view := LiveSessionView{LiveSession: *session, QuizTitle: quiz.Title}
+	if !isHost {
+		view.JoinCode = ""
+	}
+
+	if session.CurrentQuestion < 0 || session.QuestionStartedAt == nil || !isActiveSession(session) {
+		return view
+	}
+
+	question := quiz.Questions[session.CurrentQuestion]
+	current := &LiveQuestion{
+		Index:    session.CurrentQuestion,
+		Total:    len(quiz.Questions),
+		Question: question,
+	}
+	if session.Status == models.SessionQuestion {
+		closesAt := session.QuestionStartedAt.Add(time.Duration(question.TimeLimit) * time.Second)
+		current.ClosesAt = &closesAt
+	}
+	if !isHost {
+		current.Question = hiddenQuestion(question)
+		if session.Status != models.SessionQuestion && services.AnswersRevealed(quiz, time.Now()) {
+			current.Question.CorrectAnswer = question.CorrectAnswer
+			current.Question.Explanation = question.Explanation
+		}
+	}
+	view.Question = current
+	return view
+}
+
+// hiddenQuestion strips the answer, explanation and hints from a question
+func hiddenQuestion(question models.Question) models.Question {
+	question.CorrectAnswer = nil
+	question.Explanation = ""
+	question.Hints = nil
+	return question
+}
+
+// liveTimeTaken is the time an attempt spent on the questions opened in a
+// session, counting unanswered questions at their full time limit
+func liveTimeTaken(quiz *models.Quiz, attempt *models.QuizAttempt, lastOpened int) int {
+	answered := make(map[primitive.ObjectID]int, len(attempt.Answers))
+	for _, answer := range attempt.Answers {
+		answered[answer.QuestionID] = answer.TimeToAnswer
+	}
+
+	total := 0
+	for i := 0; i <= lastOpened && i < len(quiz.Questions); i++ {
+		if t, ok := answered[quiz.Questions[i].ID]; ok {
+			total += t
+		} else {
+			total += quiz.Questions[i].TimeLimit
+		}
+	}
+	return total
+}
+
+// findParticipant returns the participant entry of a student, or nil
+func findParticipant(session *models.LiveSession, studentID primitive.ObjectID) *models.LiveParticipant {
+	for i := range session.Participants {
+		if session.Participants[i].StudentID == studentID {
+			return &session.Participants[i]
+		}
+	}
+	return nil
+}
+
+// isActiveSession reports whether a session is still running
+func isActiveSession(session *models.LiveSession) bool {
+	for _, status := range activeSessionStatuses {
+		if session.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
+// newJoinCode generates a random join code
+func newJoinCode() string {
+	code := make([]byte, joinCodeLength)
+	for i := range code {
+		code[i] = joinCodeAlphabet[rand.Intn(len(joinCodeAlphabet))]
+	}
+	return string(code)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NextCursorHeader carries the cursor of the next page on paginated list responses
+const NextCursorHeader = "X-Next-Cursor"
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// pageCursor is the position of the last item of a page: its sort value and ID
+type pageCursor struct {
+	Value interface{}        `bson:"v"`
+	ID    primitive.ObjectID `bson:"id"`
+}
+
+// parsePageLimit reads the limit query parameter, defaulting to 20 and capped at 100
+func parsePageLimit(c *gin.Context) (int, error) {
+	raw := c.Query("limit")
+	if raw == "" {
+		return defaultPageLimit, nil
+	}
+
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit < 1 {
+		return 0, errors.New("limit must be a positive integer")
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	return limit, nil
+}
+
+// encodeCursor builds an opaque cursor pointing after the given document
+func encodeCursor(doc bson.Raw, sortField string) (string, error) {
+	data, err := bson.Marshal(bson.M{
+		"v":  doc.Lookup(sortField),
+		"id": doc.Lookup("_id"),
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor parses a cursor produced by encodeCursor
+func decodeCursor(raw string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	var cursor pageCursor
+	if err := bson.Unmarshal(data, &cursor); err != nil || cursor.ID.IsZero() {
+		return nil, errInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// afterCursor matches documents that come after the cursor when sorting by
+// sortField and then _id, both in the given direction (1 or -1)
+func afterCursor(sortField string, direction int, cursor *pageCursor) bson.M {
+	op := "$gt"
+	if direction < 0 {
+		op = "$lt"
+	}
+
+	return bson.M{"$or": bson.A{
+		bson.M{sortField: bson.M{op: cursor.Value}},
+		bson.M{sortField: cursor.Value, "_id": bson.M{op: cursor.ID}},
+	}}
+}
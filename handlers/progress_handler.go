@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"quizmasterapi/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProgressHandler handles student activity streaks, weekly goals and performance stats
+type ProgressHandler struct {
+	progress *services.ProgressService
+	stats    *services.StatsService
+}
+
+// NewProgressHandler creates a new progress handler
+func NewProgressHandler(progress *services.ProgressService, stats *services.StatsService) *ProgressHandler {
+	return &ProgressHandler{
+		progress: progress,
+		stats:    stats,
+	}
+}
+
+// UpdateProgressSettingsRequest changes how streaks and goals are counted
+type UpdateProgressSettingsRequest struct {
+	Timezone   *string `json:"timezone,omitempty" example:"Europe/Paris"` // IANA timezone name
+	WeeklyGoal *int    `json:"weekly_goal,omitempty" example:"3"`         // Quizzes per week, 1 to 50
+}
+
+// GetMyProgress godoc
+// @Summary      Get my streak and weekly goal progress
+// @Description  Daily activity streak and weekly goal progress of the current student, computed from completed attempts with days in the student's timezone. Missed days are covered automatically by freeze tokens when that keeps the streak alive; a token is earned for each week the goal is met (max 2)
+// @Tags         progress
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} services.ProgressReport
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /users/me/progress [get]
+func (h *ProgressHandler) GetMyProgress(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	studentID := userID.(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	report, err := h.progress.Progress(ctx, studentID, time.Now())
+	if err != nil {
+		log.Printf("GetMyProgress: %v (student: %s)", err, studentID.Hex())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute progress"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// UpdateProgressSettings godoc
+// @Summary      Update my progress settings
+// @Description  Set the timezone used for day boundaries and the weekly goal of the current student
+// @Tags         progress
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body UpdateProgressSettingsRequest true "Settings to change"
+// @Success      200 {object} models.StudentProgress
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /users/me/progress [put]
+func (h *ProgressHandler) UpdateProgressSettings(c *gin.Context) {
+	var req UpdateProgressSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Timezone == nil && req.WeeklyGoal == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update, set timezone or weekly_goal"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	studentID := userID.(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	settings, err := h.progress.UpdateSettings(ctx, studentID, req.Timezone, req.WeeklyGoal)
+	if errors.Is(err, services.ErrInvalidProgressSettings) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("UpdateProgressSettings: %v (student: %s)", err, studentID.Hex())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// GetMyStats godoc
+// @Summary      Get my performance stats
+// @Description  Progress dashboard of the current student over their counted attempts: overall, per category and per difficulty attempts, average percentage, accuracy and response time, a weekly trend, accuracy by question type and the weakest category and difficulty combinations
+// @Tags         progress
+// @Produce      json
+// @Security     BearerAuth
+// @Param        weeks query int false "Weeks covered by the trend (default 12, max 52)"
+// @Success      200 {object} services.StudentStats
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /users/me/stats [get]
+func (h *ProgressHandler) GetMyStats(c *gin.Context) {
+	weeks := services.DefaultTrendWeeks
+	if value := c.Query("weeks"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > services.MaxTrendWeeks {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "weeks must be between 1 and 52"})
+			return
+		}
+		weeks = parsed
+	}
+
+	userID, _ := c.Get("user_id")
+	studentID := userID.(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	settings, err := h.progress.Settings(ctx, studentID)
+	if err != nil {
+		log.Printf("GetMyStats: %v (student: %s)", err, studentID.Hex())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute stats"})
+		return
+	}
+
+	stats, err := h.stats.StudentStats(ctx, studentID, settings.Timezone, weeks, time.Now())
+	if err != nil {
+		log.Printf("GetMyStats: %v (student: %s)", err, studentID.Hex())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
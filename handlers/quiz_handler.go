@@ -16,31 +16,40 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // QuizHandler handles quiz-related requests
 type QuizHandler struct {
-	collection    *mongo.Collection
-	courseService *services.CourseService
+	collection     *mongo.Collection
+	courseService  *services.CourseService
+	scoringService *services.ScoringService
+	events         *services.EventBus
 }
 
 // NewQuizHandler creates a new quiz handler
-func NewQuizHandler() *QuizHandler {
+func NewQuizHandler(courseService *services.CourseService, events *services.EventBus) *QuizHandler {
 	return &QuizHandler{
-		collection:    config.GetCollection("quizzes"),
-		courseService: services.NewCourseService(),
+		collection:     config.GetCollection("quizzes"),
+		courseService:  courseService,
+		scoringService: services.NewScoringService(),
+		events:         events,
 	}
 }
 
 // CreateQuizRequest represents the request to create a quiz
 type CreateQuizRequest struct {
-	Title           string                  `json:"title" binding:"required" example:"Introduction to Go Programming"`
-	Description     string                  `json:"description" example:"Basic concepts of Go programming language"`
-	Category        models.QuizCategory     `json:"category" binding:"required" example:"programming"`
-	DifficultyLevel models.DifficultyLevel  `json:"difficulty_level" binding:"required" enums:"easy,medium,hard" example:"easy"`
-	CourseID        string                  `json:"course_id" binding:"required" example:"course123"`
-	Questions       []CreateQuestionRequest `json:"questions" binding:"required,min=1"`
+	Title           string                   `json:"title" binding:"required" example:"Introduction to Go Programming"`
+	Description     string                   `json:"description" example:"Basic concepts of Go programming language"`
+	Category        models.QuizCategory      `json:"category" binding:"required" example:"programming"`
+	DifficultyLevel models.DifficultyLevel   `json:"difficulty_level" binding:"required" enums:"easy,medium,hard" example:"easy"`
+	CourseID        string                   `json:"course_id" binding:"required" example:"course123"`
+	Questions       []CreateQuestionRequest  `json:"questions" binding:"required,min=1"`
+	ReviewPolicy    models.ReviewPolicy      `json:"review_policy" enums:"immediately,after_close,never" example:"immediately"`
+	ClosesAt        *time.Time               `json:"closes_at,omitempty" example:"2024-06-30T23:59:00Z"`
+	Scoring         *models.ScoringPolicy    `json:"scoring,omitempty"`
+	Lifelines       []models.Lifeline        `json:"lifelines,omitempty"`
+	Adaptive        *models.AdaptiveSettings `json:"adaptive,omitempty"` // Questions become a pool served by ability
+	Practice        *models.PracticeSettings `json:"practice,omitempty"` // Allows unranked practice attempts
 }
 
 // CreateQuestionRequest represents a question in the create quiz request
@@ -50,6 +59,8 @@ type CreateQuestionRequest struct {
 	Options       []string            `json:"options,omitempty" swaggertype:"array,string"`
 	CorrectAnswer string              `json:"correct_answer" binding:"required" example:"true"`
 	Points        int                 `json:"points" binding:"required,min=1" example:"10"`
+	Explanation   string              `json:"explanation,omitempty" example:"Go checks types at compile time."`
+	Hints         []models.Hint       `json:"hints,omitempty"`
 }
 
 // CreateQuiz godoc
@@ -96,6 +107,15 @@ func (h *QuizHandler) CreateQuiz(c *gin.Context) {
 			return
 		}
 
+		// Validate hints, each penalty is a fraction of the question's points
+		for _, hint := range q.Hints {
+			if strings.TrimSpace(hint.Text) == "" || hint.Penalty < 0 || hint.Penalty > 1 {
+				log.Printf("CreateQuiz: Invalid hint for question %d", i+1)
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Hints must have text and a penalty between 0 and 1"})
+				return
+			}
+		}
+
 		questions[i] = models.Question{
 			ID:            primitive.NewObjectID(),
 			QuestionText:  q.QuestionText,
@@ -105,7 +125,83 @@ func (h *QuizHandler) CreateQuiz(c *gin.Context) {
 			TimeLimit:     15, // Default 15 seconds
 			Points:        q.Points,
 			Order:         i + 1,
+			Explanation:   q.Explanation,
+			Hints:         q.Hints,
+		}
+	}
+
+	// Validate lifelines, defaulting to a single use each
+	lifelines := make([]models.Lifeline, 0, len(req.Lifelines))
+	seenLifelines := make(map[models.AssistType]bool)
+	for _, lifeline := range req.Lifelines {
+		if lifeline.Type != models.AssistFiftyFifty && lifeline.Type != models.AssistSkip {
+			log.Printf("CreateQuiz: Invalid lifeline type: %s", lifeline.Type)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Lifeline type must be 'fifty_fifty' or 'skip'"})
+			return
 		}
+		if seenLifelines[lifeline.Type] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Each lifeline can only be configured once"})
+			return
+		}
+		seenLifelines[lifeline.Type] = true
+
+		if lifeline.Uses == 0 {
+			lifeline.Uses = 1
+		}
+		if lifeline.Uses < 0 || lifeline.Penalty < 0 || lifeline.Penalty > 1 {
+			log.Printf("CreateQuiz: Invalid lifeline configuration for %s", lifeline.Type)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Lifeline uses must be positive and penalty between 0 and 1"})
+			return
+		}
+		lifelines = append(lifelines, lifeline)
+	}
+
+	// Validate review policy, defaulting to immediate review
+	reviewPolicy := req.ReviewPolicy
+	switch reviewPolicy {
+	case "":
+		reviewPolicy = models.ReviewImmediately
+	case models.ReviewImmediately, models.ReviewNever:
+	case models.ReviewAfterClose:
+		if req.ClosesAt == nil {
+			log.Printf("CreateQuiz: Review policy %s requires closes_at", reviewPolicy)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "closes_at is required when review_policy is after_close"})
+			return
+		}
+	default:
+		log.Printf("CreateQuiz: Invalid review policy: %s", reviewPolicy)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review policy"})
+		return
+	}
+
+	// Practice shows the correct answer after each answer
+	if req.Practice != nil && req.Practice.Enabled && reviewPolicy != models.ReviewImmediately {
+		log.Printf("CreateQuiz: Practice enabled with review policy %s", reviewPolicy)
+		c.JSON(http.StatusBadRequest, gin.H{"error": errPracticeReviewPolicy})
+		return
+	}
+
+	// Validate scoring policy and store it with all parameters resolved
+	var scoring *models.ScoringPolicy
+	if req.Scoring != nil {
+		resolved, err := h.scoringService.ResolvePolicy(req.Scoring)
+		if err != nil {
+			log.Printf("CreateQuiz: Invalid scoring policy - %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		scoring = resolved
+	}
+
+	// Validate adaptive mode, each attempt is served question_count questions of the pool
+	var adaptive *models.AdaptiveSettings
+	if req.Adaptive != nil {
+		if req.Adaptive.QuestionCount < 1 || req.Adaptive.QuestionCount > len(questions) {
+			log.Printf("CreateQuiz: Invalid adaptive question count %d", req.Adaptive.QuestionCount)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "adaptive.question_count must be between 1 and the number of questions"})
+			return
+		}
+		adaptive = &models.AdaptiveSettings{QuestionCount: req.Adaptive.QuestionCount}
 	}
 
 	// Determine quiz status based on creator role
@@ -125,6 +221,12 @@ func (h *QuizHandler) CreateQuiz(c *gin.Context) {
 		CreatorRole:     role,
 		Status:          status,
 		Questions:       questions,
+		ReviewPolicy:    reviewPolicy,
+		Scoring:         scoring,
+		Lifelines:       lifelines,
+		ClosesAt:        req.ClosesAt,
+		Adaptive:        adaptive,
+		Practice:        req.Practice,
 		CreatedAt:       time.Now(),
 		UpdatedAt:       time.Now(),
 	}
@@ -142,9 +244,23 @@ func (h *QuizHandler) CreateQuiz(c *gin.Context) {
 	c.JSON(http.StatusCreated, quiz)
 }
 
+// quizSortOption describes how a quiz list sort is applied
+type quizSortOption struct {
+	field     string
+	direction int
+}
+
+// quizSortOptions lists the supported sort query values
+var quizSortOptions = map[string]quizSortOption{
+	"newest":        {field: "created_at", direction: -1},
+	"popularity":    {field: "attempt_count", direction: -1},
+	"average_score": {field: "average_score", direction: -1},
+	"title":         {field: "title", direction: 1},
+}
+
 // GetQuizzes godoc
 // @Summary      List quizzes
-// @Description  Get a list of quizzes with optional filters (category, difficulty, status)
+// @Description  Get a page of quizzes with optional filters, full-text search and sorting. The cursor of the next page is returned in the X-Next-Cursor header when more results exist
 // @Tags         quizzes
 // @Accept       json
 // @Produce      json
@@ -152,7 +268,16 @@ func (h *QuizHandler) CreateQuiz(c *gin.Context) {
 // @Param        category query string false "Filter by category"
 // @Param        difficulty query string false "Filter by difficulty level" Enums(easy, medium, hard)
 // @Param        status query string false "Filter by status (professors only)" Enums(pending, approved, rejected)
+// @Param        course_id query string false "Filter by course ID"
+// @Param        creator_id query string false "Filter by creator ID"
+// @Param        q query string false "Full-text search over title and description"
+// @Param        sort query string false "Sort order" Enums(newest, popularity, average_score, title) default(newest)
+// @Param        view query string false "Response shape, summary leaves out questions. Students always get summaries" Enums(full, summary) default(full)
+// @Param        limit query int false "Page size (max 100)" default(20)
+// @Param        cursor query string false "Cursor from the X-Next-Cursor header of the previous page"
 // @Success      200 {array} models.Quiz
+// @Header       200 {string} X-Next-Cursor "Cursor of the next page, absent on the last page"
+// @Failure      400 {object} map[string]string
 // @Failure      401 {object} map[string]string
 // @Failure      500 {object} map[string]string
 // @Router       /quizzes [get]
@@ -160,15 +285,35 @@ func (h *QuizHandler) GetQuizzes(c *gin.Context) {
 	category := strings.ToLower(c.Query("category"))
 	difficulty := strings.ToLower(c.Query("difficulty"))
 	status := strings.ToLower(c.Query("status"))
+	search := strings.TrimSpace(c.Query("q"))
+	view := strings.ToLower(c.DefaultQuery("view", "full"))
 	userRole, _ := c.Get("user_role")
 
-	log.Printf("GetQuizzes: Request with filters - category: %s, difficulty: %s, status: %s, userRole: %v", category, difficulty, status, userRole)
+	log.Printf("GetQuizzes: Request with filters - category: %s, difficulty: %s, status: %s, q: %q, userRole: %v", category, difficulty, status, search, userRole)
+
+	sortOption, ok := quizSortOptions[strings.ToLower(c.DefaultQuery("sort", "newest"))]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be one of: newest, popularity, average_score, title"})
+		return
+	}
+
+	if view != "full" && view != "summary" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "view must be 'full' or 'summary'"})
+		return
+	}
+
+	limit, err := parsePageLimit(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	filter := bson.M{}
 
-	// Only show approved quizzes to students
+	// Only show approved quizzes to students, without their questions
 	if userRole.(models.UserRole) == models.RoleStudent {
 		filter["status"] = models.StatusApproved
+		view = "summary"
 	} else if status != "" {
 		filter["status"] = status
 	}
@@ -181,14 +326,63 @@ func (h *QuizHandler) GetQuizzes(c *gin.Context) {
 		filter["difficulty_level"] = difficulty
 	}
 
+	if courseID := c.Query("course_id"); courseID != "" {
+		filter["course_id"] = courseID
+	}
+
+	if creator := c.Query("creator_id"); creator != "" {
+		creatorID, err := primitive.ObjectIDFromHex(creator)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid creator ID"})
+			return
+		}
+		filter["creator_id"] = creatorID
+	}
+
+	if search != "" {
+		filter["$text"] = bson.M{"$search": search}
+	}
+
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, err := decodeCursor(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		filter["$and"] = bson.A{afterCursor(sortOption.field, sortOption.direction, cursor)}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$sort", Value: bson.D{
+			{Key: sortOption.field, Value: sortOption.direction},
+			{Key: "_id", Value: sortOption.direction},
+		}}},
+		// Fetch one extra quiz to know whether another page exists
+		{{Key: "$limit", Value: limit + 1}},
+	}
+	if view == "summary" {
+		pipeline = append(pipeline, bson.D{{Key: "$project", Value: bson.M{
+			"title":            1,
+			"description":      1,
+			"category":         1,
+			"difficulty_level": 1,
+			"course_id":        1,
+			"creator_id":       1,
+			"creator_role":     1,
+			"status":           1,
+			"attempt_count":    1,
+			"average_score":    1,
+			"practice":         1,
+			"created_at":       1,
+			"question_count":   bson.M{"$size": bson.M{"$ifNull": bson.A{"$questions", bson.A{}}}},
+		}}})
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Debug: Log the filter being used
-	// fmt.Printf("Filter: %+v\n", filter)
-
-	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
-	cursor, err := h.collection.Find(ctx, filter, opts)
+	cursor, err := h.collection.Aggregate(ctx, pipeline)
 	if err != nil {
 		log.Printf("GetQuizzes: Failed to fetch quizzes - %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch quizzes"})
@@ -196,25 +390,55 @@ func (h *QuizHandler) GetQuizzes(c *gin.Context) {
 	}
 	defer cursor.Close(ctx)
 
-	quizzes := make([]models.Quiz, 0)
-	if err := cursor.All(ctx, &quizzes); err != nil {
+	docs := make([]bson.Raw, 0, limit+1)
+	for cursor.Next(ctx) {
+		docs = append(docs, append(bson.Raw(nil), cursor.Current...))
+	}
+	if err := cursor.Err(); err != nil {
 		log.Printf("GetQuizzes: Failed to decode quizzes - %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode quizzes"})
 		return
 	}
 
-	// If no quizzes found and user requested "all" categories, return empty array
-	if len(quizzes) == 0 {
-		c.JSON(http.StatusOK, []models.Quiz{})
+	if len(docs) > limit {
+		docs = docs[:limit]
+		next, err := encodeCursor(docs[limit-1], sortOption.field)
+		if err != nil {
+			log.Printf("GetQuizzes: Failed to encode cursor - %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch quizzes"})
+			return
+		}
+		c.Header(NextCursorHeader, next)
+	}
+
+	if view == "summary" {
+		summaries := make([]models.QuizSummary, len(docs))
+		for i, doc := range docs {
+			if err := bson.Unmarshal(doc, &summaries[i]); err != nil {
+				log.Printf("GetQuizzes: Failed to decode quiz summary - %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode quizzes"})
+				return
+			}
+		}
+		c.JSON(http.StatusOK, summaries)
 		return
 	}
 
+	quizzes := make([]models.Quiz, len(docs))
+	for i, doc := range docs {
+		if err := bson.Unmarshal(doc, &quizzes[i]); err != nil {
+			log.Printf("GetQuizzes: Failed to decode quiz - %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode quizzes"})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, quizzes)
 }
 
 // GetQuizByID godoc
 // @Summary      Get quiz by ID
-// @Description  Get detailed information about a specific quiz
+// @Description  Get a quiz. Professors and the quiz creator get the full quiz, other students a summary without questions
 // @Tags         quizzes
 // @Accept       json
 // @Produce      json
@@ -247,17 +471,47 @@ func (h *QuizHandler) GetQuizByID(c *gin.Context) {
 		return
 	}
 
+	userID, _ := c.Get("user_id")
 	userRole, _ := c.Get("user_role")
-	// Students can only view approved quizzes
-	if userRole.(models.UserRole) == models.RoleStudent && quiz.Status != models.StatusApproved {
+	role := userRole.(models.UserRole)
+	isCreator := quiz.CreatorID == userID.(primitive.ObjectID)
+
+	// Students can only view approved quizzes, apart from their own submissions
+	if role == models.RoleStudent && quiz.Status != models.StatusApproved && !isCreator {
 		log.Printf("GetQuizByID: Student attempted to access non-approved quiz: %s, status: %s", quizID, quiz.Status)
 		c.JSON(http.StatusForbidden, gin.H{"error": "Quiz not available"})
 		return
 	}
 
+	// Only professors and the quiz creator see questions and correct answers
+	if role == models.RoleStudent && !isCreator {
+		c.JSON(http.StatusOK, newQuizSummary(&quiz))
+		return
+	}
+
 	c.JSON(http.StatusOK, quiz)
 }
 
+// newQuizSummary builds the question-free view of a quiz shown to students
+func newQuizSummary(quiz *models.Quiz) models.QuizSummary {
+	return models.QuizSummary{
+		ID:              quiz.ID,
+		Title:           quiz.Title,
+		Description:     quiz.Description,
+		Category:        quiz.Category,
+		DifficultyLevel: quiz.DifficultyLevel,
+		CourseID:        quiz.CourseID,
+		CreatorID:       quiz.CreatorID,
+		CreatorRole:     quiz.CreatorRole,
+		Status:          quiz.Status,
+		QuestionCount:   len(quiz.Questions),
+		AttemptCount:    quiz.AttemptCount,
+		AverageScore:    quiz.AverageScore,
+		Practice:        quiz.Practice,
+		CreatedAt:       quiz.CreatedAt,
+	}
+}
+
 // ApproveQuizRequest represents the request to approve/reject a quiz
 type ApproveQuizRequest struct {
 	Status models.QuizStatus `json:"status" binding:"required" enums:"approved,rejected" example:"approved"`
@@ -327,6 +581,8 @@ func (h *QuizHandler) ApproveRejectQuiz(c *gin.Context) {
 		return
 	}
 
+	h.events.Publish(services.EventQuizStatusChanged, objectID, gin.H{"status": status})
+
 	log.Printf("ApproveRejectQuiz: Successfully %sd quiz %s", action, quizID)
 	c.JSON(http.StatusOK, gin.H{"message": "Quiz " + action + "d successfully"})
 }
@@ -385,6 +641,8 @@ func (h *QuizHandler) DeleteQuiz(c *gin.Context) {
 		return
 	}
 
+	h.events.Publish(services.EventQuizDeleted, objectID, nil)
+
 	log.Printf("DeleteQuiz: Successfully deleted quiz %s", quizID)
 	c.JSON(http.StatusOK, gin.H{"message": "Quiz deleted successfully"})
 }
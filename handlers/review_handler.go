@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"quizmasterapi/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReviewHandler handles the spaced repetition review queue of students
+type ReviewHandler struct {
+	reviews  *services.ReviewService
+	progress *services.ProgressService
+}
+
+// NewReviewHandler creates a new review handler
+func NewReviewHandler(progress *services.ProgressService) *ReviewHandler {
+	return &ReviewHandler{
+		reviews:  services.NewReviewService(),
+		progress: progress,
+	}
+}
+
+// GradeReviewRequest is the answer given to a review item
+type GradeReviewRequest struct {
+	Answer  string `json:"answer" binding:"required" example:"1"`
+	Quality *int   `json:"quality,omitempty" example:"4"` // How easy a correct answer felt: 3 hard, 4 good (default), 5 easy
+}
+
+// GetReviewToday godoc
+// @Summary      Get today's review session
+// @Description  Practice session of the questions the current student missed in completed attempts that are due for review by the end of their day (in their progress timezone), most overdue first. Questions are returned without their answer. Reviews are practice only and never affect leaderboards
+// @Tags         review
+// @Produce      json
+// @Security     BearerAuth
+// @Param        limit query int false "Items in the session (default 20, max 100)"
+// @Success      200 {object} services.ReviewSession
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /review/today [get]
+func (h *ReviewHandler) GetReviewToday(c *gin.Context) {
+	limit := services.DefaultReviewSessionSize
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > services.MaxReviewSessionSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+			return
+		}
+		limit = parsed
+	}
+
+	userID, _ := c.Get("user_id")
+	studentID := userID.(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	settings, err := h.progress.Settings(ctx, studentID)
+	if err != nil {
+		log.Printf("GetReviewToday: %v (student: %s)", err, studentID.Hex())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load review session"})
+		return
+	}
+
+	session, err := h.reviews.Today(ctx, studentID, settings.Timezone, limit, time.Now())
+	if err != nil {
+		log.Printf("GetReviewToday: %v (student: %s)", err, studentID.Hex())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load review session"})
+		return
+	}
+	for i := range session.Items {
+		session.Items[i].Question = attemptQuestion(session.Items[i].Question)
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// GradeReview godoc
+// @Summary      Grade a review item
+// @Description  Answer a due review item. The answer is checked and the item rescheduled with the SM-2 algorithm: a correct answer is graded with the given quality (3 to 5, default 4) and comes back after 1 day, then 6 days, then a growing interval; a wrong answer is graded 1 and comes back the next day. The correct answer and explanation are returned, so items of quizzes whose review policy does not reveal answers yet are rejected with 403. Supports the Idempotency-Key header
+// @Tags         review
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Review item ID"
+// @Param        request body GradeReviewRequest true "Answer"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /review/items/{id}/grade [post]
+func (h *ReviewHandler) GradeReview(c *gin.Context) {
+	itemID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review item ID"})
+		return
+	}
+
+	var req GradeReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Quality != nil && (*req.Quality < 3 || *req.Quality > services.MaxReviewQuality) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "quality must be between 3 and 5"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	studentID := userID.(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	item, quiz, question, err := h.reviews.Item(ctx, studentID, itemID)
+	if errors.Is(err, services.ErrReviewItemNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review item not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("GradeReview: %v (item: %s)", err, itemID.Hex())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to grade review"})
+		return
+	}
+
+	// Grading would tell whether the answer is correct
+	if !services.AnswersRevealed(quiz, time.Now()) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Answers to this quiz are not revealed yet"})
+		return
+	}
+
+	settings, err := h.progress.Settings(ctx, studentID)
+	if err != nil {
+		log.Printf("GradeReview: %v (student: %s)", err, studentID.Hex())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to grade review"})
+		return
+	}
+
+	correctAnswer := correctAnswerString(question.CorrectAnswer)
+	isCorrect := req.Answer == correctAnswer
+	quality := 1
+	if isCorrect {
+		quality = 4
+		if req.Quality != nil {
+			quality = *req.Quality
+		}
+	}
+
+	next, err := h.reviews.Grade(ctx, item, quality, settings.Timezone, time.Now())
+	if errors.Is(err, services.ErrReviewNotDue) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This item is not due for review yet"})
+		return
+	}
+	if errors.Is(err, services.ErrReviewConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "This item was graded concurrently, please reload the session"})
+		return
+	}
+	if err != nil {
+		log.Printf("GradeReview: %v (item: %s)", err, itemID.Hex())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to grade review"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"is_correct":     isCorrect,
+		"correct_answer": correctAnswer,
+		"explanation":    question.Explanation,
+		"quality":        quality,
+		"item":           next,
+	})
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"quizmasterapi/config"
+	"quizmasterapi/models"
+	"quizmasterapi/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Interval between keep-alive comments on idle streams
+const streamHeartbeatInterval = 25 * time.Second
+
+// StreamHandler serves live events over Server-Sent Events
+type StreamHandler struct {
+	quizCollection    *mongo.Collection
+	sessionCollection *mongo.Collection
+	events            *services.EventBus
+}
+
+// NewStreamHandler creates a new stream handler
+func NewStreamHandler(events *services.EventBus) *StreamHandler {
+	return &StreamHandler{
+		quizCollection:    config.GetCollection("quizzes"),
+		sessionCollection: config.GetCollection("live_sessions"),
+		events:            events,
+	}
+}
+
+// StreamQuizEvents godoc
+// @Summary      Stream live quiz events
+// @Description  Server-Sent Events stream of a quiz: attempts started and completed (with the new rank), leaderboard changes and quiz status changes. Browser clients may pass the JWT as the access_token query parameter. A "lagged" event is sent before closing the stream when the client falls behind; reconnect and refetch the leaderboard
+// @Tags         streams
+// @Produce      text/event-stream
+// @Security     BearerAuth
+// @Param        quiz_id path string true "Quiz ID"
+// @Param        access_token query string false "JWT, for clients that cannot set the Authorization header"
+// @Success      200 {object} services.Event
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /stream/quizzes/{quiz_id} [get]
+func (h *StreamHandler) StreamQuizEvents(c *gin.Context) {
+	quizID, err := primitive.ObjectIDFromHex(c.Param("quiz_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quiz ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	var quiz models.Quiz
+	err = h.quizCollection.FindOne(ctx, bson.M{"_id": quizID}).Decode(&quiz)
+	cancel()
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Quiz not found"})
+		return
+	}
+
+	userRole, _ := c.Get("user_role")
+	if userRole.(models.UserRole) == models.RoleStudent && quiz.Status != models.StatusApproved {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Quiz not available"})
+		return
+	}
+
+	h.stream(c, quizID, gin.H{"quiz_id": quizID, "status": quiz.Status})
+}
+
+// StreamSessionEvents godoc
+// @Summary      Stream live session events
+// @Description  Server-Sent Events stream of a live session for its host and participants: participants joining, questions opening and closing (with the correct answer and standings), answer counts, and the session finishing or being cancelled. Browser clients may pass the JWT as the access_token query parameter
+// @Tags         streams
+// @Produce      text/event-stream
+// @Security     BearerAuth
+// @Param        session_id path string true "Session ID"
+// @Param        access_token query string false "JWT, for clients that cannot set the Authorization header"
+// @Success      200 {object} services.Event
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /stream/sessions/{session_id} [get]
+func (h *StreamHandler) StreamSessionEvents(c *gin.Context) {
+	sessionID, err := primitive.ObjectIDFromHex(c.Param("session_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	var session models.LiveSession
+	err = h.sessionCollection.FindOne(ctx, bson.M{"_id": sessionID}).Decode(&session)
+	cancel()
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	if session.HostID != userID.(primitive.ObjectID) && findParticipant(&session, userID.(primitive.ObjectID)) == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You have not joined this session"})
+		return
+	}
+
+	h.stream(c, sessionID, gin.H{
+		"session_id":       sessionID,
+		"status":           session.Status,
+		"current_question": session.CurrentQuestion,
+	})
+}
+
+// stream relays the events of a quiz or session topic until the client disconnects
+func (h *StreamHandler) stream(c *gin.Context, topic primitive.ObjectID, ready gin.H) {
+	sub := h.events.Subscribe(&topic)
+	defer h.events.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	writeSSE(c, "ready", ready, 0)
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		case event, ok := <-sub.Events():
+			if !ok {
+				if sub.Lagged() {
+					writeSSE(c, "lagged", gin.H{"message": "Client fell behind, reconnect and refetch"}, 0)
+				}
+				return
+			}
+			writeSSE(c, event.Type, event, event.ID)
+		}
+	}
+}
+
+// writeSSE writes one Server-Sent Event and flushes it to the client
+func writeSSE(c *gin.Context, eventType string, data interface{}, id uint64) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	if id > 0 {
+		fmt.Fprintf(c.Writer, "id: %d\n", id)
+	}
+	fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", eventType, payload)
+	c.Writer.Flush()
+}
@@ -0,0 +1,330 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"quizmasterapi/config"
+	"quizmasterapi/models"
+	"quizmasterapi/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// The tests below serve student routes from a mock MongoDB deployment that
+// answers each command, in order, with the responses queued by the test.
+// Queries return the full stored documents, so any answer a handler fails to
+// strip reaches the response
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newStudentViewQuiz returns an approved quiz whose questions have answers,
+// explanations and hints
+func newStudentViewQuiz(policy models.ReviewPolicy) *models.Quiz {
+	return &models.Quiz{
+		ID:           primitive.NewObjectID(),
+		Title:        "Go basics",
+		CourseID:     "go-101",
+		CreatorID:    primitive.NewObjectID(),
+		Status:       models.StatusApproved,
+		ReviewPolicy: policy,
+		Practice:     &models.PracticeSettings{Enabled: true},
+		Questions: []models.Question{
+			{
+				ID:            primitive.NewObjectID(),
+				QuestionText:  "Is Go a compiled language?",
+				Type:          models.QuestionTypeTrueFalse,
+				CorrectAnswer: true,
+				TimeLimit:     30,
+				Points:        10,
+				Explanation:   "Go compiles to native machine code.",
+				Hints:         []models.Hint{{Text: "Think about how Go programs are run.", Penalty: 0.1}},
+			},
+			{
+				ID:            primitive.NewObjectID(),
+				QuestionText:  "Which keyword starts a goroutine?",
+				Type:          models.QuestionTypeMultipleChoice,
+				Options:       []string{"async", "go", "spawn", "thread"},
+				CorrectAnswer: 1,
+				TimeLimit:     30,
+				Points:        10,
+				Explanation:   "The go statement runs a call in a new goroutine.",
+			},
+		},
+	}
+}
+
+// newMockDB points config.DB at the mock deployment of a subtest
+func newMockDB(mt *mtest.T) {
+	previous := config.DB
+	config.DB = mt.Client.Database("test")
+	mt.Cleanup(func() { config.DB = previous })
+}
+
+// mockDocument converts a value to the document MongoDB would return
+func mockDocument(t *testing.T, v interface{}) bson.D {
+	data, err := bson.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var doc bson.D
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	return doc
+}
+
+// mockFound is the response to a query finding the given documents
+func mockFound(t *testing.T, collection string, docs ...interface{}) bson.D {
+	batch := make([]bson.D, len(docs))
+	for i, doc := range docs {
+		batch[i] = mockDocument(t, doc)
+	}
+	return mtest.CreateCursorResponse(0, "test."+collection, mtest.FirstBatch, batch...)
+}
+
+// serveStudent sends a request to a handler as the given student
+func serveStudent(t *testing.T, studentID primitive.ObjectID, route string, handler gin.HandlerFunc, method, target string, body interface{}) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Handle(method, route, func(c *gin.Context) {
+		c.Set("user_id", studentID)
+		c.Set("user_role", models.RoleStudent)
+	}, handler)
+
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+	}
+	req := httptest.NewRequest(method, target, &payload)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// assertNoAnswers fails if a response is not the expected status or has a
+// correct_answer or explanation key anywhere in its JSON
+func assertNoAnswers(t *testing.T, w *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status %d, want %d: %s", w.Code, status, w.Body.String())
+	}
+	var body interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if path, ok := findAnswerKey(body, "$"); ok {
+		t.Fatalf("response reveals %s: %s", path, w.Body.String())
+	}
+}
+
+// findAnswerKey returns the path of the first correct_answer or explanation key in a JSON value
+func findAnswerKey(value interface{}, path string) (string, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if key == "correct_answer" || key == "explanation" {
+				return path + "." + key, true
+			}
+			if found, ok := findAnswerKey(item, path+"."+key); ok {
+				return found, true
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			if found, ok := findAnswerKey(item, fmt.Sprintf("%s[%d]", path, i)); ok {
+				return found, true
+			}
+		}
+	}
+	return "", false
+}
+
+func TestGetQuizzesHidesAnswersFromStudents(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	// Students get summaries even when they ask for the full view
+	for _, target := range []string{"/quizzes", "/quizzes?view=full"} {
+		mt.Run(target, func(mt *mtest.T) {
+			newMockDB(mt)
+			mt.AddMockResponses(mockFound(mt.T, "quizzes", newStudentViewQuiz(models.ReviewImmediately)))
+
+			h := NewQuizHandler(nil, nil)
+			w := serveStudent(mt.T, primitive.NewObjectID(), "/quizzes", h.GetQuizzes, http.MethodGet, target, nil)
+			assertNoAnswers(mt.T, w, http.StatusOK)
+		})
+	}
+}
+
+func TestGetQuizByIDHidesAnswersFromStudents(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("student", func(mt *mtest.T) {
+		newMockDB(mt)
+		quiz := newStudentViewQuiz(models.ReviewImmediately)
+		mt.AddMockResponses(mockFound(mt.T, "quizzes", quiz))
+
+		h := NewQuizHandler(nil, nil)
+		w := serveStudent(mt.T, primitive.NewObjectID(), "/quizzes/:id", h.GetQuizByID, http.MethodGet, "/quizzes/"+quiz.ID.Hex(), nil)
+		assertNoAnswers(mt.T, w, http.StatusOK)
+	})
+}
+
+func TestStartAttemptHidesAnswers(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("ranked", func(mt *mtest.T) {
+		newMockDB(mt)
+		quiz := newStudentViewQuiz(models.ReviewImmediately)
+		studentID := primitive.NewObjectID()
+		mt.AddMockResponses(
+			mockFound(mt.T, "quizzes", quiz),
+			mockFound(mt.T, "course_completions", models.CourseCompletion{
+				StudentID: studentID,
+				CourseID:  quiz.CourseID,
+				Completed: true,
+				Source:    models.CompletionFromAPI,
+			}),
+			mockFound(mt.T, "attempts"),
+			mtest.CreateSuccessResponse(),
+			mockFound(mt.T, "users"),
+		)
+
+		courses := services.NewCourseService("http://courses.invalid", nil, config.CourseAPIConfig{}, services.NewMongoCompletionStore())
+		h := NewAttemptHandler(courses, nil, nil, services.NewEventBus())
+		w := serveStudent(mt.T, studentID, "/attempts/start", h.StartAttempt, http.MethodPost, "/attempts/start", StartAttemptRequest{QuizID: quiz.ID.Hex()})
+		assertNoAnswers(mt.T, w, http.StatusCreated)
+	})
+}
+
+func TestSubmitAnswerHidesAnswers(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	// Practice only gives the answer away when the review policy reveals it
+	tests := []struct {
+		name   string
+		mode   models.AttemptMode
+		policy models.ReviewPolicy
+	}{
+		{"ranked", models.ModeRanked, models.ReviewImmediately},
+		{"practice without review", models.ModePractice, models.ReviewNever},
+	}
+
+	for _, tc := range tests {
+		mt.Run(tc.name, func(mt *mtest.T) {
+			newMockDB(mt)
+			quiz := newStudentViewQuiz(tc.policy)
+			attempt := newInProgressAttempt(quiz)
+			attempt.Mode = tc.mode
+			mt.AddMockResponses(
+				mockFound(mt.T, "attempts", attempt),
+				mockFound(mt.T, "quizzes", quiz),
+				mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+			)
+
+			h := NewAttemptHandler(nil, nil, nil, services.NewEventBus())
+			w := serveStudent(mt.T, attempt.StudentID, "/attempts/answer", h.SubmitAnswer, http.MethodPost, "/attempts/answer", SubmitAnswerRequest{
+				AttemptID:    attempt.ID.Hex(),
+				QuestionID:   quiz.Questions[0].ID.Hex(),
+				Answer:       "false",
+				TimeToAnswer: 5,
+			})
+			assertNoAnswers(mt.T, w, http.StatusOK)
+		})
+	}
+}
+
+func TestGetSessionHidesAnswersFromParticipants(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	// An open question is always hidden, a closed one until the review policy reveals it
+	tests := []struct {
+		name   string
+		status models.LiveSessionStatus
+		policy models.ReviewPolicy
+	}{
+		{"open question", models.SessionQuestion, models.ReviewImmediately},
+		{"closed question", models.SessionLeaderboard, models.ReviewNever},
+	}
+
+	for _, tc := range tests {
+		mt.Run(tc.name, func(mt *mtest.T) {
+			newMockDB(mt)
+			quiz := newStudentViewQuiz(tc.policy)
+			studentID := primitive.NewObjectID()
+			startedAt := time.Now().Add(-10 * time.Second)
+			session := models.LiveSession{
+				ID:                primitive.NewObjectID(),
+				QuizID:            quiz.ID,
+				HostID:            primitive.NewObjectID(),
+				Status:            tc.status,
+				CurrentQuestion:   0,
+				QuestionStartedAt: &startedAt,
+				Participants: []models.LiveParticipant{
+					{StudentID: studentID, AttemptID: primitive.NewObjectID(), JoinedAt: startedAt},
+				},
+			}
+			mt.AddMockResponses(
+				mockFound(mt.T, "live_sessions", session),
+				mockFound(mt.T, "quizzes", quiz),
+			)
+
+			h := NewLiveSessionHandler(nil, nil, nil, services.NewEventBus())
+			w := serveStudent(mt.T, studentID, "/sessions/:id", h.GetSession, http.MethodGet, "/sessions/"+session.ID.Hex(), nil)
+			assertNoAnswers(mt.T, w, http.StatusOK)
+
+			var view LiveSessionView
+			if err := json.Unmarshal(w.Body.Bytes(), &view); err != nil || view.Question == nil {
+				mt.Fatalf("no current question in %s (%v)", w.Body.String(), err)
+			}
+		})
+	}
+}
+
+func TestGetReviewTodayHidesAnswers(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("due items", func(mt *mtest.T) {
+		newMockDB(mt)
+		quiz := newStudentViewQuiz(models.ReviewImmediately)
+		studentID := primitive.NewObjectID()
+		items := make([]interface{}, len(quiz.Questions))
+		for i, question := range quiz.Questions {
+			items[i] = models.ReviewItem{
+				ID:         primitive.NewObjectID(),
+				StudentID:  studentID,
+				QuizID:     quiz.ID,
+				QuestionID: question.ID,
+				EaseFactor: 2.5,
+				DueAt:      time.Now().Add(-time.Hour),
+			}
+		}
+		mt.AddMockResponses(
+			mockFound(mt.T, "student_progress"),
+			mockFound(mt.T, "review_items", bson.M{"n": len(items)}),
+			mockFound(mt.T, "review_items", items...),
+			mockFound(mt.T, "quizzes", quiz),
+		)
+
+		h := NewReviewHandler(services.NewProgressService())
+		w := serveStudent(mt.T, studentID, "/review/today", h.GetReviewToday, http.MethodGet, "/review/today", nil)
+		assertNoAnswers(mt.T, w, http.StatusOK)
+
+		var session services.ReviewSession
+		if err := json.Unmarshal(w.Body.Bytes(), &session); err != nil || len(session.Items) != len(items) {
+			mt.Fatalf("want %d review items in %s (%v)", len(items), w.Body.String(), err)
+		}
+	})
+}
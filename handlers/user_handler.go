@@ -9,6 +9,7 @@ import (
 	"quizmasterapi/config"
 	"quizmasterapi/middleware"
 	"quizmasterapi/models"
+	"quizmasterapi/services"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
@@ -19,13 +20,15 @@ import (
 
 // UserHandler handles user-related requests
 type UserHandler struct {
-	collection *mongo.Collection
+	collection   *mongo.Collection
+	achievements *services.AchievementService
 }
 
 // NewUserHandler creates a new user handler
-func NewUserHandler() *UserHandler {
+func NewUserHandler(achievements *services.AchievementService) *UserHandler {
 	return &UserHandler{
-		collection: config.GetCollection("users"),
+		collection:   config.GetCollection("users"),
+		achievements: achievements,
 	}
 }
 
@@ -44,6 +47,13 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required" example:"password123"`
 }
 
+// UserProfile is a user with their level and earned achievements
+type UserProfile struct {
+	models.User
+	Level        services.Level             `json:"level"`
+	Achievements []models.EarnedAchievement `json:"achievements"`
+}
+
 // LoginResponse represents login response
 type LoginResponse struct {
 	Token string       `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
@@ -175,12 +185,12 @@ func (h *UserHandler) Login(c *gin.Context) {
 
 // GetProfile godoc
 // @Summary      Get user profile
-// @Description  Get the profile of the authenticated user
+// @Description  Get the profile of the authenticated user, with their XP level and earned achievements
 // @Tags         users
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
-// @Success      200 {object} models.User
+// @Success      200 {object} UserProfile
 // @Failure      401 {object} map[string]string
 // @Failure      404 {object} map[string]string
 // @Router       /users/profile [get]
@@ -198,5 +208,15 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	earned, err := h.achievements.Earned(ctx, objectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch achievements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, UserProfile{
+		User:         user,
+		Level:        services.LevelForXP(user.XP),
+		Achievements: earned,
+	})
 }
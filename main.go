@@ -2,13 +2,17 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
+	"time"
 
 	"quizmasterapi/config"
 	_ "quizmasterapi/docs"
 	"quizmasterapi/handlers"
 	"quizmasterapi/middleware"
 	"quizmasterapi/models"
+	"quizmasterapi/services"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -37,6 +41,9 @@ import (
 // @description Type "Bearer" followed by a space and JWT token.
 
 func main() {
+	rebuildLeaderboards := flag.Bool("rebuild-leaderboards", false, "Recompute the materialized leaderboards from all attempts and exit")
+	flag.Parse()
+
 	// Load configuration
 	config.LoadConfig()
 
@@ -50,22 +57,73 @@ func main() {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
+	// Create indexes
+	if err := config.EnsureIndexes(); err != nil {
+		log.Println("Warning: failed to ensure indexes:", err)
+	}
+
+	leaderboardService := services.NewLeaderboardService(services.NewLeaderboardCache(config.AppConfig.LeaderboardTTL))
+	progressService := services.NewProgressService()
+	achievementService := services.NewAchievementService(leaderboardService, progressService)
+
+	if *rebuildLeaderboards {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		defer cancel()
+
+		entries, err := leaderboardService.Rebuild(ctx, nil)
+		if err != nil {
+			log.Fatal("Failed to rebuild leaderboards:", err)
+		}
+		log.Printf("Rebuilt leaderboards with %d entries", entries)
+		return
+	}
+
+	// Create the default achievements
+	seedCtx, seedCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if err := achievementService.SeedDefaults(seedCtx); err != nil {
+		log.Println("Warning: failed to seed achievements:", err)
+	}
+	seedCancel()
+
+	// Give quizzes created before their stats were maintained sortable stats
+	backfillCtx, backfillCancel := context.WithTimeout(context.Background(), time.Minute)
+	if backfilled, err := services.NewQuizStatsService().Backfill(backfillCtx); err != nil {
+		log.Println("Warning: failed to backfill quiz stats:", err)
+	} else if backfilled > 0 {
+		log.Printf("Backfilled the stats of %d quizzes", backfilled)
+	}
+	backfillCancel()
+
 	// Initialize Gin router
-	router := gin.Default()
+	// gin.Default's logger would write stream access tokens to the logs
+	router := gin.New()
+	router.Use(middleware.Logger(), gin.Recovery())
 	router.Use(cors.New(cors.Config{
 		// Mettez ici l'URL de votre frontend.
 		// Exemples : "http://localhost:3000" pour React, "http://localhost:4200" for Angular, "http://localhost:5173" for Vite
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", middleware.IdempotencyKeyHeader},
+		ExposeHeaders:    []string{"Content-Length", "Idempotent-Replayed", handlers.NextCursorHeader},
 		AllowCredentials: true,
 	}))
 	// Initialize handlers
-	userHandler := handlers.NewUserHandler()
-	quizHandler := handlers.NewQuizHandler()
-	attemptHandler := handlers.NewAttemptHandler()
-	leaderboardHandler := handlers.NewLeaderboardHandler()
+	eventBus := services.NewEventBus()
+	// Shared so its circuit breaker sees every call to the course API
+	courseService := services.NewCourseService(config.AppConfig.ExternalCourseAPI, nil, config.AppConfig.CourseAPI, services.NewMongoCompletionStore())
+	userHandler := handlers.NewUserHandler(achievementService)
+	quizHandler := handlers.NewQuizHandler(courseService, eventBus)
+	attemptHandler := handlers.NewAttemptHandler(courseService, leaderboardService, achievementService, eventBus)
+	attemptManagementHandler := handlers.NewAttemptManagementHandler(leaderboardService, achievementService, eventBus)
+	leaderboardHandler := handlers.NewLeaderboardHandler(leaderboardService)
+	streamHandler := handlers.NewStreamHandler(eventBus)
+	liveSessionHandler := handlers.NewLiveSessionHandler(courseService, leaderboardService, achievementService, eventBus)
+	achievementHandler := handlers.NewAchievementHandler(achievementService)
+	progressHandler := handlers.NewProgressHandler(progressService, services.NewStatsService())
+	challengeHandler := handlers.NewChallengeHandler()
+	gradebookHandler := handlers.NewGradebookHandler()
+	reviewHandler := handlers.NewReviewHandler(progressService)
+	courseHandler := handlers.NewCourseHandler(courseService)
 
 	// Swagger documentation
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -84,6 +142,17 @@ func main() {
 			auth.POST("/register", userHandler.Register)
 			auth.POST("/login", userHandler.Login)
 		}
+
+		// Course platform webhooks, authenticated by their signature
+		api.POST("/webhooks/course-completions", courseHandler.CompletionWebhook)
+	}
+
+	// Streaming routes, which also accept the token as a query parameter
+	stream := api.Group("/stream")
+	stream.Use(middleware.StreamAuthMiddleware())
+	{
+		stream.GET("/quizzes/:quiz_id", streamHandler.StreamQuizEvents)
+		stream.GET("/sessions/:session_id", streamHandler.StreamSessionEvents)
 	}
 
 	// Protected routes
@@ -94,6 +163,10 @@ func main() {
 		users := protected.Group("/users")
 		{
 			users.GET("/profile", userHandler.GetProfile)
+			users.GET("/:id/achievements", achievementHandler.GetUserAchievements)
+			users.GET("/me/progress", middleware.RequireRole(models.RoleStudent), progressHandler.GetMyProgress)
+			users.PUT("/me/progress", middleware.RequireRole(models.RoleStudent), progressHandler.UpdateProgressSettings)
+			users.GET("/me/stats", middleware.RequireRole(models.RoleStudent), progressHandler.GetMyStats)
 		}
 
 		// Quiz routes
@@ -113,15 +186,78 @@ func main() {
 		// Quiz attempt routes (Students only)
 		attempts := protected.Group("/attempts")
 		attempts.Use(middleware.RequireRole(models.RoleStudent))
+		idempotent := middleware.Idempotency()
 		{
-			attempts.POST("/start", attemptHandler.StartAttempt)
-			attempts.POST("/answer", attemptHandler.SubmitAnswer)
-			attempts.PUT("/:id/complete", attemptHandler.CompleteAttempt)
+			attempts.POST("/start", idempotent, attemptHandler.StartAttempt)
+			attempts.POST("/answer", idempotent, attemptHandler.SubmitAnswer)
+			attempts.PUT("/:id/complete", idempotent, attemptHandler.CompleteAttempt)
 			attempts.GET("/:id", attemptHandler.GetAttemptByID)
+			attempts.GET("/:id/review", attemptHandler.GetAttemptReview)
+			attempts.POST("/:id/assist", idempotent, attemptHandler.UseAssist)
+			attempts.POST("/:id/next", idempotent, attemptHandler.NextQuestion)
 			attempts.GET("", attemptHandler.GetMyAttempts)
 		}
 
-	
+		// Spaced repetition review routes (Students only)
+		review := protected.Group("/review")
+		review.Use(middleware.RequireRole(models.RoleStudent))
+		{
+			review.GET("/today", reviewHandler.GetReviewToday)
+			review.POST("/items/:id/grade", idempotent, reviewHandler.GradeReview)
+		}
+
+		// Achievement routes
+		protected.GET("/achievements", achievementHandler.GetAchievements)
+
+		// Challenge routes (Students only)
+		challenges := protected.Group("/challenges")
+		challenges.Use(middleware.RequireRole(models.RoleStudent))
+		{
+			challenges.POST("", challengeHandler.CreateChallenge)
+			challenges.GET("", challengeHandler.GetChallenges)
+			challenges.GET("/:id", challengeHandler.GetChallengeByID)
+			challenges.PUT("/:id/:action", challengeHandler.RespondToChallenge)
+		}
+
+		// Attempt management routes (Professors only)
+		manage := protected.Group("/manage")
+		manage.Use(middleware.RequireRole(models.RoleProfessor))
+		{
+			manage.GET("/attempts", attemptManagementHandler.ListAttempts)
+			manage.GET("/attempts/:id", attemptManagementHandler.GetAttempt)
+			manage.PUT("/attempts/:id/answers/:question_id", attemptManagementHandler.OverrideAnswerScore)
+			manage.PUT("/attempts/:id/invalidate", attemptManagementHandler.InvalidateAttempt)
+			manage.PUT("/quizzes/:quiz_id/questions/:question_id/answer", attemptManagementHandler.CorrectAnswerKey)
+			manage.POST("/quizzes/:quiz_id/regrade", attemptManagementHandler.RegradeQuiz)
+			manage.POST("/quizzes/:quiz_id/leaderboard/rebuild", attemptManagementHandler.RebuildQuizLeaderboard)
+			manage.GET("/quizzes/:quiz_id/leaderboard/verify", attemptManagementHandler.VerifyQuizLeaderboard)
+			manage.GET("/quizzes/:quiz_id/item-analysis", attemptManagementHandler.GetItemAnalysis)
+			manage.POST("/quizzes/:quiz_id/calibrate", attemptManagementHandler.CalibrateQuiz)
+			manage.PUT("/quizzes/:quiz_id/practice", attemptManagementHandler.UpdatePracticeSettings)
+			manage.GET("/courses/:course_id/gradebook", gradebookHandler.ExportGradebook)
+			manage.PUT("/courses/:course_id/completions/:student_id/:action", courseHandler.UpdateCompletion)
+			manage.PUT("/achievements/:key", achievementHandler.UpsertAchievement)
+		}
+
+		// Live session routes
+		sessions := protected.Group("/sessions")
+		{
+			sessions.POST("",
+				middleware.RequireRole(models.RoleProfessor),
+				liveSessionHandler.CreateSession)
+			sessions.POST("/join",
+				middleware.RequireRole(models.RoleStudent),
+				liveSessionHandler.JoinSession)
+			sessions.GET("/:id", liveSessionHandler.GetSession)
+			sessions.GET("/:id/leaderboard", liveSessionHandler.GetSessionLeaderboard)
+			sessions.POST("/:id/advance", liveSessionHandler.AdvanceSession)
+			sessions.POST("/:id/end", liveSessionHandler.EndSession)
+			sessions.DELETE("/:id", liveSessionHandler.CancelSession)
+			sessions.POST("/:id/answer",
+				middleware.RequireRole(models.RoleStudent),
+				liveSessionHandler.SubmitSessionAnswer)
+		}
+
 		// Leaderboard routes
 		leaderboards := protected.Group("/leaderboards")
 		{
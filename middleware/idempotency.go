@@ -0,0 +1,248 @@
+// Package middleware provides HTTP middleware functions
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"quizmasterapi/config"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// IdempotencyKeyHeader is the request header clients set to make retries safe
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// How long a request keeps its key in progress before a retry may take it over
+const idempotencyLease = time.Minute
+
+// Timeout of each idempotency store operation, a variable so tests can shorten it
+var idempotencyStoreTimeout = 5 * time.Second
+
+// idempotencyRecord stores the response of a request made with an idempotency key
+type idempotencyRecord struct {
+	ID          string    `bson:"_id"`
+	RequestHash string    `bson:"request_hash"`
+	Completed   bool      `bson:"completed"`
+	StatusCode  int       `bson:"status_code,omitempty"`
+	ContentType string    `bson:"content_type,omitempty"`
+	Body        []byte    `bson:"body,omitempty"`
+	CreatedAt   time.Time `bson:"created_at"`
+}
+
+// responseRecorder captures the response body while writing it to the client
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
+
+// Idempotency replays the stored response when an authenticated request is retried
+// with the same Idempotency-Key header. Requests without the header pass through.
+// Keys are scoped to the user, method and path; server errors are not stored so
+// that the client can retry them. A key left in progress longer than the lease,
+// e.g. by a crashed server, is taken over by the next retry.
+func Idempotency() gin.HandlerFunc {
+	return idempotency(&mongoIdempotencyStore{collection: config.GetCollection("idempotency_keys")})
+}
+
+func idempotency(store idempotencyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.Sum256(body)
+		record := idempotencyRecord{
+			ID:          userID.(primitive.ObjectID).Hex() + ":" + c.Request.Method + ":" + c.Request.URL.Path + ":" + key,
+			RequestHash: hex.EncodeToString(hash[:]),
+			CreatedAt:   time.Now(),
+		}
+
+		if !claimIdempotencyKey(c, store, record) {
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		// The handler may have used up any deadline set before it ran
+		ctx, cancel := context.WithTimeout(context.Background(), idempotencyStoreTimeout)
+		defer cancel()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			if err := store.Release(ctx, record); err != nil {
+				log.Printf("Idempotency: Failed to release key %s - %v", record.ID, err)
+			}
+			return
+		}
+
+		if err := store.Complete(ctx, record, recorder.Status(), recorder.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+			log.Printf("Idempotency: Failed to store response for key %s - %v", record.ID, err)
+		}
+	}
+}
+
+// claimIdempotencyKey stores the in-progress record of a request, or answers
+// a retried request from the stored one. It reports whether the request
+// should be executed
+func claimIdempotencyKey(c *gin.Context, store idempotencyStore, record idempotencyRecord) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), idempotencyStoreTimeout)
+	defer cancel()
+
+	stored, err := store.Claim(ctx, record)
+	if err != nil {
+		log.Printf("Idempotency: Failed to store key %s - %v", record.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process idempotency key"})
+		c.Abort()
+		return false
+	}
+	if stored == nil {
+		return true
+	}
+
+	if stored.RequestHash != record.RequestHash {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency key was already used with a different request"})
+		c.Abort()
+		return false
+	}
+
+	if stored.Completed {
+		replayIdempotentResponse(c, stored)
+		return false
+	}
+
+	if record.CreatedAt.Sub(stored.CreatedAt) >= idempotencyLease {
+		reclaimed, err := store.Reclaim(ctx, stored, record.CreatedAt)
+		if err != nil {
+			log.Printf("Idempotency: Failed to reclaim key %s - %v", record.ID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process idempotency key"})
+			c.Abort()
+			return false
+		}
+		if reclaimed {
+			log.Printf("Idempotency: Reclaimed stale key %s", record.ID)
+			return true
+		}
+	}
+
+	c.JSON(http.StatusConflict, gin.H{"error": "A request with this idempotency key is still in progress"})
+	c.Abort()
+	return false
+}
+
+// replayIdempotentResponse answers a retried request from its stored record
+func replayIdempotentResponse(c *gin.Context, stored *idempotencyRecord) {
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(stored.StatusCode, stored.ContentType, stored.Body)
+	c.Abort()
+}
+
+// idempotencyStore keeps the records of idempotency keys
+type idempotencyStore interface {
+	// Claim stores a new in-progress record. If the key is already taken it
+	// returns the stored record instead
+	Claim(ctx context.Context, record idempotencyRecord) (*idempotencyRecord, error)
+	// Reclaim takes over a stale in-progress record, restarting its lease at
+	// now. It reports false if another request took it over or completed it first
+	Reclaim(ctx context.Context, stale *idempotencyRecord, now time.Time) (bool, error)
+	// Complete stores the response of a record. Like Release, it leaves the
+	// key alone if another request reclaimed it meanwhile
+	Complete(ctx context.Context, record idempotencyRecord, statusCode int, contentType string, body []byte) error
+	// Release deletes a record so that the request can be retried
+	Release(ctx context.Context, record idempotencyRecord) error
+}
+
+// mongoIdempotencyStore keeps idempotency records in a collection
+type mongoIdempotencyStore struct {
+	collection *mongo.Collection
+}
+
+func (s *mongoIdempotencyStore) Claim(ctx context.Context, record idempotencyRecord) (*idempotencyRecord, error) {
+	// A key released between the insert and the read is claimed again
+	for try := 1; ; try++ {
+		_, err := s.collection.InsertOne(ctx, record)
+		if err == nil {
+			return nil, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+
+		var stored idempotencyRecord
+		err = s.collection.FindOne(ctx, bson.M{"_id": record.ID}).Decode(&stored)
+		if err == nil {
+			return &stored, nil
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) || try == 3 {
+			return nil, err
+		}
+	}
+}
+
+func (s *mongoIdempotencyStore) Reclaim(ctx context.Context, stale *idempotencyRecord, now time.Time) (bool, error) {
+	result, err := s.collection.UpdateOne(ctx, bson.M{
+		"_id":        stale.ID,
+		"completed":  false,
+		"created_at": stale.CreatedAt,
+	}, bson.M{"$set": bson.M{"created_at": now}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (s *mongoIdempotencyStore) Complete(ctx context.Context, record idempotencyRecord, statusCode int, contentType string, body []byte) error {
+	_, err := s.collection.UpdateOne(ctx, bson.M{"_id": record.ID, "created_at": record.CreatedAt}, bson.M{
+		"$set": bson.M{
+			"completed":    true,
+			"status_code":  statusCode,
+			"content_type": contentType,
+			"body":         body,
+		},
+	})
+	return err
+}
+
+func (s *mongoIdempotencyStore) Release(ctx context.Context, record idempotencyRecord) error {
+	_, err := s.collection.DeleteOne(ctx, bson.M{"_id": record.ID, "created_at": record.CreatedAt})
+	return err
+}
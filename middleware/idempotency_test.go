@@ -0,0 +1,290 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryIdempotencyStore keeps idempotency records in memory. Like a database
+// call, each operation fails once its context is done
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]idempotencyRecord
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: map[string]idempotencyRecord{}}
+}
+
+func (s *memoryIdempotencyStore) Claim(ctx context.Context, record idempotencyRecord) (*idempotencyRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stored, ok := s.records[record.ID]; ok {
+		return &stored, nil
+	}
+	s.records[record.ID] = record
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Reclaim(ctx context.Context, stale *idempotencyRecord, now time.Time) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.records[stale.ID]
+	if !ok || stored.Completed || !stored.CreatedAt.Equal(stale.CreatedAt) {
+		return false, nil
+	}
+	stored.CreatedAt = now
+	s.records[stale.ID] = stored
+	return true, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, record idempotencyRecord, statusCode int, contentType string, body []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.records[record.ID]
+	if !ok || !stored.CreatedAt.Equal(record.CreatedAt) {
+		return nil
+	}
+	stored.Completed = true
+	stored.StatusCode = statusCode
+	stored.ContentType = contentType
+	stored.Body = append([]byte(nil), body...)
+	s.records[record.ID] = stored
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, record idempotencyRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stored, ok := s.records[record.ID]; ok && stored.CreatedAt.Equal(record.CreatedAt) {
+		delete(s.records, record.ID)
+	}
+	return nil
+}
+
+// only returns the single record of the store
+func (s *memoryIdempotencyStore) only(t *testing.T) idempotencyRecord {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.records) != 1 {
+		t.Fatalf("store holds %d records, want 1", len(s.records))
+	}
+	for _, record := range s.records {
+		return record
+	}
+	return idempotencyRecord{}
+}
+
+var testUserID = primitive.NewObjectID()
+
+// newIdempotentRouter serves handler at POST /attempts/start behind the middleware
+func newIdempotentRouter(store idempotencyStore, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", testUserID)
+	})
+	router.POST("/attempts/start", idempotency(store), handler)
+	return router
+}
+
+func idempotentRequest(router http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/attempts/start", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyConcurrentRetriesRunOnce(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	var runs atomic.Int32
+	router := newIdempotentRouter(store, func(c *gin.Context) {
+		runs.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		c.JSON(http.StatusCreated, gin.H{"attempt": "a1"})
+	})
+
+	const clients = 50
+	var wg sync.WaitGroup
+	codes := make([]int, clients)
+	bodies := make([]string, clients)
+	start := make(chan struct{})
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			w := idempotentRequest(router, "key-1", `{"quiz_id":"q1"}`)
+			codes[i], bodies[i] = w.Code, w.Body.String()
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	if got := runs.Load(); got != 1 {
+		t.Fatalf("handler ran %d times, want 1", got)
+	}
+	created := 0
+	for i, code := range codes {
+		switch code {
+		case http.StatusCreated:
+			created++
+			if bodies[i] != `{"attempt":"a1"}` {
+				t.Errorf("client %d got body %s", i, bodies[i])
+			}
+		case http.StatusConflict:
+		default:
+			t.Errorf("client %d got status %d", i, code)
+		}
+	}
+	if created == 0 {
+		t.Fatal("no client got the created response")
+	}
+
+	// Once the first request is done, retries replay its response
+	w := idempotentRequest(router, "key-1", `{"quiz_id":"q1"}`)
+	if w.Code != http.StatusCreated || w.Body.String() != `{"attempt":"a1"}` || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("replay got %d %s, replayed header %q", w.Code, w.Body.String(), w.Header().Get("Idempotent-Replayed"))
+	}
+	if got := runs.Load(); got != 1 {
+		t.Fatalf("handler ran %d times after replay, want 1", got)
+	}
+}
+
+func TestIdempotencyDistinctKeysRunConcurrently(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	var runs atomic.Int32
+	router := newIdempotentRouter(store, func(c *gin.Context) {
+		runs.Add(1)
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	const clients = 20
+	var wg sync.WaitGroup
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := "key-" + string(rune('a'+i))
+			if w := idempotentRequest(router, key, `{}`); w.Code != http.StatusOK {
+				t.Errorf("key %s got status %d", key, w.Code)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if got := runs.Load(); got != clients {
+		t.Fatalf("handler ran %d times, want %d", got, clients)
+	}
+}
+
+func TestIdempotencyRejectsDifferentBody(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	router := newIdempotentRouter(store, func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	idempotentRequest(router, "key-1", `{"quiz_id":"q1"}`)
+	if w := idempotentRequest(router, "key-1", `{"quiz_id":"q2"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("got status %d, want 422", w.Code)
+	}
+}
+
+func TestIdempotencyReleasesKeyOnServerError(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	var runs atomic.Int32
+	router := newIdempotentRouter(store, func(c *gin.Context) {
+		if runs.Add(1) == 1 {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "boom"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	if w := idempotentRequest(router, "key-1", `{}`); w.Code != http.StatusInternalServerError {
+		t.Fatalf("first request got status %d, want 500", w.Code)
+	}
+	if w := idempotentRequest(router, "key-1", `{}`); w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("retry got status %d, want a fresh 201", w.Code)
+	}
+}
+
+func TestIdempotencyStoresResponseOfSlowHandler(t *testing.T) {
+	previous := idempotencyStoreTimeout
+	idempotencyStoreTimeout = 20 * time.Millisecond
+	defer func() { idempotencyStoreTimeout = previous }()
+
+	store := newMemoryIdempotencyStore()
+	router := newIdempotentRouter(store, func(c *gin.Context) {
+		time.Sleep(2 * idempotencyStoreTimeout)
+		c.JSON(http.StatusCreated, gin.H{"attempt": "a1"})
+	})
+
+	idempotentRequest(router, "key-1", `{}`)
+	if record := store.only(t); !record.Completed {
+		t.Fatal("response of a handler slower than the store timeout was not stored")
+	}
+}
+
+func TestIdempotencyReclaimsStaleKey(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	var runs atomic.Int32
+	router := newIdempotentRouter(store, func(c *gin.Context) {
+		runs.Add(1)
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	// A request that never finished, e.g. because the server crashed
+	idempotentRequest(newIdempotentRouter(store, func(c *gin.Context) {}), "key-1", `{}`)
+	stale := store.only(t)
+	stale.Completed = false
+	stale.CreatedAt = time.Now().Add(-idempotencyLease / 2)
+	store.records[stale.ID] = stale
+
+	if w := idempotentRequest(router, "key-1", `{}`); w.Code != http.StatusConflict {
+		t.Fatalf("retry within the lease got status %d, want 409", w.Code)
+	}
+
+	stale.CreatedAt = time.Now().Add(-2 * idempotencyLease)
+	store.records[stale.ID] = stale
+	if w := idempotentRequest(router, "key-1", `{}`); w.Code != http.StatusCreated {
+		t.Fatalf("retry after the lease got status %d, want 201", w.Code)
+	}
+	if got := runs.Load(); got != 1 {
+		t.Fatalf("handler ran %d times, want 1", got)
+	}
+	if record := store.only(t); !record.Completed {
+		t.Fatal("response of the reclaimed request was not stored")
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRedactPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/api/v1/quizzes", "/api/v1/quizzes"},
+		{"/stream?access_token=secret", "/stream?access_token=REDACTED"},
+		{"/stream?a=1&access_token=secret&b=2", "/stream?a=1&access_token=REDACTED&b=2"},
+		{"/stream?access_token", "/stream?access_token=REDACTED"},
+		{"/stream?my_access_token=x", "/stream?my_access_token=x"},
+	}
+	for _, tt := range tests {
+		if got := redactPath(tt.path); got != tt.want {
+			t.Errorf("redactPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLoggerRedactsAccessToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var logs bytes.Buffer
+	previous := gin.DefaultWriter
+	gin.DefaultWriter = &logs
+	defer func() { gin.DefaultWriter = previous }()
+
+	router := gin.New()
+	router.Use(Logger())
+	router.GET("/stream", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/stream?access_token=secret-jwt&since=5", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	line := logs.String()
+	if strings.Contains(line, "secret-jwt") {
+		t.Fatalf("log line leaks the token: %s", line)
+	}
+	if !strings.Contains(line, "/stream?access_token=REDACTED&since=5") {
+		t.Fatalf("log line does not show the redacted path: %s", line)
+	}
+}
@@ -23,6 +23,7 @@ type User struct {
 	FirstName string             `bson:"first_name" json:"first_name" binding:"required" example:"John"`
 	LastName  string             `bson:"last_name" json:"last_name" binding:"required" example:"Doe"`
 	Role      UserRole           `bson:"role" json:"role" binding:"required" enums:"student,professor" example:"student"`
+	XP        int                `bson:"xp" json:"xp"` // Experience earned from completed attempts and achievements
 	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
 }
@@ -65,6 +66,71 @@ const (
 	QuestionTypeMultipleChoice QuestionType = "multiple_choice"
 )
 
+// ReviewPolicy controls when students can see correct answers after an attempt
+type ReviewPolicy string
+
+const (
+	ReviewImmediately ReviewPolicy = "immediately"
+	ReviewAfterClose  ReviewPolicy = "after_close"
+	ReviewNever       ReviewPolicy = "never"
+)
+
+// AttemptMode tells whether an attempt counts towards leaderboards
+type AttemptMode string
+
+const (
+	ModeRanked   AttemptMode = "ranked"
+	ModePractice AttemptMode = "practice"
+)
+
+// ScoringPolicy selects a named scoring strategy and its parameters
+type ScoringPolicy struct {
+	Strategy  string             `bson:"strategy" json:"strategy" example:"speed_decay"`
+	Params    map[string]float64 `bson:"params,omitempty" json:"params,omitempty"`
+	Modifiers *ScoringModifiers  `bson:"modifiers,omitempty" json:"modifiers,omitempty"`
+}
+
+// ScoringModifiers configures bonuses applied on top of the scoring strategy.
+// Each modifier is enabled by setting it.
+type ScoringModifiers struct {
+	Streak     *StreakBonus       `bson:"streak,omitempty" json:"streak,omitempty"`
+	Perfect    *PerfectBonus      `bson:"perfect,omitempty" json:"perfect,omitempty"`
+	Difficulty *DifficultyWeights `bson:"difficulty,omitempty" json:"difficulty,omitempty"`
+}
+
+// StreakBonus multiplies points once a student answers MinStreak questions
+// correctly in a row, growing by Step per further correct answer up to MaxMultiplier
+type StreakBonus struct {
+	MinStreak     int     `bson:"min_streak" json:"min_streak" example:"3"`
+	Step          float64 `bson:"step" json:"step" example:"0.1"`
+	MaxMultiplier float64 `bson:"max_multiplier" json:"max_multiplier" example:"1.5"`
+}
+
+// PerfectBonus awards Percent of the quiz's base points when every question is answered correctly
+type PerfectBonus struct {
+	Percent float64 `bson:"percent" json:"percent" example:"0.1"`
+}
+
+// DifficultyWeights multiplies points by the quiz's difficulty level
+type DifficultyWeights struct {
+	Easy   float64 `bson:"easy" json:"easy" example:"1"`
+	Medium float64 `bson:"medium" json:"medium" example:"1.25"`
+	Hard   float64 `bson:"hard" json:"hard" example:"1.5"`
+}
+
+// ScoreBreakdown explains how the points of an answer were computed
+type ScoreBreakdown struct {
+	BasePoints           int     `bson:"base_points" json:"base_points"`
+	StrategyPoints       float64 `bson:"strategy_points" json:"strategy_points"`
+	StreakLength         int     `bson:"streak_length" json:"streak_length"`
+	StreakMultiplier     float64 `bson:"streak_multiplier" json:"streak_multiplier"`
+	StreakBonus          float64 `bson:"streak_bonus" json:"streak_bonus"`
+	DifficultyMultiplier float64 `bson:"difficulty_multiplier" json:"difficulty_multiplier"`
+	DifficultyBonus      float64 `bson:"difficulty_bonus" json:"difficulty_bonus"`
+	AssistPenalty        float64 `bson:"assist_penalty" json:"assist_penalty"`
+	Total                float64 `bson:"total" json:"total"`
+}
+
 // Quiz represents a quiz in the system
 type Quiz struct {
 	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
@@ -77,22 +143,102 @@ type Quiz struct {
 	CreatorRole     UserRole           `bson:"creator_role" json:"creator_role"`
 	Status          QuizStatus         `bson:"status" json:"status"`
 	Questions       []Question         `bson:"questions" json:"questions"`
+	ReviewPolicy    ReviewPolicy       `bson:"review_policy,omitempty" json:"review_policy,omitempty"`
+	Scoring         *ScoringPolicy     `bson:"scoring,omitempty" json:"scoring,omitempty"`
+	Lifelines       []Lifeline         `bson:"lifelines,omitempty" json:"lifelines,omitempty"`
+	ClosesAt        *time.Time         `bson:"closes_at,omitempty" json:"closes_at,omitempty"`
+	Adaptive        *AdaptiveSettings  `bson:"adaptive,omitempty" json:"adaptive,omitempty"`
+	Practice        *PracticeSettings  `bson:"practice,omitempty" json:"practice,omitempty"`
+	AttemptCount    int                `bson:"attempt_count" json:"attempt_count"`
+	AverageScore    float64            `bson:"average_score" json:"average_score"` // Average percentage of counted attempts
+	PercentageSum   float64            `bson:"percentage_sum" json:"-"`
 	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt       time.Time          `bson:"updated_at" json:"updated_at"`
 	ApprovedBy      primitive.ObjectID `bson:"approved_by,omitempty" json:"approved_by,omitempty"`
 	ApprovedAt      *time.Time         `bson:"approved_at,omitempty" json:"approved_at,omitempty"`
 }
 
+// AdaptiveSettings turns the questions of a quiz into a pool from which each
+// attempt is served QuestionCount questions matched to the student's ability
+type AdaptiveSettings struct {
+	QuestionCount int        `bson:"question_count" json:"question_count" example:"10"`
+	CalibratedAt  *time.Time `bson:"calibrated_at,omitempty" json:"calibrated_at,omitempty"`
+}
+
+// PracticeSettings lets students take practice attempts of a quiz, which
+// give immediate feedback and never count towards leaderboards
+type PracticeSettings struct {
+	Enabled          bool `bson:"enabled" json:"enabled" example:"true"`
+	SkipCourseGating bool `bson:"skip_course_gating" json:"skip_course_gating" example:"true"` // Practice does not require the course to be completed
+}
+
+// QuizSummary is a lightweight view of a quiz without its questions
+type QuizSummary struct {
+	ID              primitive.ObjectID `bson:"_id" json:"id"`
+	Title           string             `bson:"title" json:"title"`
+	Description     string             `bson:"description" json:"description"`
+	Category        QuizCategory       `bson:"category" json:"category"`
+	DifficultyLevel DifficultyLevel    `bson:"difficulty_level" json:"difficulty_level"`
+	CourseID        string             `bson:"course_id" json:"course_id"`
+	CreatorID       primitive.ObjectID `bson:"creator_id" json:"creator_id"`
+	CreatorRole     UserRole           `bson:"creator_role" json:"creator_role"`
+	Status          QuizStatus         `bson:"status" json:"status"`
+	QuestionCount   int                `bson:"question_count" json:"question_count"`
+	AttemptCount    int                `bson:"attempt_count" json:"attempt_count"`
+	AverageScore    float64            `bson:"average_score" json:"average_score"`
+	Practice        *PracticeSettings  `bson:"practice,omitempty" json:"practice,omitempty"`
+	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+}
+
 // Question represents a question in a quiz
 type Question struct {
 	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id" example:"507f1f77bcf86cd799439012"`
 	QuestionText  string             `bson:"question_text" json:"question_text" binding:"required" example:"Is Go a compiled language?"`
 	Type          QuestionType       `bson:"type" json:"type" binding:"required" enums:"true_false,multiple_choice" example:"true_false"`
-	Options       []string           `bson:"options,omitempty" json:"options,omitempty" swaggertype:"array,string"`    // For multiple choice
-	CorrectAnswer interface{}        `bson:"correct_answer" json:"correct_answer" swaggertype:"string" example:"true"` // bool for T/F, int for MC (index)
-	TimeLimit     int                `bson:"time_limit" json:"time_limit" example:"15"`                                // In seconds, default 15
-	Points        int                `bson:"points" json:"points" example:"10"`                                        // Base points for this question
-	Order         int                `bson:"order" json:"order" example:"1"`                                           // Question order in quiz
+	Options       []string           `bson:"options,omitempty" json:"options,omitempty" swaggertype:"array,string"`              // For multiple choice
+	CorrectAnswer interface{}        `bson:"correct_answer" json:"correct_answer,omitempty" swaggertype:"string" example:"true"` // bool for T/F, int for MC (index)
+	TimeLimit     int                `bson:"time_limit" json:"time_limit" example:"15"`                                          // In seconds, default 15
+	Points        int                `bson:"points" json:"points" example:"10"`                                                  // Base points for this question
+	Order         int                `bson:"order" json:"order" example:"1"`                                                     // Question order in quiz
+	Explanation   string             `bson:"explanation,omitempty" json:"explanation,omitempty" example:"Go compiles to native machine code."`
+	Hints         []Hint             `bson:"hints,omitempty" json:"hints,omitempty"`
+	HintCount     int                `bson:"-" json:"hint_count,omitempty"` // Set instead of Hints when hints are hidden
+
+	// Rasch difficulty in logits estimated from historical answers, used by adaptive attempts
+	IRTDifficulty *float64 `bson:"irt_difficulty,omitempty" json:"-"`
+}
+
+// Hint is an ordered clue for a question. Revealing it deducts Penalty
+// (a fraction of the points earned) from the answer.
+type Hint struct {
+	Text    string  `bson:"text" json:"text" example:"Think about how Go programs are run."`
+	Penalty float64 `bson:"penalty" json:"penalty" example:"0.1"`
+}
+
+// AssistType represents a kind of help a student can use during an attempt
+type AssistType string
+
+const (
+	AssistHint       AssistType = "hint"
+	AssistFiftyFifty AssistType = "fifty_fifty"
+	AssistSkip       AssistType = "skip"
+)
+
+// Lifeline configures a lifeline students may use during an attempt on a quiz
+type Lifeline struct {
+	Type    AssistType `bson:"type" json:"type" enums:"fifty_fifty,skip" example:"fifty_fifty"`
+	Uses    int        `bson:"uses" json:"uses" example:"1"`          // Per attempt
+	Penalty float64    `bson:"penalty" json:"penalty" example:"0.25"` // Fraction of the points earned (of base points for skip)
+}
+
+// AssistUsage records a hint or lifeline used on a question during an attempt
+type AssistUsage struct {
+	QuestionID     primitive.ObjectID `bson:"question_id" json:"question_id"`
+	Type           AssistType         `bson:"type" json:"type"`
+	HintIndex      int                `bson:"hint_index,omitempty" json:"hint_index,omitempty"`
+	RemovedOptions []int              `bson:"removed_options,omitempty" json:"removed_options,omitempty"`
+	Penalty        float64            `bson:"penalty" json:"penalty"`
+	UsedAt         time.Time          `bson:"used_at" json:"used_at"`
 }
 
 // QuizAttempt represents a student's attempt at a quiz
@@ -106,6 +252,147 @@ type QuizAttempt struct {
 	StartedAt   time.Time          `bson:"started_at" json:"started_at"`
 	CompletedAt *time.Time         `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
 	TimeTaken   int                `bson:"time_taken" json:"time_taken"` // In seconds
+
+	// Practice attempts give immediate feedback and are left out of
+	// leaderboards, achievements, quiz stats and gradebooks. Empty is ranked
+	Mode AttemptMode `bson:"mode,omitempty" json:"mode,omitempty" enums:"ranked,practice"`
+
+	// Scoring policy of the quiz when the attempt started
+	Scoring *ScoringPolicy `bson:"scoring,omitempty" json:"scoring,omitempty"`
+
+	// Set when the attempt belongs to a live classroom session
+	SessionID *primitive.ObjectID `bson:"session_id,omitempty" json:"session_id,omitempty"`
+
+	// Set when the attempt is a student's side of a head-to-head challenge
+	ChallengeID *primitive.ObjectID `bson:"challenge_id,omitempty" json:"challenge_id,omitempty"`
+
+	// Set on adaptive attempts: the questions served so far, in order, and the
+	// Rasch ability estimate updated after every answer
+	Adaptive    bool                 `bson:"adaptive,omitempty" json:"adaptive,omitempty"`
+	QuestionIDs []primitive.ObjectID `bson:"question_ids,omitempty" json:"question_ids,omitempty"`
+	Ability     *AbilityEstimate     `bson:"ability,omitempty" json:"ability,omitempty"`
+
+	// Hints and lifelines used so far, including on questions not yet answered
+	Assists []AssistUsage `bson:"assists,omitempty" json:"assists,omitempty"`
+
+	// Rewards granted when the attempt was completed
+	XPEarned        int                 `bson:"xp_earned,omitempty" json:"xp_earned,omitempty"`
+	NewAchievements []EarnedAchievement `bson:"-" json:"new_achievements,omitempty"` // Only in the completion response
+
+	// Computed server-side when the attempt is completed
+	CorrectCount    int     `bson:"correct_count" json:"correct_count"`
+	IncorrectCount  int     `bson:"incorrect_count" json:"incorrect_count"`
+	UnansweredCount int     `bson:"unanswered_count" json:"unanswered_count"`
+	PerfectBonus    float64 `bson:"perfect_bonus,omitempty" json:"perfect_bonus,omitempty"`
+	Percentage      float64 `bson:"percentage" json:"percentage"`
+	ScoringVersion  string  `bson:"scoring_version,omitempty" json:"scoring_version,omitempty"`
+
+	// Incremented each time a professor adjusts the completed attempt, so
+	// concurrent overrides and regrades don't overwrite each other
+	Revision int `bson:"revision,omitempty" json:"-"`
+
+	// Set when a professor invalidates the attempt; invalidated attempts are excluded from leaderboards
+	InvalidatedAt      *time.Time         `bson:"invalidated_at,omitempty" json:"invalidated_at,omitempty"`
+	InvalidatedBy      primitive.ObjectID `bson:"invalidated_by,omitempty" json:"invalidated_by,omitempty"`
+	InvalidationReason string             `bson:"invalidation_reason,omitempty" json:"invalidation_reason,omitempty"`
+}
+
+// AbilityEstimate is a student's ability on the logit scale of the question difficulties
+type AbilityEstimate struct {
+	Theta         float64 `bson:"theta" json:"theta"`
+	StandardError float64 `bson:"standard_error" json:"standard_error"`
+	Answers       int     `bson:"answers" json:"answers"` // Answers the estimate is based on
+}
+
+// LiveSessionStatus represents the state of a live classroom session
+type LiveSessionStatus string
+
+const (
+	// SessionLobby waits for students to join before the first question
+	SessionLobby LiveSessionStatus = "lobby"
+	// SessionQuestion has a question open for answers
+	SessionQuestion LiveSessionStatus = "question"
+	// SessionLeaderboard shows the standings between questions
+	SessionLeaderboard LiveSessionStatus = "leaderboard"
+	SessionFinished    LiveSessionStatus = "finished"
+	SessionCancelled   LiveSessionStatus = "cancelled"
+)
+
+// LiveSession is a professor-hosted session where every participant answers
+// the same question at the same time
+type LiveSession struct {
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	QuizID            primitive.ObjectID `bson:"quiz_id" json:"quiz_id"`
+	HostID            primitive.ObjectID `bson:"host_id" json:"host_id"`
+	JoinCode          string             `bson:"join_code" json:"join_code"`
+	Status            LiveSessionStatus  `bson:"status" json:"status"`
+	CurrentQuestion   int                `bson:"current_question" json:"current_question"` // Index into the quiz questions, -1 before the first
+	QuestionStartedAt *time.Time         `bson:"question_started_at,omitempty" json:"question_started_at,omitempty"`
+	Participants      []LiveParticipant  `bson:"participants" json:"participants"`
+	CreatedAt         time.Time          `bson:"created_at" json:"created_at"`
+	StartedAt         *time.Time         `bson:"started_at,omitempty" json:"started_at,omitempty"`
+	FinishedAt        *time.Time         `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+	RecordedAt        *time.Time         `bson:"recorded_at,omitempty" json:"recorded_at,omitempty"` // Set once every attempt of a finished session is recorded
+}
+
+// LiveParticipant is a student who joined a live session
+type LiveParticipant struct {
+	StudentID   primitive.ObjectID `bson:"student_id" json:"student_id"`
+	StudentName string             `bson:"student_name" json:"student_name"`
+	AttemptID   primitive.ObjectID `bson:"attempt_id" json:"attempt_id"`
+	JoinedAt    time.Time          `bson:"joined_at" json:"joined_at"`
+}
+
+// ChallengeStatus represents the state of a head-to-head challenge
+type ChallengeStatus string
+
+const (
+	// ChallengePending waits for the opponent to accept
+	ChallengePending ChallengeStatus = "pending"
+	// ChallengeActive was accepted; both students can attempt the quiz
+	ChallengeActive   ChallengeStatus = "active"
+	ChallengeFinished ChallengeStatus = "finished"
+	ChallengeDeclined ChallengeStatus = "declined"
+	// ChallengeExpired ran out of time without a result
+	ChallengeExpired ChallengeStatus = "expired"
+)
+
+// ChallengeOutcome is the result of a finished challenge for one of its students
+type ChallengeOutcome string
+
+const (
+	OutcomeWin  ChallengeOutcome = "win"
+	OutcomeLoss ChallengeOutcome = "loss"
+	OutcomeDraw ChallengeOutcome = "draw"
+)
+
+// Challenge is a student challenging a classmate to beat their result on a quiz
+type Challenge struct {
+	ID                  primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	QuizID              primitive.ObjectID  `bson:"quiz_id" json:"quiz_id"`
+	QuizTitle           string              `bson:"quiz_title" json:"quiz_title"`
+	ChallengerID        primitive.ObjectID  `bson:"challenger_id" json:"challenger_id"`
+	ChallengerName      string              `bson:"challenger_name" json:"challenger_name"`
+	OpponentID          primitive.ObjectID  `bson:"opponent_id" json:"opponent_id"`
+	OpponentName        string              `bson:"opponent_name" json:"opponent_name"`
+	Status              ChallengeStatus     `bson:"status" json:"status"`
+	ChallengerAttemptID *primitive.ObjectID `bson:"challenger_attempt_id,omitempty" json:"challenger_attempt_id,omitempty"`
+	OpponentAttemptID   *primitive.ObjectID `bson:"opponent_attempt_id,omitempty" json:"opponent_attempt_id,omitempty"`
+	ChallengerResult    *ChallengeResult    `bson:"challenger_result,omitempty" json:"challenger_result,omitempty"`
+	OpponentResult      *ChallengeResult    `bson:"opponent_result,omitempty" json:"opponent_result,omitempty"`
+	WinnerID            *primitive.ObjectID `bson:"winner_id,omitempty" json:"winner_id,omitempty"` // Unset on a draw
+	ResultReason        string              `bson:"result_reason,omitempty" json:"result_reason,omitempty"`
+	ExpiresAt           time.Time           `bson:"expires_at" json:"expires_at"`
+	CreatedAt           time.Time           `bson:"created_at" json:"created_at"`
+	AcceptedAt          *time.Time          `bson:"accepted_at,omitempty" json:"accepted_at,omitempty"`
+	FinishedAt          *time.Time          `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+}
+
+// ChallengeResult is one student's completed attempt in a challenge
+type ChallengeResult struct {
+	Score      float64 `bson:"score" json:"score"`
+	Percentage float64 `bson:"percentage" json:"percentage"`
+	TimeTaken  int     `bson:"time_taken" json:"time_taken"`
 }
 
 // Answer represents a student's answer to a question
@@ -116,16 +403,212 @@ type Answer struct {
 	TimeToAnswer  int                `bson:"time_to_answer" json:"time_to_answer"` // In seconds
 	PointsEarned  float64            `bson:"points_earned" json:"points_earned"`
 	AnsweredAt    time.Time          `bson:"answered_at" json:"answered_at"`
+	Skipped       bool               `bson:"skipped,omitempty" json:"skipped,omitempty"`
+	Assists       []AssistUsage      `bson:"assists,omitempty" json:"assists,omitempty"`
+	Breakdown     *ScoreBreakdown    `bson:"breakdown,omitempty" json:"breakdown,omitempty"`
+	Override      *ScoreOverride     `bson:"override,omitempty" json:"override,omitempty"`
+}
+
+// ScoreOverride records a professor's manual change to the points of an answer.
+// Overridden answers keep their points when the quiz is regraded.
+type ScoreOverride struct {
+	Points         float64            `bson:"points" json:"points"`
+	OriginalPoints float64            `bson:"original_points" json:"original_points"`
+	Reason         string             `bson:"reason" json:"reason"`
+	ProfessorID    primitive.ObjectID `bson:"professor_id" json:"professor_id"`
+	OverriddenAt   time.Time          `bson:"overridden_at" json:"overridden_at"`
 }
 
 // LeaderboardEntry represents an entry in the quiz leaderboard
 type LeaderboardEntry struct {
-	Rank        int                `json:"rank"`
-	StudentID   primitive.ObjectID `json:"student_id"`
-	StudentName string             `json:"student_name"`
-	Score       float64            `json:"score"`
-	MaxScore    float64            `json:"max_score"`
-	Percentage  float64            `json:"percentage"`
-	TimeTaken   int                `json:"time_taken"`
-	CompletedAt time.Time          `json:"completed_at"`
+	Rank        int                `bson:"rank" json:"rank"`
+	StudentID   primitive.ObjectID `bson:"student_id" json:"student_id"`
+	StudentName string             `bson:"student_name" json:"student_name"`
+	Score       float64            `bson:"score" json:"score"`
+	MaxScore    float64            `bson:"max_score" json:"max_score"`
+	Percentage  float64            `bson:"percentage" json:"percentage"`
+	TimeTaken   int                `bson:"time_taken" json:"time_taken"`
+	CompletedAt time.Time          `bson:"completed_at" json:"completed_at"`
+}
+
+// LeaderboardRecord is the materialized leaderboard row of one counted attempt
+type LeaderboardRecord struct {
+	AttemptID       primitive.ObjectID `bson:"_id" json:"attempt_id"`
+	QuizID          primitive.ObjectID `bson:"quiz_id" json:"quiz_id"`
+	StudentID       primitive.ObjectID `bson:"student_id" json:"student_id"`
+	Score           float64            `bson:"score" json:"score"`
+	MaxScore        float64            `bson:"max_score" json:"max_score"`
+	Percentage      float64            `bson:"percentage" json:"percentage"`
+	TimeTaken       int                `bson:"time_taken" json:"time_taken"`
+	CompletedAt     time.Time          `bson:"completed_at" json:"completed_at"`
+	Category        QuizCategory       `bson:"category" json:"category"`
+	DifficultyLevel DifficultyLevel    `bson:"difficulty_level" json:"difficulty_level"`
+	CourseID        string             `bson:"course_id" json:"course_id"`
+}
+
+// QuestionReview represents one question of a completed attempt as shown in a review
+type QuestionReview struct {
+	QuestionID    primitive.ObjectID `json:"question_id"`
+	QuestionText  string             `json:"question_text"`
+	Type          QuestionType       `json:"type"`
+	Options       []string           `json:"options,omitempty"`
+	Answered      bool               `json:"answered"`
+	StudentAnswer interface{}        `json:"student_answer,omitempty" swaggertype:"string"`
+	IsCorrect     bool               `json:"is_correct"`
+	CorrectAnswer interface{}        `json:"correct_answer,omitempty" swaggertype:"string"`
+	Explanation   string             `json:"explanation,omitempty"`
+	Points        int                `json:"points"`
+	PointsEarned  float64            `json:"points_earned"`
+	TimeToAnswer  int                `json:"time_to_answer"`
+}
+
+// AttemptReview represents a completed attempt joined with its quiz questions
+type AttemptReview struct {
+	AttemptID      primitive.ObjectID `json:"attempt_id"`
+	QuizID         primitive.ObjectID `json:"quiz_id"`
+	QuizTitle      string             `json:"quiz_title"`
+	TotalScore     float64            `json:"total_score"`
+	MaxScore       float64            `json:"max_score"`
+	TimeTaken      int                `json:"time_taken"`
+	CompletedAt    time.Time          `json:"completed_at"`
+	ReviewPolicy   ReviewPolicy       `json:"review_policy"`
+	AnswersVisible bool               `json:"answers_visible"`
+	AvailableAt    *time.Time         `json:"available_at,omitempty"`
+	Questions      []QuestionReview   `json:"questions"`
+}
+
+// AuditAction represents the kind of change recorded in the audit trail
+type AuditAction string
+
+const (
+	AuditScoreOverride      AuditAction = "score_override"
+	AuditAttemptInvalidated AuditAction = "attempt_invalidated"
+	AuditAttemptRegraded    AuditAction = "attempt_regraded"
+	AuditAnswerKeyCorrected AuditAction = "answer_key_corrected"
+	AuditCompletionGranted  AuditAction = "completion_granted"
+	AuditCompletionRevoked  AuditAction = "completion_revoked"
+)
+
+// AuditLog represents a recorded change made by a user to another user's data
+type AuditLog struct {
+	ID         primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	ActorID    primitive.ObjectID     `bson:"actor_id" json:"actor_id"`
+	Action     AuditAction            `bson:"action" json:"action"`
+	EntityType string                 `bson:"entity_type" json:"entity_type"`
+	EntityID   primitive.ObjectID     `bson:"entity_id" json:"entity_id"`
+	QuizID     primitive.ObjectID     `bson:"quiz_id,omitempty" json:"quiz_id,omitempty"`
+	StudentID  primitive.ObjectID     `bson:"student_id,omitempty" json:"student_id,omitempty"`
+	Reason     string                 `bson:"reason,omitempty" json:"reason,omitempty"`
+	Changes    map[string]interface{} `bson:"changes,omitempty" json:"changes,omitempty"`
+	CreatedAt  time.Time              `bson:"created_at" json:"created_at"`
+}
+
+// AchievementMetric is the statistic an achievement rule is evaluated against
+type AchievementMetric string
+
+const (
+	// MetricCompletedAttempts counts completed attempts, optionally of one category or difficulty
+	MetricCompletedAttempts AchievementMetric = "completed_attempts"
+	// MetricPerfectScores counts completed attempts scoring 100%
+	MetricPerfectScores AchievementMetric = "perfect_scores"
+	// MetricStreakDays is the student's current daily streak, in their timezone
+	MetricStreakDays AchievementMetric = "streak_days"
+	// MetricLeaderboardRank is the quiz leaderboard rank of the completed attempt; lower is better
+	MetricLeaderboardRank AchievementMetric = "leaderboard_rank"
+	// MetricAttemptPercentage is the percentage scored by the completed attempt
+	MetricAttemptPercentage AchievementMetric = "attempt_percentage"
+)
+
+// Achievement is a badge definition. Rules are data, so new badges can be
+// added without code changes
+type Achievement struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	Key         string             `bson:"key" json:"key" example:"first_perfect_score"`
+	Name        string             `bson:"name" json:"name" example:"Flawless"`
+	Description string             `bson:"description" json:"description" example:"Score 100% on a quiz"`
+	Icon        string             `bson:"icon,omitempty" json:"icon,omitempty"`
+	XP          int                `bson:"xp" json:"xp" example:"50"`
+	Rule        AchievementRule    `bson:"rule" json:"rule"`
+	Active      bool               `bson:"active" json:"active"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// AchievementRule is met when the metric reaches the threshold, or for
+// leaderboard_rank when the rank is at most the threshold
+type AchievementRule struct {
+	Metric     AchievementMetric `bson:"metric" json:"metric" enums:"completed_attempts,perfect_scores,streak_days,leaderboard_rank,attempt_percentage"`
+	Threshold  float64           `bson:"threshold" json:"threshold" example:"10"`
+	Category   QuizCategory      `bson:"category,omitempty" json:"category,omitempty"`
+	Difficulty DifficultyLevel   `bson:"difficulty,omitempty" json:"difficulty,omitempty"`
+}
+
+// EarnedAchievement is a badge awarded to a student
+type EarnedAchievement struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	UserID      primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Key         string             `bson:"key" json:"key"`
+	Name        string             `bson:"name" json:"name"`
+	Description string             `bson:"description" json:"description"`
+	Icon        string             `bson:"icon,omitempty" json:"icon,omitempty"`
+	XP          int                `bson:"xp" json:"xp"`
+	QuizID      primitive.ObjectID `bson:"quiz_id" json:"quiz_id"`       // Quiz whose attempt earned the badge
+	AttemptID   primitive.ObjectID `bson:"attempt_id" json:"attempt_id"` // Attempt that earned the badge
+	EarnedAt    time.Time          `bson:"earned_at" json:"earned_at"`
+}
+
+// StudentProgress holds a student's streak and goal settings and the streak
+// freeze tokens they hold
+type StudentProgress struct {
+	StudentID        primitive.ObjectID `bson:"_id" json:"student_id"`
+	Timezone         string             `bson:"timezone" json:"timezone" example:"Europe/Paris"` // IANA name; days start at local midnight
+	WeeklyGoal       int                `bson:"weekly_goal" json:"weekly_goal" example:"3"`      // Quizzes to complete per week, weeks start on Monday
+	FreezeTokens     int                `bson:"freeze_tokens" json:"freeze_tokens"`
+	FrozenDays       []string           `bson:"frozen_days" json:"frozen_days"` // Missed days covered by a freeze token, YYYY-MM-DD
+	LastRewardedWeek string             `bson:"last_rewarded_week,omitempty" json:"-"`
+	UpdatedAt        time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// ReviewItem is a question a student missed, scheduled for spaced repetition
+// review with the SM-2 algorithm
+type ReviewItem struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	StudentID      primitive.ObjectID `bson:"student_id" json:"student_id"`
+	QuizID         primitive.ObjectID `bson:"quiz_id" json:"quiz_id"`
+	QuestionID     primitive.ObjectID `bson:"question_id" json:"question_id"`
+	AttemptID      primitive.ObjectID `bson:"attempt_id" json:"attempt_id"`       // Last attempt the question was missed in
+	EaseFactor     float64            `bson:"ease_factor" json:"ease_factor"`     // Interval multiplier, at least 1.3
+	IntervalDays   int                `bson:"interval_days" json:"interval_days"` // Days between the last review and the next
+	Repetitions    int                `bson:"repetitions" json:"repetitions"`     // Successful reviews in a row
+	Lapses         int                `bson:"lapses" json:"lapses"`               // Times the question was missed, in attempts or reviews
+	DueAt          time.Time          `bson:"due_at" json:"due_at"`
+	LastReviewedAt *time.Time         `bson:"last_reviewed_at,omitempty" json:"last_reviewed_at,omitempty"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// CompletionSource tells where a course completion result came from
+type CompletionSource string
+
+const (
+	CompletionFromAPI     CompletionSource = "api"     // Checked with the course API
+	CompletionFromWebhook CompletionSource = "webhook" // Pushed by the course platform
+	CompletionFromManual  CompletionSource = "manual"  // Granted or revoked by a professor
+)
+
+// CourseCompletion is the known completion status of a course by a student.
+// Completions are kept for good; results that a course was not completed
+// expire so the course API is asked again. Manual results override the others
+type CourseCompletion struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	StudentID   primitive.ObjectID  `bson:"student_id" json:"student_id"`
+	CourseID    string              `bson:"course_id" json:"course_id"`
+	Completed   bool                `bson:"completed" json:"completed"`
+	Source      CompletionSource    `bson:"source" json:"source"`
+	CompletedAt *time.Time          `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time          `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	UpdatedBy   *primitive.ObjectID `bson:"updated_by,omitempty" json:"updated_by,omitempty"` // Professor of a manual result
+	Reason      string              `bson:"reason,omitempty" json:"reason,omitempty"`
+	CreatedAt   time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time           `bson:"updated_at" json:"updated_at"`
 }
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"quizmasterapi/config"
+	"quizmasterapi/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// XP for completing an attempt, plus one point per 10% scored
+	attemptBaseXP = 10
+	// XP needed to go from level n to n+1 is levelXPStep * n
+	levelXPStep = 100
+)
+
+// DefaultAchievements are created at startup if missing. Existing definitions
+// are never overwritten, so they can be tuned in the database
+var DefaultAchievements = []models.Achievement{
+	{
+		Key:         "first_quiz",
+		Name:        "First Steps",
+		Description: "Complete your first quiz",
+		XP:          20,
+		Rule:        models.AchievementRule{Metric: models.MetricCompletedAttempts, Threshold: 1},
+	},
+	{
+		Key:         "first_perfect_score",
+		Name:        "Flawless",
+		Description: "Score 100% on a quiz",
+		XP:          50,
+		Rule:        models.AchievementRule{Metric: models.MetricPerfectScores, Threshold: 1},
+	},
+	{
+		Key:         "programming_10",
+		Name:        "Code Cruncher",
+		Description: "Complete 10 programming quizzes",
+		XP:          100,
+		Rule:        models.AchievementRule{Metric: models.MetricCompletedAttempts, Threshold: 10, Category: models.CategoryProgramming},
+	},
+	{
+		Key:         "streak_7",
+		Name:        "On a Roll",
+		Description: "Complete a quiz 7 days in a row",
+		XP:          100,
+		Rule:        models.AchievementRule{Metric: models.MetricStreakDays, Threshold: 7},
+	},
+	{
+		Key:         "top_3",
+		Name:        "Podium",
+		Description: "Reach the top 3 of a quiz leaderboard",
+		XP:          75,
+		Rule:        models.AchievementRule{Metric: models.MetricLeaderboardRank, Threshold: 3},
+	},
+}
+
+// AchievementService evaluates achievement rules and awards badges and XP
+type AchievementService struct {
+	collection     *mongo.Collection
+	earned         *mongo.Collection
+	userCollection *mongo.Collection
+	attempts       *mongo.Collection
+	leaderboards   *LeaderboardService
+	progress       *ProgressService
+}
+
+// Level describes a student's progress through the XP levels
+type Level struct {
+	Level       int `json:"level"`
+	XP          int `json:"xp"`
+	LevelXP     int `json:"level_xp"`      // XP at which the current level started
+	NextLevelXP int `json:"next_level_xp"` // XP needed for the next level
+}
+
+// NewAchievementService creates a new achievement service
+func NewAchievementService(leaderboards *LeaderboardService, progress *ProgressService) *AchievementService {
+	return &AchievementService{
+		collection:     config.GetCollection("achievements"),
+		earned:         config.GetCollection("user_achievements"),
+		userCollection: config.GetCollection("users"),
+		attempts:       config.GetCollection("attempts"),
+		leaderboards:   leaderboards,
+		progress:       progress,
+	}
+}
+
+// SeedDefaults creates the default achievements that do not exist yet
+func (as *AchievementService) SeedDefaults(ctx context.Context) error {
+	now := time.Now()
+	for _, achievement := range DefaultAchievements {
+		achievement.Active = true
+		achievement.CreatedAt = now
+		achievement.UpdatedAt = now
+		_, err := as.collection.UpdateOne(ctx,
+			bson.M{"key": achievement.Key},
+			bson.M{"$setOnInsert": achievement},
+			options.Update().SetUpsert(true))
+		if err != nil {
+			return fmt.Errorf("failed to seed achievement %s: %w", achievement.Key, err)
+		}
+	}
+	return nil
+}
+
+// Definitions returns the achievement definitions, only the active ones unless all is set
+func (as *AchievementService) Definitions(ctx context.Context, all bool) ([]models.Achievement, error) {
+	filter := bson.M{"active": true}
+	if all {
+		filter = bson.M{}
+	}
+
+	cursor, err := as.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "key", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch achievements: %w", err)
+	}
+
+	achievements := []models.Achievement{}
+	if err := cursor.All(ctx, &achievements); err != nil {
+		return nil, fmt.Errorf("failed to fetch achievements: %w", err)
+	}
+	return achievements, nil
+}
+
+// Upsert creates or replaces an achievement definition by key
+func (as *AchievementService) Upsert(ctx context.Context, achievement *models.Achievement) error {
+	if err := ValidateAchievementRule(&achievement.Rule); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	achievement.UpdatedAt = now
+	err := as.collection.FindOneAndUpdate(ctx,
+		bson.M{"key": achievement.Key},
+		bson.M{
+			"$set": bson.M{
+				"name":        achievement.Name,
+				"description": achievement.Description,
+				"icon":        achievement.Icon,
+				"xp":          achievement.XP,
+				"rule":        achievement.Rule,
+				"active":      achievement.Active,
+				"updated_at":  now,
+			},
+			"$setOnInsert": bson.M{"created_at": now},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(achievement)
+	if err != nil {
+		return fmt.Errorf("failed to save achievement: %w", err)
+	}
+	return nil
+}
+
+// ValidateAchievementRule checks that a rule uses a known metric and a usable threshold
+func ValidateAchievementRule(rule *models.AchievementRule) error {
+	switch rule.Metric {
+	case models.MetricCompletedAttempts, models.MetricPerfectScores, models.MetricStreakDays,
+		models.MetricLeaderboardRank, models.MetricAttemptPercentage:
+	default:
+		return fmt.Errorf("unknown achievement metric %q", rule.Metric)
+	}
+	if rule.Threshold <= 0 {
+		return fmt.Errorf("achievement threshold must be positive")
+	}
+	if (rule.Category != "" || rule.Difficulty != "") && rule.Metric != models.MetricCompletedAttempts && rule.Metric != models.MetricPerfectScores {
+		return fmt.Errorf("category and difficulty only apply to completed_attempts and perfect_scores")
+	}
+	return nil
+}
+
+// Earned returns the achievements a student has earned, most recent first
+func (as *AchievementService) Earned(ctx context.Context, userID primitive.ObjectID) ([]models.EarnedAchievement, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "earned_at", Value: -1}})
+	cursor, err := as.earned.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch earned achievements: %w", err)
+	}
+
+	earned := []models.EarnedAchievement{}
+	if err := cursor.All(ctx, &earned); err != nil {
+		return nil, fmt.Errorf("failed to fetch earned achievements: %w", err)
+	}
+	return earned, nil
+}
+
+// Evaluate runs the active rules after an attempt is completed and recorded on
+// the leaderboard. It awards newly met achievements and the XP of the attempt
+// and achievements, and stores the XP earned on the attempt
+func (as *AchievementService) Evaluate(ctx context.Context, attempt *models.QuizAttempt) ([]models.EarnedAchievement, int, error) {
+	definitions, err := as.Definitions(ctx, false)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	earnedKeys, err := as.earned.Distinct(ctx, "key", bson.M{"user_id": attempt.StudentID})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch earned achievements: %w", err)
+	}
+	owned := make(map[string]bool, len(earnedKeys))
+	for _, key := range earnedKeys {
+		if k, ok := key.(string); ok {
+			owned[k] = true
+		}
+	}
+
+	eval := &ruleEvaluator{service: as, attempt: attempt, values: make(map[models.AchievementRule]float64)}
+	now := time.Now()
+	awarded := []models.EarnedAchievement{}
+	xp := attemptXP(attempt)
+
+	for _, definition := range definitions {
+		if owned[definition.Key] {
+			continue
+		}
+
+		met, err := eval.met(ctx, definition.Rule)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !met {
+			continue
+		}
+
+		earned := models.EarnedAchievement{
+			ID:          primitive.NewObjectID(),
+			UserID:      attempt.StudentID,
+			Key:         definition.Key,
+			Name:        definition.Name,
+			Description: definition.Description,
+			Icon:        definition.Icon,
+			XP:          definition.XP,
+			QuizID:      attempt.QuizID,
+			AttemptID:   attempt.ID,
+			EarnedAt:    now,
+		}
+
+		// The unique (user_id, key) index stops a concurrent evaluation awarding it twice
+		if _, err := as.earned.InsertOne(ctx, earned); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				continue
+			}
+			return nil, 0, fmt.Errorf("failed to award achievement %s: %w", definition.Key, err)
+		}
+		awarded = append(awarded, earned)
+		xp += definition.XP
+	}
+
+	if _, err := as.userCollection.UpdateOne(ctx, bson.M{"_id": attempt.StudentID}, bson.M{"$inc": bson.M{"xp": xp}}); err != nil {
+		return awarded, 0, fmt.Errorf("failed to award XP: %w", err)
+	}
+	if _, err := as.attempts.UpdateOne(ctx, bson.M{"_id": attempt.ID}, bson.M{"$set": bson.M{"xp_earned": xp}}); err != nil {
+		return awarded, xp, fmt.Errorf("failed to record XP on attempt: %w", err)
+	}
+
+	return awarded, xp, nil
+}
+
+// Revoke takes back the achievements and XP an attempt earned, when it is
+// invalidated. The XP is cleared from the attempt as it is taken back, so a
+// retry never takes it back twice. Revoked achievements can be earned again
+// by a later attempt
+func (as *AchievementService) Revoke(ctx context.Context, attempt *models.QuizAttempt) (int, error) {
+	if _, err := as.earned.DeleteMany(ctx, bson.M{"user_id": attempt.StudentID, "attempt_id": attempt.ID}); err != nil {
+		return 0, fmt.Errorf("failed to revoke achievements: %w", err)
+	}
+
+	var previous models.QuizAttempt
+	err := as.attempts.FindOneAndUpdate(ctx,
+		bson.M{"_id": attempt.ID, "xp_earned": bson.M{"$gt": 0}},
+		bson.M{"$unset": bson.M{"xp_earned": ""}},
+	).Decode(&previous)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear XP on attempt: %w", err)
+	}
+
+	if _, err := as.userCollection.UpdateOne(ctx, bson.M{"_id": attempt.StudentID}, bson.M{"$inc": bson.M{"xp": -previous.XPEarned}}); err != nil {
+		return 0, fmt.Errorf("failed to revoke XP: %w", err)
+	}
+	return previous.XPEarned, nil
+}
+
+// LevelForXP maps an XP total to a level. Level 1 starts at 0 XP and each
+// level takes 100 XP more than the previous one: 100 XP to reach level 2,
+// 300 for level 3, 600 for level 4, and so on
+func LevelForXP(xp int) Level {
+	if xp < 0 {
+		xp = 0
+	}
+
+	// Solve levelXPStep * n(n-1)/2 <= xp for the largest n
+	n := int((1 + math.Sqrt(1+8*float64(xp)/levelXPStep)) / 2)
+	for levelXPStep*n*(n-1)/2 > xp {
+		n--
+	}
+	for levelXPStep*(n+1)*n/2 <= xp {
+		n++
+	}
+
+	return Level{
+		Level:       n,
+		XP:          xp,
+		LevelXP:     levelXPStep * n * (n - 1) / 2,
+		NextLevelXP: levelXPStep * (n + 1) * n / 2,
+	}
+}
+
+// attemptXP is the XP awarded for completing an attempt
+func attemptXP(attempt *models.QuizAttempt) int {
+	return attemptBaseXP + int(math.Round(attempt.Percentage/10))
+}
+
+// ruleEvaluator computes the metric values of one evaluation, each at most once
+type ruleEvaluator struct {
+	service *AchievementService
+	attempt *models.QuizAttempt
+	values  map[models.AchievementRule]float64
+}
+
+func (e *ruleEvaluator) met(ctx context.Context, rule models.AchievementRule) (bool, error) {
+	key := models.AchievementRule{Metric: rule.Metric, Category: rule.Category, Difficulty: rule.Difficulty}
+	value, ok := e.values[key]
+	if !ok {
+		var err error
+		if value, err = e.value(ctx, key); err != nil {
+			return false, err
+		}
+		e.values[key] = value
+	}
+
+	if rule.Metric == models.MetricLeaderboardRank {
+		return value > 0 && value <= rule.Threshold, nil
+	}
+	return value >= rule.Threshold, nil
+}
+
+func (e *ruleEvaluator) value(ctx context.Context, rule models.AchievementRule) (float64, error) {
+	switch rule.Metric {
+	case models.MetricAttemptPercentage:
+		return e.attempt.Percentage, nil
+	case models.MetricCompletedAttempts, models.MetricPerfectScores:
+		// Leaderboard rows are exactly the completed, counted attempts
+		filter := bson.M{"student_id": e.attempt.StudentID}
+		if rule.Category != "" {
+			filter["category"] = rule.Category
+		}
+		if rule.Difficulty != "" {
+			filter["difficulty_level"] = rule.Difficulty
+		}
+		if rule.Metric == models.MetricPerfectScores {
+			filter["percentage"] = bson.M{"$gte": 100}
+		}
+		count, err := e.service.leaderboards.Collection().CountDocuments(ctx, filter)
+		if err != nil {
+			return 0, fmt.Errorf("failed to count attempts: %w", err)
+		}
+		return float64(count), nil
+	case models.MetricStreakDays:
+		streak, err := e.service.progress.CurrentStreak(ctx, e.attempt.StudentID)
+		return float64(streak), err
+	case models.MetricLeaderboardRank:
+		if e.attempt.InvalidatedAt != nil {
+			return 0, nil
+		}
+		rank, _, err := e.service.leaderboards.Rank(ctx, e.attempt.QuizID, e.attempt.TotalScore, false)
+		if err != nil {
+			return 0, fmt.Errorf("failed to rank attempt: %w", err)
+		}
+		return float64(rank), nil
+	}
+	return 0, fmt.Errorf("unknown achievement metric %q", rule.Metric)
+}
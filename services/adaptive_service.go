@@ -0,0 +1,353 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"quizmasterapi/config"
+	"quizmasterapi/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// A question needs this many answers before its difficulty is calibrated
+	MinCalibrationResponses = 10
+
+	// Ability estimates use a standard normal prior, evaluated on a grid
+	abilityGridMin  = -5.0
+	abilityGridMax  = 5.0
+	abilityGridStep = 0.05
+
+	// Calibration priors and convergence
+	calibrationThetaSD    = 1.0
+	calibrationDifficulty = 3.0 // Prior SD of difficulties, keeps items everyone got right finite
+	calibrationMaxIters   = 200
+	calibrationTolerance  = 1e-4
+)
+
+// Difficulty of questions that were never calibrated, from the quiz's level
+var defaultLevelDifficulty = map[models.DifficultyLevel]float64{
+	models.LevelEasy:   -1,
+	models.LevelMedium: 0,
+	models.LevelHard:   1,
+}
+
+// AdaptiveService calibrates question difficulties of adaptive quizzes
+type AdaptiveService struct {
+	quizCollection    *mongo.Collection
+	attemptCollection *mongo.Collection
+}
+
+// ItemResponse is a scored answer to a question of known difficulty
+type ItemResponse struct {
+	Difficulty float64
+	Correct    bool
+}
+
+// CalibrationReport describes the difficulties estimated for a quiz
+type CalibrationReport struct {
+	QuizID       primitive.ObjectID    `json:"quiz_id"`
+	Attempts     int                   `json:"attempts"`
+	CalibratedAt time.Time             `json:"calibrated_at"`
+	Questions    []QuestionCalibration `json:"questions"`
+}
+
+// QuestionCalibration is the difficulty estimated for one question
+type QuestionCalibration struct {
+	QuestionID     primitive.ObjectID `json:"question_id"`
+	Order          int                `json:"order"`
+	Responses      int                `json:"responses"`
+	PercentCorrect float64            `json:"percent_correct"`
+	Difficulty     float64            `json:"difficulty"` // Logits, higher is harder
+	Calibrated     bool               `json:"calibrated"` // False when the question had too few answers and keeps its previous difficulty
+}
+
+// NewAdaptiveService creates a new adaptive service
+func NewAdaptiveService() *AdaptiveService {
+	return &AdaptiveService{
+		quizCollection:    config.GetCollection("quizzes"),
+		attemptCollection: config.GetCollection("attempts"),
+	}
+}
+
+// RaschProbability is the probability that a student of ability theta
+// answers a question of difficulty b correctly
+func RaschProbability(theta, b float64) float64 {
+	return 1 / (1 + math.Exp(b-theta))
+}
+
+// EstimateAbility returns the expected a posteriori ability given the
+// responses, with a standard normal prior
+func EstimateAbility(responses []ItemResponse) models.AbilityEstimate {
+	var weightSum, thetaSum, squareSum float64
+	for theta := abilityGridMin; theta <= abilityGridMax+1e-9; theta += abilityGridStep {
+		logLikelihood := -theta * theta / 2
+		for _, response := range responses {
+			p := RaschProbability(theta, response.Difficulty)
+			if response.Correct {
+				logLikelihood += math.Log(p)
+			} else {
+				logLikelihood += math.Log(1 - p)
+			}
+		}
+		weight := math.Exp(logLikelihood)
+		weightSum += weight
+		thetaSum += weight * theta
+		squareSum += weight * theta * theta
+	}
+
+	theta := thetaSum / weightSum
+	return models.AbilityEstimate{
+		Theta:         round2(theta),
+		StandardError: round2(math.Sqrt(math.Max(0, squareSum/weightSum-theta*theta))),
+		Answers:       len(responses),
+	}
+}
+
+// QuestionDifficulty returns the calibrated difficulty of a question, or a
+// default from the quiz's difficulty level
+func QuestionDifficulty(quiz *models.Quiz, question *models.Question) float64 {
+	if question.IRTDifficulty != nil {
+		return *question.IRTDifficulty
+	}
+	return defaultLevelDifficulty[quiz.DifficultyLevel]
+}
+
+// AdaptiveQuestionCount returns the number of questions served per adaptive attempt
+func AdaptiveQuestionCount(quiz *models.Quiz) int {
+	if quiz.Adaptive == nil {
+		return len(quiz.Questions)
+	}
+	return min(quiz.Adaptive.QuestionCount, len(quiz.Questions))
+}
+
+// SelectNextQuestion picks the unserved question with the most information
+// at the ability theta, which under the Rasch model is the one whose
+// difficulty is closest to theta. It returns nil once the pool is exhausted
+func SelectNextQuestion(quiz *models.Quiz, served []primitive.ObjectID, theta float64) *models.Question {
+	used := make(map[primitive.ObjectID]bool, len(served))
+	for _, id := range served {
+		used[id] = true
+	}
+
+	var best *models.Question
+	bestInformation := -1.0
+	for i := range quiz.Questions {
+		question := &quiz.Questions[i]
+		if used[question.ID] {
+			continue
+		}
+		p := RaschProbability(theta, QuestionDifficulty(quiz, question))
+		if information := p * (1 - p); information > bestInformation {
+			best, bestInformation = question, information
+		}
+	}
+	return best
+}
+
+// AttemptAbility estimates the ability of a student from the answered
+// questions of an attempt. Skipped questions are left out
+func AttemptAbility(quiz *models.Quiz, answers []models.Answer) models.AbilityEstimate {
+	questions := questionsByID(quiz)
+	responses := make([]ItemResponse, 0, len(answers))
+	for _, answer := range answers {
+		question, ok := questions[answer.QuestionID]
+		if !ok || answer.Skipped {
+			continue
+		}
+		responses = append(responses, ItemResponse{Difficulty: QuestionDifficulty(quiz, question), Correct: answer.IsCorrect})
+	}
+	return EstimateAbility(responses)
+}
+
+// FillAdaptiveQuestions serves the questions an adaptive attempt still had
+// to see when it is completed early, so they count as unanswered
+func FillAdaptiveQuestions(quiz *models.Quiz, attempt *models.QuizAttempt) {
+	theta := 0.0
+	if attempt.Ability != nil {
+		theta = attempt.Ability.Theta
+	}
+	for len(attempt.QuestionIDs) < AdaptiveQuestionCount(quiz) {
+		question := SelectNextQuestion(quiz, attempt.QuestionIDs, theta)
+		if question == nil {
+			return
+		}
+		attempt.QuestionIDs = append(attempt.QuestionIDs, question.ID)
+	}
+}
+
+// AttemptQuiz returns the quiz as seen by an attempt: adaptive attempts only
+// see the questions served to them, in the order they were served
+func AttemptQuiz(quiz *models.Quiz, attempt *models.QuizAttempt) *models.Quiz {
+	if !attempt.Adaptive {
+		return quiz
+	}
+
+	questions := questionsByID(quiz)
+	served := *quiz
+	served.Questions = make([]models.Question, 0, len(attempt.QuestionIDs))
+	for _, id := range attempt.QuestionIDs {
+		if question, ok := questions[id]; ok {
+			served.Questions = append(served.Questions, *question)
+		}
+	}
+	return &served
+}
+
+// Calibrate estimates the Rasch difficulty of every question of a quiz from
+// the answers of its completed ranked attempts and stores it on the questions.
+// Questions with too few answers keep their previous difficulty
+func (as *AdaptiveService) Calibrate(ctx context.Context, quiz *models.Quiz) (*CalibrationReport, error) {
+	index := make(map[primitive.ObjectID]int, len(quiz.Questions))
+	for i, question := range quiz.Questions {
+		index[question.ID] = i
+	}
+
+	filter := bson.M{
+		"quiz_id":        quiz.ID,
+		"completed_at":   bson.M{"$ne": nil},
+		"invalidated_at": bson.M{"$exists": false},
+		"mode":           bson.M{"$ne": models.ModePractice},
+	}
+	cursor, err := as.attemptCollection.Find(ctx, filter, options.Find().SetProjection(bson.M{"answers": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch attempts: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	// One row per attempt: 1 correct, 0 incorrect, -1 not answered
+	responses := [][]int8{}
+	for cursor.Next(ctx) {
+		var attempt struct {
+			Answers []models.Answer `bson:"answers"`
+		}
+		if err := cursor.Decode(&attempt); err != nil {
+			return nil, fmt.Errorf("failed to decode attempt: %w", err)
+		}
+
+		row := make([]int8, len(quiz.Questions))
+		for i := range row {
+			row[i] = -1
+		}
+		for _, answer := range attempt.Answers {
+			if i, ok := index[answer.QuestionID]; ok && !answer.Skipped {
+				row[i] = 0
+				if answer.IsCorrect {
+					row[i] = 1
+				}
+			}
+		}
+		responses = append(responses, row)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch attempts: %w", err)
+	}
+
+	difficulties := CalibrateRasch(len(quiz.Questions), responses)
+
+	now := time.Now()
+	report := &CalibrationReport{
+		QuizID:       quiz.ID,
+		Attempts:     len(responses),
+		CalibratedAt: now,
+		Questions:    make([]QuestionCalibration, len(quiz.Questions)),
+	}
+	set := bson.M{"adaptive.calibrated_at": now, "updated_at": now}
+	arrayFilters := []interface{}{}
+	for i := range quiz.Questions {
+		question := &quiz.Questions[i]
+		answered, correct := 0, 0
+		for _, row := range responses {
+			if row[i] >= 0 {
+				answered++
+				correct += int(row[i])
+			}
+		}
+
+		calibration := QuestionCalibration{
+			QuestionID: question.ID,
+			Order:      question.Order,
+			Responses:  answered,
+			Difficulty: round2(QuestionDifficulty(quiz, question)),
+		}
+		if answered > 0 {
+			calibration.PercentCorrect = round2(float64(correct) / float64(answered) * 100)
+		}
+		if answered >= MinCalibrationResponses {
+			calibration.Difficulty = round2(difficulties[i])
+			calibration.Calibrated = true
+			identifier := fmt.Sprintf("q%d", i)
+			set[fmt.Sprintf("questions.$[%s].irt_difficulty", identifier)] = calibration.Difficulty
+			arrayFilters = append(arrayFilters, bson.M{identifier + "._id": question.ID})
+		}
+		report.Questions[i] = calibration
+	}
+
+	opts := options.Update()
+	if len(arrayFilters) > 0 {
+		opts.SetArrayFilters(options.ArrayFilters{Filters: arrayFilters})
+	}
+	if _, err := as.quizCollection.UpdateOne(ctx, bson.M{"_id": quiz.ID}, bson.M{"$set": set}, opts); err != nil {
+		return nil, fmt.Errorf("failed to save calibration: %w", err)
+	}
+	return report, nil
+}
+
+// CalibrateRasch estimates item difficulties from a persons × items matrix
+// of responses (1 correct, 0 incorrect, -1 missing) by joint maximum a
+// posteriori estimation. Normal priors on abilities and difficulties keep
+// the estimates finite for perfect or zero scores and fix the scale
+func CalibrateRasch(items int, responses [][]int8) []float64 {
+	difficulties := make([]float64, items)
+	thetas := make([]float64, len(responses))
+
+	for iter := 0; iter < calibrationMaxIters; iter++ {
+		change := 0.0
+
+		for p, row := range responses {
+			gradient, information := -thetas[p]/(calibrationThetaSD*calibrationThetaSD), 1/(calibrationThetaSD*calibrationThetaSD)
+			for i, x := range row {
+				if x < 0 {
+					continue
+				}
+				prob := RaschProbability(thetas[p], difficulties[i])
+				gradient += float64(x) - prob
+				information += prob * (1 - prob)
+			}
+			step := clampStep(gradient / information)
+			thetas[p] += step
+			change = math.Max(change, math.Abs(step))
+		}
+
+		for i := 0; i < items; i++ {
+			gradient, information := -difficulties[i]/(calibrationDifficulty*calibrationDifficulty), 1/(calibrationDifficulty*calibrationDifficulty)
+			for p, row := range responses {
+				if row[i] < 0 {
+					continue
+				}
+				prob := RaschProbability(thetas[p], difficulties[i])
+				gradient += prob - float64(row[i])
+				information += prob * (1 - prob)
+			}
+			step := clampStep(gradient / information)
+			difficulties[i] += step
+			change = math.Max(change, math.Abs(step))
+		}
+
+		if change < calibrationTolerance {
+			break
+		}
+	}
+	return difficulties
+}
+
+// clampStep bounds a Newton step to one logit to keep early iterations stable
+func clampStep(step float64) float64 {
+	return math.Max(-1, math.Min(1, step))
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"quizmasterapi/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Simulation of the adaptive engine against students with known Rasch
+// parameters. The seed is fixed so that the checks are reproducible
+const (
+	simulatedStudents  = 500
+	simulatedPool      = 60
+	simulatedLength    = 15
+	simulationSeed     = 1
+	maxCalibrationRMSE = 0.3 // Largest accepted difficulty recovery error, in logits
+)
+
+// simulatedPopulation draws question difficulties and student abilities
+func simulatedPopulation(rng *rand.Rand) (difficulties, abilities []float64) {
+	difficulties = make([]float64, simulatedPool)
+	for i := range difficulties {
+		difficulties[i] = rng.NormFloat64() * 1.2
+	}
+	abilities = make([]float64, simulatedStudents)
+	for s := range abilities {
+		abilities[s] = rng.NormFloat64()
+	}
+	return difficulties, abilities
+}
+
+// simulatedAnswer simulates a student of ability theta answering a question of difficulty b
+func simulatedAnswer(rng *rand.Rand, theta, b float64) bool {
+	return rng.Float64() < RaschProbability(theta, b)
+}
+
+func rmse(estimates, truth []float64) float64 {
+	sum := 0.0
+	for i := range estimates {
+		sum += (estimates[i] - truth[i]) * (estimates[i] - truth[i])
+	}
+	return math.Sqrt(sum / float64(len(estimates)))
+}
+
+func correlation(x, y []float64) float64 {
+	var meanX, meanY float64
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX /= float64(len(x))
+	meanY /= float64(len(y))
+
+	var cov, varX, varY float64
+	for i := range x {
+		cov += (x[i] - meanX) * (y[i] - meanY)
+		varX += (x[i] - meanX) * (x[i] - meanX)
+		varY += (y[i] - meanY) * (y[i] - meanY)
+	}
+	return cov / math.Sqrt(varX*varY)
+}
+
+// TestCalibrateRaschRecoversDifficulties calibrates the pool from every
+// simulated student answering every question
+func TestCalibrateRaschRecoversDifficulties(t *testing.T) {
+	rng := rand.New(rand.NewSource(simulationSeed))
+	difficulties, abilities := simulatedPopulation(rng)
+
+	responses := make([][]int8, len(abilities))
+	for s, theta := range abilities {
+		responses[s] = make([]int8, len(difficulties))
+		for i, b := range difficulties {
+			if simulatedAnswer(rng, theta, b) {
+				responses[s][i] = 1
+			}
+		}
+	}
+
+	estimated := CalibrateRasch(len(difficulties), responses)
+	calibrationRMSE := rmse(estimated, difficulties)
+	t.Logf("Calibration: %d students × %d questions, RMSE %.3f, correlation %.3f",
+		len(abilities), len(difficulties), calibrationRMSE, correlation(estimated, difficulties))
+	if calibrationRMSE > maxCalibrationRMSE {
+		t.Fatalf("calibration RMSE %.3f above %.3f", calibrationRMSE, maxCalibrationRMSE)
+	}
+}
+
+// TestAdaptiveSelectionBeatsRandomSelection estimates the abilities of the
+// simulated students from adaptive and from fixed random question selections
+// of the same length, using the true difficulties
+func TestAdaptiveSelectionBeatsRandomSelection(t *testing.T) {
+	rng := rand.New(rand.NewSource(simulationSeed))
+	difficulties, abilities := simulatedPopulation(rng)
+
+	quiz := &models.Quiz{
+		DifficultyLevel: models.LevelMedium,
+		Adaptive:        &models.AdaptiveSettings{QuestionCount: simulatedLength},
+		Questions:       make([]models.Question, len(difficulties)),
+	}
+	difficulty := make(map[primitive.ObjectID]float64, len(difficulties))
+	for i := range quiz.Questions {
+		b := difficulties[i]
+		quiz.Questions[i] = models.Question{ID: primitive.NewObjectID(), Order: i, IRTDifficulty: &b}
+		difficulty[quiz.Questions[i].ID] = b
+	}
+
+	adaptiveEstimates := make([]float64, len(abilities))
+	randomEstimates := make([]float64, len(abilities))
+	for s, theta := range abilities {
+		served := []primitive.ObjectID{}
+		adaptive := []ItemResponse{}
+		estimate := EstimateAbility(nil)
+		for len(served) < simulatedLength {
+			question := SelectNextQuestion(quiz, served, estimate.Theta)
+			served = append(served, question.ID)
+			b := difficulty[question.ID]
+			adaptive = append(adaptive, ItemResponse{Difficulty: b, Correct: simulatedAnswer(rng, theta, b)})
+			estimate = EstimateAbility(adaptive)
+		}
+		adaptiveEstimates[s] = estimate.Theta
+
+		random := make([]ItemResponse, 0, simulatedLength)
+		for _, i := range rng.Perm(len(difficulties))[:simulatedLength] {
+			b := difficulties[i]
+			random = append(random, ItemResponse{Difficulty: b, Correct: simulatedAnswer(rng, theta, b)})
+		}
+		randomEstimates[s] = EstimateAbility(random).Theta
+	}
+
+	adaptiveRMSE := rmse(adaptiveEstimates, abilities)
+	randomRMSE := rmse(randomEstimates, abilities)
+	t.Logf("Ability after %d questions: adaptive RMSE %.3f, random RMSE %.3f", simulatedLength, adaptiveRMSE, randomRMSE)
+	if adaptiveRMSE > randomRMSE {
+		t.Fatalf("adaptive selection (RMSE %.3f) is less precise than random selection (RMSE %.3f)", adaptiveRMSE, randomRMSE)
+	}
+}
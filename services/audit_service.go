@@ -0,0 +1,58 @@
+// Package services provides business logic services
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"quizmasterapi/config"
+	"quizmasterapi/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditService records changes made by professors to student data
+type AuditService struct {
+	collection *mongo.Collection
+}
+
+// NewAuditService creates a new audit service
+func NewAuditService() *AuditService {
+	return &AuditService{
+		collection: config.GetCollection("audit_logs"),
+	}
+}
+
+// Record stores an audit entry, filling in its ID and timestamp
+func (as *AuditService) Record(ctx context.Context, entry models.AuditLog) error {
+	entry.ID = primitive.NewObjectID()
+	entry.CreatedAt = time.Now()
+
+	if _, err := as.collection.InsertOne(ctx, entry); err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	return nil
+}
+
+// ListForEntity returns the audit entries of an entity, oldest first
+func (as *AuditService) ListForEntity(ctx context.Context, entityType string, entityID primitive.ObjectID) ([]models.AuditLog, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := as.collection.Find(ctx, bson.M{
+		"entity_type": entityType,
+		"entity_id":   entityID,
+	}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch audit entries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	entries := make([]models.AuditLog, 0)
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode audit entries: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,348 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"quizmasterapi/config"
+	"quizmasterapi/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Reasons recorded on finished challenges
+const (
+	ChallengeReasonScore     = "score"
+	ChallengeReasonTimeTaken = "time_taken"
+	ChallengeReasonTie       = "tie"
+	ChallengeReasonForfeit   = "forfeit"
+	// A student's attempt was invalidated, e.g. for cheating
+	ChallengeReasonInvalidated = "invalidated"
+)
+
+// Errors returned when an attempt cannot be linked to a challenge
+var (
+	ErrChallengeNotFound    = errors.New("challenge not found")
+	ErrChallengeNotActive   = errors.New("challenge is not active")
+	ErrChallengeWrongQuiz   = errors.New("challenge is for another quiz")
+	ErrChallengeAlreadyUsed = errors.New("you already have an attempt for this challenge")
+)
+
+// ChallengeService links attempts to head-to-head challenges and settles them
+type ChallengeService struct {
+	collection        *mongo.Collection
+	attemptCollection *mongo.Collection
+}
+
+// NewChallengeService creates a new challenge service
+func NewChallengeService() *ChallengeService {
+	return &ChallengeService{
+		collection:        config.GetCollection("challenges"),
+		attemptCollection: config.GetCollection("attempts"),
+	}
+}
+
+// ClaimAttempt records attemptID as the student's side of an active challenge.
+// Each student gets a single attempt per challenge
+func (cs *ChallengeService) ClaimAttempt(ctx context.Context, challengeID, quizID, studentID, attemptID primitive.ObjectID) error {
+	var challenge models.Challenge
+	err := cs.collection.FindOne(ctx, bson.M{
+		"_id": challengeID,
+		"$or": bson.A{
+			bson.M{"challenger_id": studentID},
+			bson.M{"opponent_id": studentID},
+		},
+	}).Decode(&challenge)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrChallengeNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load challenge: %w", err)
+	}
+
+	if challenge.QuizID != quizID {
+		return ErrChallengeWrongQuiz
+	}
+	if challenge.Status != models.ChallengeActive || !time.Now().Before(challenge.ExpiresAt) {
+		return ErrChallengeNotActive
+	}
+
+	field := "opponent_attempt_id"
+	if challenge.ChallengerID == studentID {
+		field = "challenger_attempt_id"
+	}
+
+	result, err := cs.collection.UpdateOne(ctx, bson.M{
+		"_id":        challengeID,
+		"status":     models.ChallengeActive,
+		"quiz_id":    quizID,
+		field:        bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": time.Now()},
+	}, bson.M{"$set": bson.M{field: attemptID}})
+	if err != nil {
+		return fmt.Errorf("failed to link attempt to challenge: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrChallengeAlreadyUsed
+	}
+	return nil
+}
+
+// Resolve settles an active challenge once both of its attempts are completed.
+// The higher score wins; equal scores go to the faster attempt, and a draw is
+// recorded only when both the score and the time taken are equal. A student
+// whose attempt was invalidated forfeits right away
+func (cs *ChallengeService) Resolve(ctx context.Context, challengeID primitive.ObjectID) error {
+	var challenge models.Challenge
+	err := cs.collection.FindOne(ctx, bson.M{"_id": challengeID, "status": models.ChallengeActive}).Decode(&challenge)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load challenge: %w", err)
+	}
+
+	challengerAttempt, opponentAttempt, err := cs.challengeAttempts(ctx, &challenge)
+	if err != nil {
+		return err
+	}
+
+	set := challengeSettlement(&challenge, challengerAttempt, opponentAttempt, time.Now())
+	if set == nil {
+		return nil
+	}
+	return cs.finish(ctx, challengeID, set)
+}
+
+// Resettle settles a challenge again after one of its attempts was
+// invalidated. An active challenge is settled right away, and a finished one
+// that the invalidated attempt took part in is settled again so that the
+// attempt can't win it
+func (cs *ChallengeService) Resettle(ctx context.Context, challengeID primitive.ObjectID) error {
+	var challenge models.Challenge
+	err := cs.collection.FindOne(ctx, bson.M{"_id": challengeID}).Decode(&challenge)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load challenge: %w", err)
+	}
+
+	switch challenge.Status {
+	case models.ChallengeActive:
+		return cs.Resolve(ctx, challengeID)
+	case models.ChallengeFinished:
+	default:
+		return nil
+	}
+
+	challengerAttempt, opponentAttempt, err := cs.challengeAttempts(ctx, &challenge)
+	if err != nil {
+		return err
+	}
+
+	set := challengeSettlement(&challenge, challengerAttempt, opponentAttempt, time.Now())
+	if set == nil || set["result_reason"] != ChallengeReasonInvalidated {
+		return nil
+	}
+	if challenge.ResultReason == ChallengeReasonInvalidated && set["status"] == challenge.Status {
+		return nil
+	}
+	delete(set, "finished_at")
+	update := bson.M{"$set": set}
+	if _, ok := set["winner_id"]; !ok {
+		update["$unset"] = bson.M{"winner_id": ""}
+	}
+
+	// Guarded by the previous result so that concurrent settlements apply once
+	filter := bson.M{"_id": challengeID, "status": challenge.Status, "result_reason": challenge.ResultReason}
+	if _, err := cs.collection.UpdateOne(ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to settle challenge: %w", err)
+	}
+	return nil
+}
+
+// challengeSettlement returns the fields that finish a challenge given its
+// completed attempts, or nil if it can't be settled yet. An invalidated
+// attempt forfeits even if the other student has not completed theirs, and
+// the challenge expires without a winner if both were invalidated
+func challengeSettlement(challenge *models.Challenge, challengerAttempt, opponentAttempt *models.QuizAttempt, now time.Time) bson.M {
+	challengerVoid := challengerAttempt != nil && challengerAttempt.InvalidatedAt != nil
+	opponentVoid := opponentAttempt != nil && opponentAttempt.InvalidatedAt != nil
+
+	set := bson.M{"status": models.ChallengeFinished, "finished_at": now}
+	if challengerAttempt != nil {
+		set["challenger_result"] = newChallengeResult(challengerAttempt)
+	}
+	if opponentAttempt != nil {
+		set["opponent_result"] = newChallengeResult(opponentAttempt)
+	}
+
+	switch {
+	case challengerVoid && opponentVoid:
+		set["status"] = models.ChallengeExpired
+		set["result_reason"] = ChallengeReasonInvalidated
+	case challengerVoid:
+		set["result_reason"] = ChallengeReasonInvalidated
+		set["winner_id"] = challenge.OpponentID
+	case opponentVoid:
+		set["result_reason"] = ChallengeReasonInvalidated
+		set["winner_id"] = challenge.ChallengerID
+	case challengerAttempt == nil || opponentAttempt == nil:
+		return nil
+	case challengerAttempt.TotalScore != opponentAttempt.TotalScore:
+		set["result_reason"] = ChallengeReasonScore
+		set["winner_id"] = challenge.OpponentID
+		if challengerAttempt.TotalScore > opponentAttempt.TotalScore {
+			set["winner_id"] = challenge.ChallengerID
+		}
+	case challengerAttempt.TimeTaken != opponentAttempt.TimeTaken:
+		set["result_reason"] = ChallengeReasonTimeTaken
+		set["winner_id"] = challenge.OpponentID
+		if challengerAttempt.TimeTaken < opponentAttempt.TimeTaken {
+			set["winner_id"] = challenge.ChallengerID
+		}
+	default:
+		set["result_reason"] = ChallengeReasonTie
+	}
+	return set
+}
+
+// challengeAttempts fetches the completed attempts of both sides of a
+// challenge; a side is nil until its attempt is completed
+func (cs *ChallengeService) challengeAttempts(ctx context.Context, challenge *models.Challenge) (*models.QuizAttempt, *models.QuizAttempt, error) {
+	var challengerAttempt, opponentAttempt *models.QuizAttempt
+	var err error
+	if challenge.ChallengerAttemptID != nil {
+		if challengerAttempt, err = cs.completedAttempt(ctx, *challenge.ChallengerAttemptID); err != nil {
+			return nil, nil, err
+		}
+	}
+	if challenge.OpponentAttemptID != nil {
+		if opponentAttempt, err = cs.completedAttempt(ctx, *challenge.OpponentAttemptID); err != nil {
+			return nil, nil, err
+		}
+	}
+	return challengerAttempt, opponentAttempt, nil
+}
+
+// ExpireDue closes the student's challenges whose deadline has passed. Pending
+// challenges expire; an active challenge is won by forfeit if only one student
+// completed their attempt, and otherwise expires
+func (cs *ChallengeService) ExpireDue(ctx context.Context, studentID primitive.ObjectID) error {
+	now := time.Now()
+	involved := bson.A{
+		bson.M{"challenger_id": studentID},
+		bson.M{"opponent_id": studentID},
+	}
+
+	_, err := cs.collection.UpdateMany(ctx, bson.M{
+		"$or":        involved,
+		"status":     models.ChallengePending,
+		"expires_at": bson.M{"$lte": now},
+	}, bson.M{"$set": bson.M{"status": models.ChallengeExpired, "finished_at": now}})
+	if err != nil {
+		return fmt.Errorf("failed to expire challenges: %w", err)
+	}
+
+	cursor, err := cs.collection.Find(ctx, bson.M{
+		"$or":        involved,
+		"status":     models.ChallengeActive,
+		"expires_at": bson.M{"$lte": now},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load expired challenges: %w", err)
+	}
+
+	var challenges []models.Challenge
+	if err := cursor.All(ctx, &challenges); err != nil {
+		return fmt.Errorf("failed to load expired challenges: %w", err)
+	}
+
+	for _, challenge := range challenges {
+		// Both attempts may have completed just before the deadline
+		if err := cs.Resolve(ctx, challenge.ID); err != nil {
+			return err
+		}
+
+		challengerAttempt, opponentAttempt, err := cs.challengeAttempts(ctx, &challenge)
+		if err != nil {
+			return err
+		}
+
+		set := bson.M{"status": models.ChallengeExpired, "finished_at": now}
+		switch {
+		case challengerAttempt != nil && opponentAttempt == nil:
+			set["status"] = models.ChallengeFinished
+			set["winner_id"] = challenge.ChallengerID
+			set["result_reason"] = ChallengeReasonForfeit
+			set["challenger_result"] = newChallengeResult(challengerAttempt)
+		case opponentAttempt != nil && challengerAttempt == nil:
+			set["status"] = models.ChallengeFinished
+			set["winner_id"] = challenge.OpponentID
+			set["result_reason"] = ChallengeReasonForfeit
+			set["opponent_result"] = newChallengeResult(opponentAttempt)
+		}
+
+		if err := cs.finish(ctx, challenge.ID, set); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ChallengeOutcomeFor returns the result of a finished challenge from a student's point of view
+func ChallengeOutcomeFor(challenge *models.Challenge, studentID primitive.ObjectID) models.ChallengeOutcome {
+	if challenge.Status != models.ChallengeFinished {
+		return ""
+	}
+	if challenge.WinnerID == nil {
+		return models.OutcomeDraw
+	}
+	if *challenge.WinnerID == studentID {
+		return models.OutcomeWin
+	}
+	return models.OutcomeLoss
+}
+
+// finish closes a challenge that is still active; it is a no-op if another
+// request closed it first
+func (cs *ChallengeService) finish(ctx context.Context, challengeID primitive.ObjectID, set bson.M) error {
+	_, err := cs.collection.UpdateOne(ctx, bson.M{
+		"_id":    challengeID,
+		"status": models.ChallengeActive,
+	}, bson.M{"$set": set})
+	if err != nil {
+		return fmt.Errorf("failed to finish challenge: %w", err)
+	}
+	return nil
+}
+
+// completedAttempt fetches an attempt, or returns nil if it is not completed yet
+func (cs *ChallengeService) completedAttempt(ctx context.Context, attemptID primitive.ObjectID) (*models.QuizAttempt, error) {
+	var attempt models.QuizAttempt
+	err := cs.attemptCollection.FindOne(ctx, bson.M{
+		"_id":          attemptID,
+		"completed_at": bson.M{"$exists": true},
+	}).Decode(&attempt)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load challenge attempt: %w", err)
+	}
+	return &attempt, nil
+}
+
+func newChallengeResult(attempt *models.QuizAttempt) models.ChallengeResult {
+	return models.ChallengeResult{
+		Score:      attempt.TotalScore,
+		Percentage: attempt.Percentage,
+		TimeTaken:  attempt.TimeTaken,
+	}
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"quizmasterapi/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestChallengeSettlement(t *testing.T) {
+	challenge := &models.Challenge{ChallengerID: primitive.NewObjectID(), OpponentID: primitive.NewObjectID()}
+	now := time.Now()
+	attempt := func(score float64, timeTaken int, invalidated bool) *models.QuizAttempt {
+		a := &models.QuizAttempt{TotalScore: score, TimeTaken: timeTaken, CompletedAt: &now}
+		if invalidated {
+			a.InvalidatedAt = &now
+		}
+		return a
+	}
+
+	tests := []struct {
+		name                 string
+		challenger, opponent *models.QuizAttempt
+		settled              bool
+		status               models.ChallengeStatus
+		reason               string
+		winner               *primitive.ObjectID
+	}{
+		{"waiting for the opponent", attempt(8, 60, false), nil, false, "", "", nil},
+		{"higher score wins", attempt(8, 60, false), attempt(6, 30, false), true, models.ChallengeFinished, ChallengeReasonScore, &challenge.ChallengerID},
+		{"faster attempt wins a tie", attempt(8, 60, false), attempt(8, 30, false), true, models.ChallengeFinished, ChallengeReasonTimeTaken, &challenge.OpponentID},
+		{"equal score and time draw", attempt(8, 60, false), attempt(8, 60, false), true, models.ChallengeFinished, ChallengeReasonTie, nil},
+		{"invalidated winner forfeits", attempt(10, 10, true), attempt(2, 90, false), true, models.ChallengeFinished, ChallengeReasonInvalidated, &challenge.OpponentID},
+		{"invalidated side forfeits before the other completes", nil, attempt(5, 60, true), true, models.ChallengeFinished, ChallengeReasonInvalidated, &challenge.ChallengerID},
+		{"both invalidated expire", attempt(5, 60, true), attempt(7, 60, true), true, models.ChallengeExpired, ChallengeReasonInvalidated, nil},
+	}
+
+	for _, tc := range tests {
+		set := challengeSettlement(challenge, tc.challenger, tc.opponent, now)
+		if !tc.settled {
+			if set != nil {
+				t.Errorf("%s: settled with %v, want unsettled", tc.name, set)
+			}
+			continue
+		}
+		if set == nil {
+			t.Errorf("%s: unsettled", tc.name)
+			continue
+		}
+		if set["status"] != tc.status || set["result_reason"] != tc.reason {
+			t.Errorf("%s: status %v, reason %v, want %v, %v", tc.name, set["status"], set["result_reason"], tc.status, tc.reason)
+		}
+		winner, ok := set["winner_id"].(primitive.ObjectID)
+		if tc.winner == nil && ok || tc.winner != nil && (!ok || winner != *tc.winner) {
+			t.Errorf("%s: winner %v, want %v", tc.name, set["winner_id"], tc.winner)
+		}
+	}
+}
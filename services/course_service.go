@@ -2,22 +2,87 @@
 package services
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"math/rand/v2"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
 	"quizmasterapi/config"
+	"quizmasterapi/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// Largest course API response read
+	maxCourseResponseBytes = 1 << 20
+	// How far a webhook timestamp may be from now, limiting replays
+	WebhookTolerance = 5 * time.Minute
+)
+
+var (
+	// ErrCourseAPIUnavailable is returned when the course API cannot be reached,
+	// keeps failing, or the circuit breaker refuses calls
+	ErrCourseAPIUnavailable = errors.New("course API unavailable")
+	// ErrWebhookDisabled is returned when no webhook secret is configured
+	ErrWebhookDisabled = errors.New("completion webhook is not configured")
+	// ErrWebhookSignature is returned when a webhook signature or timestamp is invalid
+	ErrWebhookSignature = errors.New("invalid webhook signature")
 )
 
-// CourseService handles external course API integration
+// CourseService handles external course API integration. Known results are
+// kept in a completion store so the API is only asked about courses not
+// known to be completed. Requests time out, failed requests are
+// retried with jittered backoff, and a circuit breaker stops calling the API
+// after repeated failures. It is safe for concurrent use and should be shared
+// so the breaker sees every call
 type CourseService struct {
 	BaseURL string
+	client  *http.Client
+	options config.CourseAPIConfig
+	breaker *circuitBreaker
+	store   CompletionStore
 }
 
-// NewCourseService creates a new course service
-func NewCourseService() *CourseService {
+// CompletionStore keeps the known course completion results, one per student
+// and course
+type CompletionStore interface {
+	// Find returns the result for a student and course, or nil when there is none
+	Find(ctx context.Context, studentID primitive.ObjectID, courseID string) (*models.CourseCompletion, error)
+	// Save stores a result from the course API or a webhook. It never replaces
+	// a manual result, and a course not completed never replaces a completion.
+	// It reports whether the result was stored
+	Save(ctx context.Context, completion models.CourseCompletion) (bool, error)
+	// SetManual stores a manual result over any other and returns it
+	SetManual(ctx context.Context, completion models.CourseCompletion) (*models.CourseCompletion, error)
+}
+
+// NewCourseService creates a new course service. A nil client gets one with
+// the configured timeout
+func NewCourseService(baseURL string, client *http.Client, options config.CourseAPIConfig, store CompletionStore) *CourseService {
+	if client == nil {
+		client = &http.Client{Timeout: options.Timeout}
+	}
 	return &CourseService{
-		BaseURL: config.AppConfig.ExternalCourseAPI,
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		client:  client,
+		options: options,
+		breaker: newCircuitBreaker(options.BreakerThreshold, options.BreakerCooldown),
+		store:   store,
 	}
 }
 
@@ -29,37 +94,326 @@ type CourseCompletionResponse struct {
 	CompletedAt string `json:"completed_at,omitempty"`
 }
 
-// CheckCourseCompletion checks if a student has completed a course
-func (cs *CourseService) CheckCourseCompletion(studentID, courseID string) (bool, error) {
-	// Build URL for external API
-	url := fmt.Sprintf("%s/courses/%s/students/%s/completion", cs.BaseURL, courseID, studentID)
+// CheckCourseCompletion checks if a student has completed a course, from the
+// stored result when there is one and otherwise with the course API, whose
+// answer is stored. While the circuit breaker is open it fails with
+// ErrCourseAPIUnavailable, or reports the course as completed when the
+// service is configured to fail open
+func (cs *CourseService) CheckCourseCompletion(ctx context.Context, studentID primitive.ObjectID, courseID string) (bool, error) {
+	now := time.Now()
+	known, err := cs.storedCompletion(ctx, studentID, courseID, now)
+	if err != nil {
+		return false, err
+	}
+	if known != nil {
+		return known.Completed, nil
+	}
+
+	if !cs.breaker.Allow() {
+		if cs.options.FailOpen {
+			log.Printf("CourseService: Circuit open, allowing course %s for student %s", courseID, studentID.Hex())
+			return true, nil
+		}
+		return false, fmt.Errorf("%w: circuit breaker open", ErrCourseAPIUnavailable)
+	}
 
-	// Make HTTP GET request
-	resp, err := http.Get(url)
+	completed, err := cs.callAPI(ctx, studentID.Hex(), courseID)
 	if err != nil {
-		return false, fmt.Errorf("failed to call course API: %w", err)
+		return false, err
 	}
-	defer resp.Body.Close()
+	if err := cs.storeAPIResult(ctx, studentID, courseID, completed, now); err != nil {
+		// The answer is still good, the API will just be asked again next time
+		log.Printf("CourseService: %v (course: %s, student: %s)", err, courseID, studentID.Hex())
+	}
+	return completed, nil
+}
+
+// callAPI asks the course API, recording the outcome in the circuit breaker
+func (cs *CourseService) callAPI(ctx context.Context, studentID, courseID string) (bool, error) {
+	endpoint := fmt.Sprintf("%s/courses/%s/students/%s/completion",
+		cs.BaseURL, url.PathEscape(courseID), url.PathEscape(studentID))
 
-	// Check status code
-	if resp.StatusCode == http.StatusNotFound {
-		return false, nil // Student hasn't enrolled or completed
+	completed, retryable, err := cs.fetchWithRetries(ctx, endpoint)
+	switch {
+	case err == nil:
+		cs.breaker.Success()
+		return completed, nil
+	case errors.Is(ctx.Err(), context.Canceled):
+		// The caller gave up, which says nothing about the API
+		cs.breaker.Abandon()
+		return false, err
+	case retryable || ctx.Err() != nil:
+		cs.breaker.Failure()
+		return false, fmt.Errorf("%w: %v", ErrCourseAPIUnavailable, err)
+	default:
+		// The API answered, just not with something usable
+		cs.breaker.Success()
+		return false, err
 	}
+}
+
+// fetchWithRetries calls the API until it answers, a non retryable error
+// occurs or the retries run out
+func (cs *CourseService) fetchWithRetries(ctx context.Context, endpoint string) (bool, bool, error) {
+	for retry := 0; ; retry++ {
+		completed, retryable, err := cs.fetch(ctx, endpoint)
+		if err == nil || !retryable || retry >= cs.options.MaxRetries {
+			return completed, retryable, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, false, ctx.Err()
+		case <-time.After(cs.backoff(retry)):
+		}
+	}
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return false, fmt.Errorf("course API returned status %d", resp.StatusCode)
+// backoff returns the delay before a retry: the base backoff doubled on each
+// retry, half of it randomized so concurrent callers spread out
+func (cs *CourseService) backoff(retry int) time.Duration {
+	delay := cs.options.RetryBackoff << retry
+	if delay <= 0 {
+		return 0
 	}
+	return delay/2 + rand.N(delay/2+1)
+}
 
-	// Parse response
-	body, err := io.ReadAll(resp.Body)
+// fetch makes one request, reporting whether a failure is worth retrying
+func (cs *CourseService) fetch(ctx context.Context, endpoint string) (bool, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
-		return false, fmt.Errorf("failed to read response: %w", err)
+		return false, false, fmt.Errorf("failed to build course API request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := cs.client.Do(req)
+	if err != nil {
+		return false, true, fmt.Errorf("failed to call course API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, false, nil // Student hasn't enrolled or completed
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return false, true, fmt.Errorf("course API returned status %d", resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return false, false, fmt.Errorf("course API returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCourseResponseBytes))
+	if err != nil {
+		return false, true, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	var completion CourseCompletionResponse
 	if err := json.Unmarshal(body, &completion); err != nil {
-		return false, fmt.Errorf("failed to parse response: %w", err)
+		return false, false, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return completion.Completed, false, nil
+}
+
+// storedCompletion returns the stored result for a student and course, or nil
+// when there is none or it expired
+func (cs *CourseService) storedCompletion(ctx context.Context, studentID primitive.ObjectID, courseID string, now time.Time) (*models.CourseCompletion, error) {
+	completion, err := cs.store.Find(ctx, studentID, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch course completion: %w", err)
+	}
+	if completion == nil || (completion.ExpiresAt != nil && !now.Before(*completion.ExpiresAt)) {
+		return nil, nil
+	}
+	return completion, nil
+}
+
+// storeAPIResult stores the answer of the course API. A completion is kept
+// for good, a course not completed is remembered for the negative cache TTL.
+// Manual results and completions are never overwritten
+func (cs *CourseService) storeAPIResult(ctx context.Context, studentID primitive.ObjectID, courseID string, completed bool, now time.Time) error {
+	completion := models.CourseCompletion{
+		StudentID: studentID,
+		CourseID:  courseID,
+		Completed: completed,
+		Source:    models.CompletionFromAPI,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if !completed {
+		if cs.options.NegativeCacheTTL <= 0 {
+			return nil
+		}
+		expiresAt := now.Add(cs.options.NegativeCacheTTL)
+		completion.ExpiresAt = &expiresAt
+	}
+
+	if _, err := cs.store.Save(ctx, completion); err != nil {
+		return fmt.Errorf("failed to store course completion: %w", err)
+	}
+	return nil
+}
+
+// RecordWebhookCompletion stores a completion pushed by the course platform.
+// It reports false when a manual result for the course takes precedence
+func (cs *CourseService) RecordWebhookCompletion(ctx context.Context, studentID primitive.ObjectID, courseID string, completedAt time.Time) (bool, error) {
+	now := time.Now()
+	saved, err := cs.store.Save(ctx, models.CourseCompletion{
+		StudentID:   studentID,
+		CourseID:    courseID,
+		Completed:   true,
+		Source:      models.CompletionFromWebhook,
+		CompletedAt: &completedAt,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to store course completion: %w", err)
+	}
+	return saved, nil
+}
+
+// SetCompletion manually grants or revokes the completion of a course by a
+// student. The result overrides the course API and webhooks until changed
+func (cs *CourseService) SetCompletion(ctx context.Context, studentID primitive.ObjectID, courseID string, completed bool, professorID primitive.ObjectID, reason string) (*models.CourseCompletion, error) {
+	now := time.Now()
+	completion := models.CourseCompletion{
+		StudentID: studentID,
+		CourseID:  courseID,
+		Completed: completed,
+		Source:    models.CompletionFromManual,
+		UpdatedBy: &professorID,
+		Reason:    reason,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if completed {
+		completion.CompletedAt = &now
+	}
+
+	stored, err := cs.store.SetManual(ctx, completion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update course completion: %w", err)
+	}
+	return stored, nil
+}
+
+// VerifyWebhook checks the signature of a completion webhook: the hex
+// HMAC-SHA256 of "<timestamp>.<body>" with the webhook secret, optionally
+// prefixed with "sha256=", and a Unix timestamp within WebhookTolerance of now
+func (cs *CourseService) VerifyWebhook(timestamp, signature string, body []byte, now time.Time) error {
+	if cs.options.WebhookSecret == "" {
+		return ErrWebhookDisabled
 	}
 
-	return completion.Completed, nil
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: malformed timestamp", ErrWebhookSignature)
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > WebhookTolerance || age < -WebhookTolerance {
+		return fmt.Errorf("%w: timestamp out of tolerance", ErrWebhookSignature)
+	}
+
+	given, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return fmt.Errorf("%w: malformed signature", ErrWebhookSignature)
+	}
+	mac := hmac.New(sha256.New, []byte(cs.options.WebhookSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	if !hmac.Equal(given, mac.Sum(nil)) {
+		return fmt.Errorf("%w: signature mismatch", ErrWebhookSignature)
+	}
+	return nil
+}
+
+// MongoCompletionStore keeps course completion results in the
+// course_completions collection, whose unique index on student and course
+// settles concurrent writes
+type MongoCompletionStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoCompletionStore creates a completion store on the course_completions collection
+func NewMongoCompletionStore() *MongoCompletionStore {
+	return &MongoCompletionStore{collection: config.GetCollection("course_completions")}
+}
+
+func (s *MongoCompletionStore) Find(ctx context.Context, studentID primitive.ObjectID, courseID string) (*models.CourseCompletion, error) {
+	var completion models.CourseCompletion
+	err := s.collection.FindOne(ctx, bson.M{"student_id": studentID, "course_id": courseID}).Decode(&completion)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &completion, nil
+}
+
+func (s *MongoCompletionStore) Save(ctx context.Context, completion models.CourseCompletion) (bool, error) {
+	filter := bson.M{
+		"student_id": completion.StudentID,
+		"course_id":  completion.CourseID,
+		"source":     bson.M{"$ne": models.CompletionFromManual},
+	}
+	if !completion.Completed {
+		filter["completed"] = false
+	}
+	set := bson.M{
+		"completed":  completion.Completed,
+		"source":     completion.Source,
+		"updated_at": completion.UpdatedAt,
+	}
+	if completion.CompletedAt != nil {
+		set["completed_at"] = *completion.CompletedAt
+	}
+	update := bson.M{
+		"$set":         set,
+		"$setOnInsert": bson.M{"created_at": completion.CreatedAt},
+	}
+	if completion.ExpiresAt != nil {
+		set["expires_at"] = *completion.ExpiresAt
+	} else {
+		update["$unset"] = bson.M{"expires_at": ""}
+	}
+
+	// A filter that excludes the stored result makes the upsert collide with
+	// it on the unique index, which leaves it as is
+	result, err := s.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0 || result.UpsertedCount > 0, nil
+}
+
+func (s *MongoCompletionStore) SetManual(ctx context.Context, completion models.CourseCompletion) (*models.CourseCompletion, error) {
+	set := bson.M{
+		"completed":  completion.Completed,
+		"source":     completion.Source,
+		"updated_by": completion.UpdatedBy,
+		"reason":     completion.Reason,
+		"updated_at": completion.UpdatedAt,
+	}
+	unset := bson.M{"expires_at": ""}
+	if completion.CompletedAt != nil {
+		set["completed_at"] = *completion.CompletedAt
+	} else {
+		unset["completed_at"] = ""
+	}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var stored models.CourseCompletion
+	err := s.collection.FindOneAndUpdate(ctx,
+		bson.M{"student_id": completion.StudentID, "course_id": completion.CourseID},
+		bson.M{"$set": set, "$unset": unset, "$setOnInsert": bson.M{"created_at": completion.CreatedAt}},
+		opts,
+	).Decode(&stored)
+	if err != nil {
+		return nil, err
+	}
+	return &stored, nil
 }
@@ -0,0 +1,365 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"quizmasterapi/config"
+	"quizmasterapi/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryCompletionStore keeps course completion results in memory
+type memoryCompletionStore struct {
+	mu      sync.Mutex
+	results map[string]models.CourseCompletion
+}
+
+func newMemoryCompletionStore() *memoryCompletionStore {
+	return &memoryCompletionStore{results: map[string]models.CourseCompletion{}}
+}
+
+func completionKey(studentID primitive.ObjectID, courseID string) string {
+	return studentID.Hex() + "/" + courseID
+}
+
+func (s *memoryCompletionStore) Find(ctx context.Context, studentID primitive.ObjectID, courseID string) (*models.CourseCompletion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	completion, ok := s.results[completionKey(studentID, courseID)]
+	if !ok {
+		return nil, nil
+	}
+	return &completion, nil
+}
+
+func (s *memoryCompletionStore) Save(ctx context.Context, completion models.CourseCompletion) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := completionKey(completion.StudentID, completion.CourseID)
+	if stored, ok := s.results[key]; ok {
+		if stored.Source == models.CompletionFromManual || (stored.Completed && !completion.Completed) {
+			return false, nil
+		}
+		completion.CreatedAt = stored.CreatedAt
+	}
+	s.results[key] = completion
+	return true, nil
+}
+
+func (s *memoryCompletionStore) SetManual(ctx context.Context, completion models.CourseCompletion) (*models.CourseCompletion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.results[completionKey(completion.StudentID, completion.CourseID)] = completion
+	return &completion, nil
+}
+
+// courseAPI is a fake course API answering with the next status of its
+// script, then with its last one
+type courseAPI struct {
+	server   *httptest.Server
+	calls    atomic.Int32
+	mu       sync.Mutex
+	statuses []int
+	paths    []string
+	delay    time.Duration
+}
+
+func newCourseAPI(t *testing.T, statuses ...int) *courseAPI {
+	api := &courseAPI{statuses: statuses}
+	api.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(api.calls.Add(1))
+		api.mu.Lock()
+		api.paths = append(api.paths, r.RequestURI)
+		status := api.statuses[min(call, len(api.statuses))-1]
+		delay := api.delay
+		api.mu.Unlock()
+
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			w.Write([]byte(`{"completed": true}`))
+		}
+	}))
+	t.Cleanup(api.server.Close)
+	return api
+}
+
+// script replaces the statuses answered from the next call on
+func (api *courseAPI) script(statuses ...int) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	offset := int(api.calls.Load())
+	api.statuses = make([]int, offset, offset+len(statuses))
+	api.statuses = append(api.statuses, statuses...)
+}
+
+func testCourseOptions() config.CourseAPIConfig {
+	return config.CourseAPIConfig{
+		Timeout:          time.Second,
+		MaxRetries:       2,
+		RetryBackoff:     time.Millisecond,
+		BreakerThreshold: 2,
+		BreakerCooldown:  time.Minute,
+	}
+}
+
+// testClock is a settable clock for the circuit breaker
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestCourseService(api *courseAPI, options config.CourseAPIConfig) (*CourseService, *testClock) {
+	cs := NewCourseService(api.server.URL+"/", nil, options, newMemoryCompletionStore())
+	clock := &testClock{now: time.Now()}
+	cs.breaker.now = clock.Now
+	return cs, clock
+}
+
+func TestCheckCourseCompletionSuccess(t *testing.T) {
+	api := newCourseAPI(t, http.StatusOK)
+	cs, _ := newTestCourseService(api, testCourseOptions())
+	studentID := primitive.NewObjectID()
+
+	completed, err := cs.CheckCourseCompletion(context.Background(), studentID, "CS101")
+	if err != nil || !completed {
+		t.Fatalf("got %v, %v, want completed", completed, err)
+	}
+
+	// The completion is stored, so the API is not asked again
+	completed, err = cs.CheckCourseCompletion(context.Background(), studentID, "CS101")
+	if err != nil || !completed {
+		t.Fatalf("second check got %v, %v, want completed", completed, err)
+	}
+	if calls := api.calls.Load(); calls != 1 {
+		t.Fatalf("API called %d times, want 1", calls)
+	}
+}
+
+func TestCheckCourseCompletionNotFound(t *testing.T) {
+	api := newCourseAPI(t, http.StatusNotFound)
+	options := testCourseOptions()
+	options.NegativeCacheTTL = time.Minute
+	cs, _ := newTestCourseService(api, options)
+	studentID := primitive.NewObjectID()
+
+	for i := 0; i < 2; i++ {
+		completed, err := cs.CheckCourseCompletion(context.Background(), studentID, "CS101")
+		if err != nil || completed {
+			t.Fatalf("check %d got %v, %v, want not completed", i, completed, err)
+		}
+	}
+	if calls := api.calls.Load(); calls != 1 {
+		t.Fatalf("API called %d times, want 1 thanks to the negative cache", calls)
+	}
+}
+
+func TestCheckCourseCompletionRetriesServerErrors(t *testing.T) {
+	api := newCourseAPI(t, http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK)
+	cs, _ := newTestCourseService(api, testCourseOptions())
+
+	completed, err := cs.CheckCourseCompletion(context.Background(), primitive.NewObjectID(), "CS101")
+	if err != nil || !completed {
+		t.Fatalf("got %v, %v, want completed after retries", completed, err)
+	}
+	if calls := api.calls.Load(); calls != 3 {
+		t.Fatalf("API called %d times, want 3", calls)
+	}
+}
+
+func TestCheckCourseCompletionDoesNotRetryClientErrors(t *testing.T) {
+	api := newCourseAPI(t, http.StatusBadRequest)
+	cs, _ := newTestCourseService(api, testCourseOptions())
+
+	_, err := cs.CheckCourseCompletion(context.Background(), primitive.NewObjectID(), "CS101")
+	if err == nil || errors.Is(err, ErrCourseAPIUnavailable) {
+		t.Fatalf("got error %v, want a non-availability error", err)
+	}
+	if calls := api.calls.Load(); calls != 1 {
+		t.Fatalf("API called %d times, want 1", calls)
+	}
+}
+
+func TestCheckCourseCompletionTimeout(t *testing.T) {
+	api := newCourseAPI(t, http.StatusOK)
+	api.delay = time.Second
+	options := testCourseOptions()
+	options.Timeout = 20 * time.Millisecond
+	options.MaxRetries = 1
+	cs, _ := newTestCourseService(api, options)
+
+	start := time.Now()
+	_, err := cs.CheckCourseCompletion(context.Background(), primitive.NewObjectID(), "CS101")
+	if !errors.Is(err, ErrCourseAPIUnavailable) {
+		t.Fatalf("got error %v, want ErrCourseAPIUnavailable", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("check took %v, the request timeout was not applied", elapsed)
+	}
+	if calls := api.calls.Load(); calls != 2 {
+		t.Fatalf("API called %d times, want 2 (timeouts are retried)", calls)
+	}
+}
+
+func TestCheckCourseCompletionBreakerOpensAfterThreshold(t *testing.T) {
+	api := newCourseAPI(t, http.StatusInternalServerError)
+	options := testCourseOptions()
+	options.MaxRetries = 0
+	cs, _ := newTestCourseService(api, options)
+	ctx := context.Background()
+
+	for i := 0; i < options.BreakerThreshold; i++ {
+		if _, err := cs.CheckCourseCompletion(ctx, primitive.NewObjectID(), "CS101"); !errors.Is(err, ErrCourseAPIUnavailable) {
+			t.Fatalf("call %d got error %v, want ErrCourseAPIUnavailable", i, err)
+		}
+	}
+
+	_, err := cs.CheckCourseCompletion(ctx, primitive.NewObjectID(), "CS101")
+	if !errors.Is(err, ErrCourseAPIUnavailable) || !strings.Contains(err.Error(), "circuit breaker open") {
+		t.Fatalf("got error %v, want the open breaker to refuse the call", err)
+	}
+	if calls := int(api.calls.Load()); calls != options.BreakerThreshold {
+		t.Fatalf("API called %d times, want %d", calls, options.BreakerThreshold)
+	}
+}
+
+func TestCheckCourseCompletionBreakerHalfOpen(t *testing.T) {
+	api := newCourseAPI(t, http.StatusInternalServerError)
+	options := testCourseOptions()
+	options.MaxRetries = 0
+	cs, clock := newTestCourseService(api, options)
+	ctx := context.Background()
+
+	for i := 0; i < options.BreakerThreshold; i++ {
+		cs.CheckCourseCompletion(ctx, primitive.NewObjectID(), "CS101")
+	}
+
+	// After the cooldown a failed trial call reopens the breaker
+	clock.Advance(options.BreakerCooldown)
+	if _, err := cs.CheckCourseCompletion(ctx, primitive.NewObjectID(), "CS101"); !errors.Is(err, ErrCourseAPIUnavailable) {
+		t.Fatalf("trial call got error %v, want ErrCourseAPIUnavailable", err)
+	}
+	calls := api.calls.Load()
+	if _, err := cs.CheckCourseCompletion(ctx, primitive.NewObjectID(), "CS101"); err == nil || api.calls.Load() != calls {
+		t.Fatalf("call after a failed trial reached the API (error %v)", err)
+	}
+
+	// Only one trial call is let through at a time
+	clock.Advance(options.BreakerCooldown)
+	api.script(http.StatusOK)
+	api.mu.Lock()
+	api.delay = 100 * time.Millisecond
+	api.mu.Unlock()
+
+	trial := make(chan error, 1)
+	go func() {
+		_, err := cs.CheckCourseCompletion(ctx, primitive.NewObjectID(), "CS101")
+		trial <- err
+	}()
+	for api.calls.Load() == calls {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := cs.CheckCourseCompletion(ctx, primitive.NewObjectID(), "CS101"); err == nil {
+		t.Fatal("call during the trial was let through")
+	}
+	if err := <-trial; err != nil {
+		t.Fatalf("trial call got error %v", err)
+	}
+
+	// The successful trial closed the breaker
+	if _, err := cs.CheckCourseCompletion(ctx, primitive.NewObjectID(), "CS101"); err != nil {
+		t.Fatalf("call after a successful trial got error %v", err)
+	}
+}
+
+func TestCheckCourseCompletionFailOpenAndClosed(t *testing.T) {
+	for _, failOpen := range []bool{false, true} {
+		api := newCourseAPI(t, http.StatusInternalServerError)
+		options := testCourseOptions()
+		options.MaxRetries = 0
+		options.FailOpen = failOpen
+		cs, _ := newTestCourseService(api, options)
+		ctx := context.Background()
+
+		for i := 0; i < options.BreakerThreshold; i++ {
+			if _, err := cs.CheckCourseCompletion(ctx, primitive.NewObjectID(), "CS101"); err == nil {
+				t.Fatalf("fail open %v: failed call %d got no error", failOpen, i)
+			}
+		}
+
+		completed, err := cs.CheckCourseCompletion(ctx, primitive.NewObjectID(), "CS101")
+		if failOpen && (err != nil || !completed) {
+			t.Fatalf("fail open: got %v, %v, want completed", completed, err)
+		}
+		if !failOpen && (!errors.Is(err, ErrCourseAPIUnavailable) || completed) {
+			t.Fatalf("fail closed: got %v, %v, want ErrCourseAPIUnavailable", completed, err)
+		}
+	}
+}
+
+func TestCheckCourseCompletionEscapesPath(t *testing.T) {
+	api := newCourseAPI(t, http.StatusOK)
+	cs, _ := newTestCourseService(api, testCourseOptions())
+	studentID := primitive.NewObjectID()
+
+	if _, err := cs.CheckCourseCompletion(context.Background(), studentID, "CS 101/../admin?x=1#y"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "/courses/CS%20101%2F..%2Fadmin%3Fx=1%23y/students/" + studentID.Hex() + "/completion"
+	if len(api.paths) != 1 || api.paths[0] != want {
+		t.Fatalf("API got %v, want %s", api.paths, want)
+	}
+}
+
+func TestCheckCourseCompletionPrefersManualResult(t *testing.T) {
+	api := newCourseAPI(t, http.StatusOK)
+	cs, _ := newTestCourseService(api, testCourseOptions())
+	ctx := context.Background()
+	studentID := primitive.NewObjectID()
+
+	if _, err := cs.SetCompletion(ctx, studentID, "CS101", false, primitive.NewObjectID(), "Plagiarism"); err != nil {
+		t.Fatal(err)
+	}
+	if saved, err := cs.RecordWebhookCompletion(ctx, studentID, "CS101", time.Now()); err != nil || saved {
+		t.Fatalf("webhook got %v, %v, want it ignored", saved, err)
+	}
+	completed, err := cs.CheckCourseCompletion(ctx, studentID, "CS101")
+	if err != nil || completed {
+		t.Fatalf("got %v, %v, want the manual revocation", completed, err)
+	}
+	if calls := api.calls.Load(); calls != 0 {
+		t.Fatalf("API called %d times, want 0", calls)
+	}
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Event types published on the event bus
+const (
+	EventAttemptStarted     = "attempt.started"
+	EventAttemptCompleted   = "attempt.completed"
+	EventLeaderboardChanged = "leaderboard.changed"
+	EventQuizStatusChanged  = "quiz.status_changed"
+	EventQuizDeleted        = "quiz.deleted"
+
+	EventSessionParticipantJoined = "session.participant_joined"
+	EventSessionQuestionOpened    = "session.question_opened"
+	EventSessionAnswerReceived    = "session.answer_received"
+	EventSessionQuestionClosed    = "session.question_closed"
+	EventSessionFinished          = "session.finished"
+	EventSessionCancelled         = "session.cancelled"
+)
+
+// Events buffered per subscriber before it counts as too slow and is dropped
+const subscriberBufferSize = 64
+
+// Event is a change pushed to stream subscribers
+type Event struct {
+	ID        uint64              `json:"id"`
+	Type      string              `json:"type"`
+	QuizID    primitive.ObjectID  `json:"quiz_id"`
+	SessionID *primitive.ObjectID `json:"session_id,omitempty"`
+	Data      interface{}         `json:"data,omitempty"`
+	CreatedAt time.Time           `json:"created_at"`
+
+	// Quiz or live session the event is delivered to
+	topic primitive.ObjectID
+}
+
+// EventBus fans events out to in-process subscribers. Publishing never
+// blocks: a subscriber whose buffer is full is disconnected instead, so one
+// slow client cannot hold up request handlers or other clients
+type EventBus struct {
+	mu          sync.RWMutex
+	nextID      atomic.Uint64
+	subscribers map[*Subscription]struct{}
+}
+
+// Subscription receives the events of one quiz or live session, or of every quiz
+type Subscription struct {
+	events chan Event
+	topic  *primitive.ObjectID
+	lagged atomic.Bool
+}
+
+// NewEventBus creates a new event bus
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Events returns the subscription's channel. It is closed on Unsubscribe or
+// when the subscriber falls behind
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Lagged reports whether the subscription was dropped for falling behind
+func (s *Subscription) Lagged() bool {
+	return s.lagged.Load()
+}
+
+// Subscribe registers a subscriber for the events of a quiz or live session,
+// or of every quiz when topic is nil
+func (eb *EventBus) Subscribe(topic *primitive.ObjectID) *Subscription {
+	sub := &Subscription{
+		events: make(chan Event, subscriberBufferSize),
+		topic:  topic,
+	}
+
+	eb.mu.Lock()
+	eb.subscribers[sub] = struct{}{}
+	eb.mu.Unlock()
+	return sub
+}
+
+// Unsubscribe removes a subscriber and closes its channel. It is safe to call more than once
+func (eb *EventBus) Unsubscribe(sub *Subscription) {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+
+	if _, ok := eb.subscribers[sub]; ok {
+		delete(eb.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// Publish sends a quiz event to every interested subscriber without blocking
+func (eb *EventBus) Publish(eventType string, quizID primitive.ObjectID, data interface{}) {
+	eb.publish(Event{
+		Type:   eventType,
+		QuizID: quizID,
+		Data:   data,
+		topic:  quizID,
+	})
+}
+
+// PublishSession sends a live session event to the subscribers of that session
+func (eb *EventBus) PublishSession(eventType string, sessionID, quizID primitive.ObjectID, data interface{}) {
+	eb.publish(Event{
+		Type:      eventType,
+		QuizID:    quizID,
+		SessionID: &sessionID,
+		Data:      data,
+		topic:     sessionID,
+	})
+}
+
+func (eb *EventBus) publish(event Event) {
+	event.ID = eb.nextID.Add(1)
+	event.CreatedAt = time.Now()
+
+	var lagging []*Subscription
+
+	eb.mu.RLock()
+	for sub := range eb.subscribers {
+		if sub.topic == nil && event.SessionID != nil {
+			// Subscribers to every quiz do not follow live sessions
+			continue
+		}
+		if sub.topic != nil && *sub.topic != event.topic {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			lagging = append(lagging, sub)
+		}
+	}
+	eb.mu.RUnlock()
+
+	for _, sub := range lagging {
+		sub.lagged.Store(true)
+		eb.Unsubscribe(sub)
+	}
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"quizmasterapi/config"
+	"quizmasterapi/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GradeRule selects which score of a student's attempts on a quiz counts in the gradebook
+type GradeRule string
+
+const (
+	GradeBest    GradeRule = "best"
+	GradeLast    GradeRule = "last"
+	GradeAverage GradeRule = "average"
+)
+
+// ErrNoCourseQuizzes is returned when a professor has no approved quiz in a course
+var ErrNoCourseQuizzes = errors.New("no quizzes found for this course")
+
+// GradebookService builds the students × quizzes score matrix of a course
+type GradebookService struct {
+	quizCollection    *mongo.Collection
+	attemptCollection *mongo.Collection
+	scoringService    *ScoringService
+}
+
+// GradebookQuiz is a column of the gradebook
+type GradebookQuiz struct {
+	ID       primitive.ObjectID `json:"id"`
+	Title    string             `json:"title"`
+	MaxScore float64            `json:"max_score"`
+}
+
+// GradebookRow is a student's line of the gradebook. Scores follow the
+// order of the quizzes and are nil when the student has no counted attempt
+type GradebookRow struct {
+	StudentID  primitive.ObjectID `json:"student_id"`
+	FirstName  string             `json:"first_name"`
+	LastName   string             `json:"last_name"`
+	Email      string             `json:"email"`
+	Scores     []*float64         `json:"scores"`
+	Attempts   []int              `json:"attempts"`
+	Total      float64            `json:"total"`
+	MaxTotal   float64            `json:"max_total"`
+	Percentage float64            `json:"percentage"`
+}
+
+// NewGradebookService creates a new gradebook service
+func NewGradebookService() *GradebookService {
+	return &GradebookService{
+		quizCollection:    config.GetCollection("quizzes"),
+		attemptCollection: config.GetCollection("attempts"),
+		scoringService:    NewScoringService(),
+	}
+}
+
+// ParseGradeRule validates a grade rule, defaulting to best
+func ParseGradeRule(value string) (GradeRule, error) {
+	switch rule := GradeRule(value); rule {
+	case "":
+		return GradeBest, nil
+	case GradeBest, GradeLast, GradeAverage:
+		return rule, nil
+	}
+	return "", fmt.Errorf("invalid rule %q, use best, last or average", value)
+}
+
+// Quizzes returns the approved quizzes of a course the professor created or
+// approved, oldest first, with their maximum score
+func (gs *GradebookService) Quizzes(ctx context.Context, courseID string, professorID primitive.ObjectID) ([]GradebookQuiz, error) {
+	filter := bson.M{
+		"course_id": courseID,
+		"status":    models.StatusApproved,
+		"$or": []bson.M{
+			{"creator_id": professorID},
+			{"approved_by": professorID},
+		},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := gs.quizCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch course quizzes: %w", err)
+	}
+
+	var quizzes []models.Quiz
+	if err := cursor.All(ctx, &quizzes); err != nil {
+		return nil, fmt.Errorf("failed to fetch course quizzes: %w", err)
+	}
+	if len(quizzes) == 0 {
+		return nil, ErrNoCourseQuizzes
+	}
+
+	columns := make([]GradebookQuiz, len(quizzes))
+	for i := range quizzes {
+		maxScore, err := gs.scoringService.MaxScore(&quizzes[i], quizzes[i].Scoring)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute max score of quiz %s: %w", quizzes[i].ID.Hex(), err)
+		}
+		columns[i] = GradebookQuiz{ID: quizzes[i].ID, Title: quizzes[i].Title, MaxScore: maxScore}
+	}
+	return columns, nil
+}
+
+// Rows streams the gradebook rows of the students who completed at least one
+// of the quizzes, ordered by name. Scores are selected per quiz by rule
+func (gs *GradebookService) Rows(ctx context.Context, quizzes []GradebookQuiz, rule GradeRule, fn func(*GradebookRow) error) error {
+	ids := make([]primitive.ObjectID, len(quizzes))
+	columns := make(map[primitive.ObjectID]int, len(quizzes))
+	maxTotal := 0.0
+	for i, quiz := range quizzes {
+		ids[i] = quiz.ID
+		columns[quiz.ID] = i
+		maxTotal += quiz.MaxScore
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"quiz_id":        bson.M{"$in": ids},
+			"completed_at":   bson.M{"$ne": nil},
+			"invalidated_at": bson.M{"$exists": false},
+			"mode":           bson.M{"$ne": models.ModePractice},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "completed_at", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      bson.M{"student_id": "$student_id", "quiz_id": "$quiz_id"},
+			"best":     bson.M{"$max": "$total_score"},
+			"last":     bson.M{"$last": "$total_score"},
+			"average":  bson.M{"$avg": "$total_score"},
+			"attempts": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": "$_id.student_id",
+			"scores": bson.M{"$push": bson.M{
+				"quiz_id":  "$_id.quiz_id",
+				"best":     "$best",
+				"last":     "$last",
+				"average":  "$average",
+				"attempts": "$attempts",
+			}},
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "users",
+			"localField":   "_id",
+			"foreignField": "_id",
+			"pipeline":     bson.A{bson.M{"$project": bson.M{"first_name": 1, "last_name": 1, "email": 1}}},
+			"as":           "student",
+		}}},
+		{{Key: "$unwind", Value: bson.M{"path": "$student", "preserveNullAndEmptyArrays": true}}},
+		{{Key: "$sort", Value: bson.D{
+			{Key: "student.last_name", Value: 1},
+			{Key: "student.first_name", Value: 1},
+			{Key: "_id", Value: 1},
+		}}},
+	}
+
+	cursor, err := gs.attemptCollection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return fmt.Errorf("failed to aggregate gradebook: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var result struct {
+			StudentID primitive.ObjectID `bson:"_id"`
+			Student   models.User        `bson:"student"`
+			Scores    []struct {
+				QuizID   primitive.ObjectID `bson:"quiz_id"`
+				Best     float64            `bson:"best"`
+				Last     float64            `bson:"last"`
+				Average  float64            `bson:"average"`
+				Attempts int                `bson:"attempts"`
+			} `bson:"scores"`
+		}
+		if err := cursor.Decode(&result); err != nil {
+			return fmt.Errorf("failed to decode gradebook row: %w", err)
+		}
+
+		row := &GradebookRow{
+			StudentID: result.StudentID,
+			FirstName: result.Student.FirstName,
+			LastName:  result.Student.LastName,
+			Email:     result.Student.Email,
+			Scores:    make([]*float64, len(quizzes)),
+			Attempts:  make([]int, len(quizzes)),
+			MaxTotal:  maxTotal,
+		}
+		for _, score := range result.Scores {
+			i := columns[score.QuizID]
+			selected := score.Best
+			switch rule {
+			case GradeLast:
+				selected = score.Last
+			case GradeAverage:
+				selected = math.Round(score.Average*100) / 100
+			}
+			row.Scores[i] = &selected
+			row.Attempts[i] = score.Attempts
+			row.Total += selected
+		}
+		row.Total = math.Round(row.Total*100) / 100
+		if maxTotal > 0 {
+			row.Percentage = math.Round(row.Total/maxTotal*10000) / 100
+		}
+
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("failed to aggregate gradebook: %w", err)
+	}
+	return nil
+}
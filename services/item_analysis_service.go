@@ -0,0 +1,382 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"quizmasterapi/config"
+	"quizmasterapi/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Item flags raised for questions that deserve a review
+const (
+	FlagTooHard                = "too_hard"
+	FlagTooEasy                = "too_easy"
+	FlagLowDiscrimination      = "low_discrimination"
+	FlagNegativeDiscrimination = "negative_discrimination"
+	FlagDistractorOverKey      = "distractor_chosen_over_key"
+	FlagUnusedDistractor       = "unused_distractor"
+	FlagLowReliability         = "low_reliability"
+)
+
+const (
+	// Share of attempts in the upper and lower groups of the discrimination index
+	discriminationGroupShare = 0.27
+	// Questions are only flagged once the quiz has this many analyzed attempts
+	minFlaggedAttempts = 10
+	// Flag thresholds
+	tooHardPercentCorrect = 20
+	tooEasyPercentCorrect = 95
+	lowDiscrimination     = 0.2
+	lowReliability        = 0.5
+)
+
+// ItemAnalysisService computes item statistics of a quiz from its completed attempts
+type ItemAnalysisService struct {
+	attemptCollection *mongo.Collection
+}
+
+// ItemAnalysis is the item analysis of a quiz
+type ItemAnalysis struct {
+	QuizID        primitive.ObjectID `json:"quiz_id"`
+	Attempts      int                `json:"attempts"`       // Completed attempts that are not invalidated
+	CronbachAlpha *float64           `json:"cronbach_alpha"` // Internal consistency, null with fewer than 2 questions or no score variance
+	Flags         []string           `json:"flags"`
+	FlaggedCount  int                `json:"flagged_count"` // Questions with at least one flag
+	Questions     []ItemStatistics   `json:"questions"`
+}
+
+// ItemStatistics describes how one question performed
+type ItemStatistics struct {
+	QuestionID          primitive.ObjectID  `json:"question_id"`
+	Order               int                 `json:"order"`
+	QuestionText        string              `json:"question_text"`
+	Type                models.QuestionType `json:"type"`
+	Answered            int                 `json:"answered"`   // Attempts that answered the question, skips excluded
+	Unanswered          int                 `json:"unanswered"` // Attempts that skipped or never answered it
+	PercentCorrect      float64             `json:"percent_correct"`
+	AverageTimeToAnswer float64             `json:"average_time_to_answer"` // In seconds
+	OptionDistribution  []OptionCount       `json:"option_distribution,omitempty"`
+	DiscriminationIndex *float64            `json:"discrimination_index"` // Upper minus lower 27% percent correct, from -1 to 1
+	PointBiserial       *float64            `json:"point_biserial"`       // Correlation with the score on the other questions
+	Flags               []string            `json:"flags"`
+}
+
+// OptionCount is how often a multiple choice option was chosen
+type OptionCount struct {
+	Option     int     `json:"option"`
+	Text       string  `json:"text"`
+	Correct    bool    `json:"correct"`
+	Count      int     `json:"count"`
+	Percentage float64 `json:"percentage"` // Of the attempts that answered
+}
+
+// itemResponse is an attempt's answer to one question
+type itemResponse struct {
+	answered bool
+	correct  bool
+	choice   string
+	time     int
+}
+
+// NewItemAnalysisService creates a new item analysis service
+func NewItemAnalysisService() *ItemAnalysisService {
+	return &ItemAnalysisService{
+		attemptCollection: config.GetCollection("attempts"),
+	}
+}
+
+// Analyze computes the item analysis of a quiz from its counted, ranked, non adaptive attempts
+func (ias *ItemAnalysisService) Analyze(ctx context.Context, quiz *models.Quiz) (*ItemAnalysis, error) {
+	// Adaptive attempts each see a different subset of the questions, and
+	// practice attempts get feedback along the way
+	filter := bson.M{
+		"quiz_id":        quiz.ID,
+		"completed_at":   bson.M{"$ne": nil},
+		"invalidated_at": bson.M{"$exists": false},
+		"adaptive":       bson.M{"$ne": true},
+		"mode":           bson.M{"$ne": models.ModePractice},
+	}
+	opts := options.Find().SetProjection(bson.M{"answers": 1})
+
+	cursor, err := ias.attemptCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch attempts: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	index := make(map[primitive.ObjectID]int, len(quiz.Questions))
+	for i, question := range quiz.Questions {
+		index[question.ID] = i
+	}
+
+	responses := [][]itemResponse{}
+	for cursor.Next(ctx) {
+		var attempt struct {
+			Answers []models.Answer `bson:"answers"`
+		}
+		if err := cursor.Decode(&attempt); err != nil {
+			return nil, fmt.Errorf("failed to decode attempt: %w", err)
+		}
+
+		row := make([]itemResponse, len(quiz.Questions))
+		for _, answer := range attempt.Answers {
+			i, ok := index[answer.QuestionID]
+			if !ok || answer.Skipped {
+				continue
+			}
+			choice, _ := answer.StudentAnswer.(string)
+			row[i] = itemResponse{answered: true, correct: answer.IsCorrect, choice: choice, time: answer.TimeToAnswer}
+		}
+		responses = append(responses, row)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch attempts: %w", err)
+	}
+
+	return analyzeItems(quiz, responses), nil
+}
+
+// analyzeItems computes the statistics of every question from one row of
+// responses per attempt. An unanswered question scores 0
+func analyzeItems(quiz *models.Quiz, responses [][]itemResponse) *ItemAnalysis {
+	n, k := len(responses), len(quiz.Questions)
+
+	totals := make([]float64, n)
+	for a, row := range responses {
+		for _, response := range row {
+			if response.correct {
+				totals[a]++
+			}
+		}
+	}
+
+	// Attempts ordered by total score for the upper and lower groups
+	ranked := make([]int, n)
+	for a := range ranked {
+		ranked[a] = a
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return totals[ranked[i]] > totals[ranked[j]] })
+	groupSize := int(math.Ceil(discriminationGroupShare * float64(n)))
+
+	analysis := &ItemAnalysis{
+		QuizID:    quiz.ID,
+		Attempts:  n,
+		Flags:     []string{},
+		Questions: make([]ItemStatistics, 0, k),
+	}
+
+	itemVarianceSum := 0.0
+	for q := range quiz.Questions {
+		question := &quiz.Questions[q]
+		scores := make([]float64, n)
+		rest := make([]float64, n)
+		stats := ItemStatistics{
+			QuestionID:   question.ID,
+			Order:        question.Order,
+			QuestionText: question.QuestionText,
+			Type:         question.Type,
+			Flags:        []string{},
+		}
+
+		correct, timeSum := 0, 0
+		choices := map[string]int{}
+		for a, row := range responses {
+			response := row[q]
+			if response.correct {
+				scores[a] = 1
+				correct++
+			}
+			rest[a] = totals[a] - scores[a]
+			if response.answered {
+				stats.Answered++
+				timeSum += response.time
+				choices[response.choice]++
+			}
+		}
+		stats.Unanswered = n - stats.Answered
+
+		p := 0.0
+		if n > 0 {
+			p = float64(correct) / float64(n)
+			stats.PercentCorrect = round2(p * 100)
+			itemVarianceSum += p * (1 - p)
+		}
+		if stats.Answered > 0 {
+			stats.AverageTimeToAnswer = round2(float64(timeSum) / float64(stats.Answered))
+		}
+		if question.Type == models.QuestionTypeMultipleChoice {
+			stats.OptionDistribution = optionDistribution(question, choices, stats.Answered)
+		}
+
+		if groupSize > 0 && n >= 2 {
+			upper, lower := 0.0, 0.0
+			for i := 0; i < groupSize; i++ {
+				upper += scores[ranked[i]]
+				lower += scores[ranked[n-1-i]]
+			}
+			d := round2((upper - lower) / float64(groupSize))
+			stats.DiscriminationIndex = &d
+		}
+		if r, ok := pearson(scores, rest); ok {
+			r = round2(r)
+			stats.PointBiserial = &r
+		}
+
+		if n >= minFlaggedAttempts {
+			stats.Flags = itemFlags(&stats)
+		}
+		if len(stats.Flags) > 0 {
+			analysis.FlaggedCount++
+		}
+		analysis.Questions = append(analysis.Questions, stats)
+	}
+
+	if k >= 2 {
+		if totalVariance := variance(totals); totalVariance > 0 {
+			alpha := round2(float64(k) / float64(k-1) * (1 - itemVarianceSum/totalVariance))
+			analysis.CronbachAlpha = &alpha
+			if n >= minFlaggedAttempts && alpha < lowReliability {
+				analysis.Flags = append(analysis.Flags, FlagLowReliability)
+			}
+		}
+	}
+
+	return analysis
+}
+
+// optionDistribution counts the choices of a multiple choice question
+func optionDistribution(question *models.Question, choices map[string]int, answered int) []OptionCount {
+	correct := correctOption(question.CorrectAnswer)
+	distribution := make([]OptionCount, len(question.Options))
+	for i, text := range question.Options {
+		count := choices[strconv.Itoa(i)]
+		distribution[i] = OptionCount{
+			Option:  i,
+			Text:    text,
+			Correct: i == correct,
+			Count:   count,
+		}
+		if answered > 0 {
+			distribution[i].Percentage = round2(float64(count) / float64(answered) * 100)
+		}
+	}
+	return distribution
+}
+
+// itemFlags returns the reasons a question should be reviewed
+func itemFlags(stats *ItemStatistics) []string {
+	flags := []string{}
+	if stats.PercentCorrect < tooHardPercentCorrect {
+		flags = append(flags, FlagTooHard)
+	}
+	if stats.PercentCorrect > tooEasyPercentCorrect {
+		flags = append(flags, FlagTooEasy)
+	}
+	negative := (stats.DiscriminationIndex != nil && *stats.DiscriminationIndex < 0) ||
+		(stats.PointBiserial != nil && *stats.PointBiserial < 0)
+	if negative {
+		// Stronger students miss it more often, the answer key may be wrong
+		flags = append(flags, FlagNegativeDiscrimination)
+	} else if stats.DiscriminationIndex != nil && *stats.DiscriminationIndex < lowDiscrimination &&
+		stats.PercentCorrect <= tooEasyPercentCorrect {
+		flags = append(flags, FlagLowDiscrimination)
+	}
+
+	keyCount, distractorOverKey, unused := -1, false, false
+	for _, option := range stats.OptionDistribution {
+		if option.Correct {
+			keyCount = option.Count
+		}
+	}
+	for _, option := range stats.OptionDistribution {
+		if option.Correct {
+			continue
+		}
+		if keyCount >= 0 && option.Count > keyCount {
+			distractorOverKey = true
+		}
+		if option.Count == 0 {
+			unused = true
+		}
+	}
+	if distractorOverKey {
+		flags = append(flags, FlagDistractorOverKey)
+	}
+	if unused && stats.Answered >= minFlaggedAttempts {
+		flags = append(flags, FlagUnusedDistractor)
+	}
+	return flags
+}
+
+// correctOption returns the index of the correct multiple choice option, or -1
+func correctOption(correctAnswer interface{}) int {
+	switch v := correctAnswer.(type) {
+	case int:
+		return v
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	case string:
+		if i, err := strconv.Atoi(v); err == nil {
+			return i
+		}
+	}
+	return -1
+}
+
+// pearson returns the correlation of x and y, false if either has no variance
+func pearson(x, y []float64) (float64, bool) {
+	n := len(x)
+	if n < 2 {
+		return 0, false
+	}
+	meanX, meanY := mean(x), mean(y)
+	var cov, varX, varY float64
+	for i := range x {
+		dx, dy := x[i]-meanX, y[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0, false
+	}
+	return cov / math.Sqrt(varX*varY), true
+}
+
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// variance returns the population variance of values
+func variance(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	m := mean(values)
+	sum := 0.0
+	for _, v := range values {
+		sum += (v - m) * (v - m)
+	}
+	return sum / float64(len(values))
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LeaderboardCache is an in-process cache of quiz leaderboard pages. Entries
+// expire after a TTL and are dropped whenever a quiz's results change
+type LeaderboardCache struct {
+	mu        sync.RWMutex
+	ttl       time.Duration
+	entries   map[primitive.ObjectID]map[string]leaderboardCacheEntry
+	nextSweep time.Time
+}
+
+type leaderboardCacheEntry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+// NewLeaderboardCache creates a cache whose entries live for ttl. A zero ttl disables caching
+func NewLeaderboardCache(ttl time.Duration) *LeaderboardCache {
+	return &LeaderboardCache{
+		ttl:     ttl,
+		entries: make(map[primitive.ObjectID]map[string]leaderboardCacheEntry),
+	}
+}
+
+// Get returns the cached value for a quiz and key, if present and not expired
+func (lc *LeaderboardCache) Get(quizID primitive.ObjectID, key string) (interface{}, bool) {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+
+	entry, ok := lc.entries[quizID][key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// Set caches a value for a quiz and key
+func (lc *LeaderboardCache) Set(quizID primitive.ObjectID, key string, value interface{}) {
+	if lc.ttl <= 0 {
+		return
+	}
+
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	now := time.Now()
+	if !now.Before(lc.nextSweep) {
+		lc.sweep(now)
+	}
+
+	pages, ok := lc.entries[quizID]
+	if !ok {
+		pages = make(map[string]leaderboardCacheEntry)
+		lc.entries[quizID] = pages
+	}
+	pages[key] = leaderboardCacheEntry{value: value, expiresAt: now.Add(lc.ttl)}
+}
+
+// sweep drops the expired pages of every quiz, at most once per TTL, so pages
+// of quizzes that are no longer read or written do not accumulate. An expired
+// page is kept for at most two TTLs while pages are being cached
+func (lc *LeaderboardCache) sweep(now time.Time) {
+	for quizID, pages := range lc.entries {
+		for k, entry := range pages {
+			if now.After(entry.expiresAt) {
+				delete(pages, k)
+			}
+		}
+		if len(pages) == 0 {
+			delete(lc.entries, quizID)
+		}
+	}
+	lc.nextSweep = now.Add(lc.ttl)
+}
+
+// Invalidate drops every cached page of a quiz
+func (lc *LeaderboardCache) Invalidate(quizID primitive.ObjectID) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	delete(lc.entries, quizID)
+}
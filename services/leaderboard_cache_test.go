@@ -0,0 +1,33 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestLeaderboardCacheSweepsQuizzesNoLongerWritten(t *testing.T) {
+	cache := NewLeaderboardCache(20 * time.Millisecond)
+	idle, active := primitive.NewObjectID(), primitive.NewObjectID()
+
+	cache.Set(idle, "page:1", 1)
+	if value, ok := cache.Get(idle, "page:1"); !ok || value != 1 {
+		t.Fatalf("got %v, %v, want the cached page", value, ok)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if _, ok := cache.Get(idle, "page:1"); ok {
+		t.Fatal("expired page still returned")
+	}
+
+	// Caching pages of another quiz drops the idle quiz's expired pages
+	cache.Set(active, "page:1", 2)
+	cache.mu.RLock()
+	_, kept := cache.entries[idle]
+	count := len(cache.entries)
+	cache.mu.RUnlock()
+	if kept || count != 1 {
+		t.Fatalf("cache holds %d quizzes (idle quiz kept: %v), want only the active one", count, kept)
+	}
+}
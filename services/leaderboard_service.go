@@ -0,0 +1,532 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"quizmasterapi/config"
+	"quizmasterapi/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// In-memory rank indexes are reloaded after this long, bounding drift when
+// several API instances write to the same database
+const rankIndexTTL = 10 * time.Minute
+
+// LeaderboardService maintains the materialized leaderboard_entries
+// collection, one row per counted attempt, and in-memory rank indexes
+// built from it
+type LeaderboardService struct {
+	collection        *mongo.Collection
+	store             leaderboardStore
+	attemptCollection *mongo.Collection
+	quizCollection    *mongo.Collection
+	cache             *LeaderboardCache
+
+	// boardsMu only guards the map; each board has its own lock, which is
+	// never held across a database call
+	boardsMu sync.Mutex
+	boards   map[primitive.ObjectID]*quizBoard
+}
+
+// quizBoard is the in-memory rank index of one quiz. It keeps the score of
+// every indexed attempt so that applying a change twice, once through a
+// load and once as a write, does not double count it
+type quizBoard struct {
+	mu       sync.Mutex
+	scores   map[primitive.ObjectID]float64 // nil until loaded
+	index    rankIndex
+	loadedAt time.Time
+	loads    []*boardLoad
+}
+
+// boardLoad collects the changes written while a board is being loaded
+type boardLoad struct {
+	changes []boardChange
+}
+
+// boardChange is the state of an attempt's leaderboard row after a write
+type boardChange struct {
+	attemptID primitive.ObjectID
+	score     float64
+	removed   bool
+}
+
+// LeaderboardVerification compares the materialized leaderboard of a quiz with a full recomputation
+type LeaderboardVerification struct {
+	QuizID       primitive.ObjectID   `json:"quiz_id"`
+	Expected     int                  `json:"expected"`
+	Materialized int                  `json:"materialized"`
+	Missing      []primitive.ObjectID `json:"missing"`
+	Extra        []primitive.ObjectID `json:"extra"`
+	Mismatched   []primitive.ObjectID `json:"mismatched"`
+	Consistent   bool                 `json:"consistent"`
+}
+
+// NewLeaderboardService creates a new leaderboard service
+func NewLeaderboardService(cache *LeaderboardCache) *LeaderboardService {
+	collection := config.GetCollection("leaderboard_entries")
+	return &LeaderboardService{
+		collection:        collection,
+		store:             &mongoLeaderboardStore{collection: collection},
+		attemptCollection: config.GetCollection("attempts"),
+		quizCollection:    config.GetCollection("quizzes"),
+		cache:             cache,
+		boards:            make(map[primitive.ObjectID]*quizBoard),
+	}
+}
+
+// Cache returns the cache of rendered leaderboard pages
+func (ls *LeaderboardService) Cache() *LeaderboardCache {
+	return ls.cache
+}
+
+// Collection returns the materialized leaderboard collection
+func (ls *LeaderboardService) Collection() *mongo.Collection {
+	return ls.collection
+}
+
+// Record stores or refreshes the leaderboard row of a completed attempt
+func (ls *LeaderboardService) Record(ctx context.Context, quiz *models.Quiz, attempt *models.QuizAttempt) error {
+	record, ok := newLeaderboardRecord(quiz, attempt)
+	if !ok {
+		return ls.Remove(ctx, attempt)
+	}
+
+	if _, err := ls.store.Replace(ctx, record); err != nil {
+		return fmt.Errorf("failed to record leaderboard entry: %w", err)
+	}
+
+	ls.board(record.QuizID).apply(boardChange{attemptID: record.AttemptID, score: record.Score})
+	ls.cache.Invalidate(record.QuizID)
+	return nil
+}
+
+// Remove drops the leaderboard row of an attempt, e.g. once it is invalidated
+func (ls *LeaderboardService) Remove(ctx context.Context, attempt *models.QuizAttempt) error {
+	previous, err := ls.store.Delete(ctx, attempt.ID)
+	if err != nil {
+		return fmt.Errorf("failed to remove leaderboard entry: %w", err)
+	}
+	if previous == nil {
+		return nil
+	}
+
+	ls.board(previous.QuizID).apply(boardChange{attemptID: previous.AttemptID, removed: true})
+	ls.cache.Invalidate(previous.QuizID)
+	return nil
+}
+
+// BestEntry returns a student's best leaderboard row on a quiz
+func (ls *LeaderboardService) BestEntry(ctx context.Context, quizID, studentID primitive.ObjectID) (*models.LeaderboardRecord, error) {
+	opts := options.FindOne().SetSort(bson.D{
+		{Key: "score", Value: -1},
+		{Key: "time_taken", Value: 1},
+	})
+
+	var record models.LeaderboardRecord
+	err := ls.collection.FindOne(ctx, bson.M{"quiz_id": quizID, "student_id": studentID}, opts).Decode(&record)
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Rank returns the rank of a score on a quiz leaderboard and the number of
+// ranked attempts. Equal scores share a rank, dense ranking does not skip
+// ranks after ties
+func (ls *LeaderboardService) Rank(ctx context.Context, quizID primitive.ObjectID, score float64, dense bool) (rank, total int, err error) {
+	board := ls.board(quizID)
+
+	board.mu.Lock()
+	// A rebuild may reset the board again between loading and locking it
+	for try := 1; board.scores == nil || time.Since(board.loadedAt) > rankIndexTTL; try++ {
+		board.mu.Unlock()
+		if try > 3 {
+			return 0, 0, fmt.Errorf("failed to load leaderboard: index was reset %d times", try-1)
+		}
+		if err := ls.loadBoard(ctx, quizID, board); err != nil {
+			return 0, 0, err
+		}
+		board.mu.Lock()
+	}
+	defer board.mu.Unlock()
+
+	entries, distinct := board.index.Above(score)
+	if dense {
+		return distinct + 1, board.index.Len(), nil
+	}
+	return entries + 1, board.index.Len(), nil
+}
+
+// board returns the in-memory board of a quiz, creating an unloaded one if needed
+func (ls *LeaderboardService) board(quizID primitive.ObjectID) *quizBoard {
+	ls.boardsMu.Lock()
+	defer ls.boardsMu.Unlock()
+
+	board, ok := ls.boards[quizID]
+	if !ok {
+		board = &quizBoard{}
+		ls.boards[quizID] = board
+	}
+	return board
+}
+
+// loadBoard rebuilds the rank index of a quiz from its materialized rows.
+// Rows written while they are read are replayed on top of the loaded ones
+func (ls *LeaderboardService) loadBoard(ctx context.Context, quizID primitive.ObjectID, board *quizBoard) error {
+	load := &boardLoad{}
+	board.mu.Lock()
+	board.loads = append(board.loads, load)
+	board.mu.Unlock()
+
+	startedAt := time.Now()
+	rows, err := ls.store.Find(ctx, &quizID)
+
+	board.mu.Lock()
+	defer board.mu.Unlock()
+
+	for i, pending := range board.loads {
+		if pending == load {
+			board.loads = append(board.loads[:i], board.loads[i+1:]...)
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load leaderboard: %w", err)
+	}
+
+	board.scores = make(map[primitive.ObjectID]float64, len(rows))
+	board.index = rankIndex{}
+	board.loadedAt = startedAt
+	for _, row := range rows {
+		board.set(boardChange{attemptID: row.AttemptID, score: row.Score})
+	}
+	for _, change := range load.changes {
+		board.set(change)
+	}
+	return nil
+}
+
+// apply records a written change in the board and in any load in progress
+func (b *quizBoard) apply(change boardChange) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, load := range b.loads {
+		load.changes = append(load.changes, change)
+	}
+	if b.scores != nil {
+		b.set(change)
+	}
+}
+
+// set brings the index in line with a change. Callers hold mu
+func (b *quizBoard) set(change boardChange) {
+	if score, ok := b.scores[change.attemptID]; ok {
+		b.index.Remove(score)
+		delete(b.scores, change.attemptID)
+	}
+	if !change.removed {
+		b.scores[change.attemptID] = change.score
+		b.index.Add(change.score)
+	}
+}
+
+// reset drops the loaded index so that the next rank query reloads it
+func (b *quizBoard) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.scores = nil
+	b.index = rankIndex{}
+}
+
+// Rebuild recomputes the materialized leaderboard from the attempts
+// collection, for one quiz or, with a nil quizID, for every quiz. Rows are
+// replaced in place and stale rows deleted afterwards, so readers never see
+// an empty leaderboard. An attempt invalidated while the rebuild runs may
+// need another rebuild to drop its row
+func (ls *LeaderboardService) Rebuild(ctx context.Context, quizID *primitive.ObjectID) (int, error) {
+	// Rows written after this read are kept even if the recomputation missed them
+	existing, err := ls.store.Find(ctx, quizID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch leaderboard entries: %w", err)
+	}
+
+	records, err := ls.recompute(ctx, quizID)
+	if err != nil {
+		return 0, err
+	}
+
+	const batchSize = 1000
+	for start := 0; start < len(records); start += batchSize {
+		end := min(start+batchSize, len(records))
+		if err := ls.store.Upsert(ctx, records[start:end]); err != nil {
+			return 0, fmt.Errorf("failed to write leaderboard entries: %w", err)
+		}
+	}
+
+	counted := make(map[primitive.ObjectID]bool, len(records))
+	for _, record := range records {
+		counted[record.AttemptID] = true
+	}
+	var stale []primitive.ObjectID
+	for _, row := range existing {
+		if !counted[row.AttemptID] {
+			stale = append(stale, row.AttemptID)
+		}
+	}
+	for start := 0; start < len(stale); start += batchSize {
+		end := min(start+batchSize, len(stale))
+		if err := ls.store.DeleteIDs(ctx, stale[start:end]); err != nil {
+			return 0, fmt.Errorf("failed to delete stale leaderboard entries: %w", err)
+		}
+	}
+
+	affected := make(map[primitive.ObjectID]bool)
+	if quizID != nil {
+		affected[*quizID] = true
+	}
+	for _, rows := range [][]models.LeaderboardRecord{existing, records} {
+		for _, row := range rows {
+			affected[row.QuizID] = true
+		}
+	}
+	for id := range affected {
+		ls.board(id).reset()
+		ls.cache.Invalidate(id)
+	}
+	return len(records), nil
+}
+
+// Verify compares the materialized leaderboard of a quiz, and its rank
+// index when loaded, with a full recomputation from the attempts collection
+func (ls *LeaderboardService) Verify(ctx context.Context, quizID primitive.ObjectID) (*LeaderboardVerification, error) {
+	expected, err := ls.recompute(ctx, &quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	materialized, err := ls.store.Find(ctx, &quizID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch leaderboard entries: %w", err)
+	}
+
+	report := compareLeaderboard(quizID, expected, materialized)
+
+	// A loaded rank index must hold exactly the expected scores
+	board := ls.board(quizID)
+	board.mu.Lock()
+	if board.scores != nil && !board.index.matches(expected) {
+		report.Consistent = false
+	}
+	board.mu.Unlock()
+
+	return report, nil
+}
+
+// compareLeaderboard reports how materialized rows differ from the expected ones
+func compareLeaderboard(quizID primitive.ObjectID, expected, materialized []models.LeaderboardRecord) *LeaderboardVerification {
+	report := &LeaderboardVerification{
+		QuizID:       quizID,
+		Expected:     len(expected),
+		Materialized: len(materialized),
+		Missing:      []primitive.ObjectID{},
+		Extra:        []primitive.ObjectID{},
+		Mismatched:   []primitive.ObjectID{},
+	}
+
+	stored := make(map[primitive.ObjectID]models.LeaderboardRecord, len(materialized))
+	for _, record := range materialized {
+		stored[record.AttemptID] = record
+	}
+	for _, want := range expected {
+		got, ok := stored[want.AttemptID]
+		if !ok {
+			report.Missing = append(report.Missing, want.AttemptID)
+			continue
+		}
+		delete(stored, want.AttemptID)
+		if got.Score != want.Score || got.MaxScore != want.MaxScore || got.TimeTaken != want.TimeTaken || got.StudentID != want.StudentID {
+			report.Mismatched = append(report.Mismatched, want.AttemptID)
+		}
+	}
+	for id := range stored {
+		report.Extra = append(report.Extra, id)
+	}
+
+	report.Consistent = len(report.Missing) == 0 && len(report.Extra) == 0 && len(report.Mismatched) == 0
+	return report
+}
+
+// recompute builds the leaderboard rows of every counted attempt from scratch
+func (ls *LeaderboardService) recompute(ctx context.Context, quizID *primitive.ObjectID) ([]models.LeaderboardRecord, error) {
+	quizFilter := bson.M{}
+	attemptFilter := bson.M{
+		"completed_at":   bson.M{"$exists": true},
+		"invalidated_at": bson.M{"$exists": false},
+		"mode":           bson.M{"$ne": models.ModePractice},
+	}
+	if quizID != nil {
+		quizFilter["_id"] = *quizID
+		attemptFilter["quiz_id"] = *quizID
+	}
+
+	quizCursor, err := ls.quizCollection.Find(ctx, quizFilter, options.Find().SetProjection(bson.M{
+		"category":         1,
+		"difficulty_level": 1,
+		"course_id":        1,
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch quizzes: %w", err)
+	}
+	var quizzes []models.Quiz
+	if err := quizCursor.All(ctx, &quizzes); err != nil {
+		return nil, fmt.Errorf("failed to decode quizzes: %w", err)
+	}
+
+	cursor, err := ls.attemptCollection.Find(ctx, attemptFilter, options.Find().SetProjection(bson.M{"answers": 0, "assists": 0}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch attempts: %w", err)
+	}
+	var attempts []models.QuizAttempt
+	if err := cursor.All(ctx, &attempts); err != nil {
+		return nil, fmt.Errorf("failed to fetch attempts: %w", err)
+	}
+	return leaderboardRecords(quizzes, attempts), nil
+}
+
+// leaderboardRecords builds the rows of the attempts that count towards the
+// leaderboards of the given quizzes
+func leaderboardRecords(quizzes []models.Quiz, attempts []models.QuizAttempt) []models.LeaderboardRecord {
+	quizByID := make(map[primitive.ObjectID]*models.Quiz, len(quizzes))
+	for i := range quizzes {
+		quizByID[quizzes[i].ID] = &quizzes[i]
+	}
+
+	records := make([]models.LeaderboardRecord, 0)
+	for i := range attempts {
+		quiz, ok := quizByID[attempts[i].QuizID]
+		if !ok {
+			continue
+		}
+		if record, ok := newLeaderboardRecord(quiz, &attempts[i]); ok {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// newLeaderboardRecord builds the leaderboard row of an attempt, reporting
+// false when the attempt does not count towards leaderboards
+func newLeaderboardRecord(quiz *models.Quiz, attempt *models.QuizAttempt) (models.LeaderboardRecord, bool) {
+	if attempt.CompletedAt == nil || attempt.InvalidatedAt != nil || attempt.Mode == models.ModePractice {
+		return models.LeaderboardRecord{}, false
+	}
+
+	percentage := 0.0
+	if attempt.MaxScore > 0 {
+		percentage = roundPoints(attempt.TotalScore / attempt.MaxScore * 100)
+	}
+
+	return models.LeaderboardRecord{
+		AttemptID:       attempt.ID,
+		QuizID:          attempt.QuizID,
+		StudentID:       attempt.StudentID,
+		Score:           attempt.TotalScore,
+		MaxScore:        attempt.MaxScore,
+		Percentage:      percentage,
+		TimeTaken:       attempt.TimeTaken,
+		CompletedAt:     *attempt.CompletedAt,
+		Category:        quiz.Category,
+		DifficultyLevel: quiz.DifficultyLevel,
+		CourseID:        quiz.CourseID,
+	}, true
+}
+
+// leaderboardStore keeps the materialized leaderboard rows
+type leaderboardStore interface {
+	// Replace stores a row, returning the row it replaced if any
+	Replace(ctx context.Context, record models.LeaderboardRecord) (*models.LeaderboardRecord, error)
+	// Delete drops the row of an attempt, returning it if it existed
+	Delete(ctx context.Context, attemptID primitive.ObjectID) (*models.LeaderboardRecord, error)
+	// Find returns the rows of a quiz or, with a nil quizID, of every quiz
+	Find(ctx context.Context, quizID *primitive.ObjectID) ([]models.LeaderboardRecord, error)
+	// Upsert stores rows in bulk
+	Upsert(ctx context.Context, records []models.LeaderboardRecord) error
+	// DeleteIDs drops the rows of the given attempts
+	DeleteIDs(ctx context.Context, attemptIDs []primitive.ObjectID) error
+}
+
+// mongoLeaderboardStore keeps leaderboard rows in a collection
+type mongoLeaderboardStore struct {
+	collection *mongo.Collection
+}
+
+func (s *mongoLeaderboardStore) Replace(ctx context.Context, record models.LeaderboardRecord) (*models.LeaderboardRecord, error) {
+	var previous models.LeaderboardRecord
+	err := s.collection.FindOneAndReplace(ctx, bson.M{"_id": record.AttemptID}, record,
+		options.FindOneAndReplace().SetUpsert(true).SetReturnDocument(options.Before)).Decode(&previous)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &previous, nil
+}
+
+func (s *mongoLeaderboardStore) Delete(ctx context.Context, attemptID primitive.ObjectID) (*models.LeaderboardRecord, error) {
+	var previous models.LeaderboardRecord
+	err := s.collection.FindOneAndDelete(ctx, bson.M{"_id": attemptID}).Decode(&previous)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &previous, nil
+}
+
+func (s *mongoLeaderboardStore) Find(ctx context.Context, quizID *primitive.ObjectID) ([]models.LeaderboardRecord, error) {
+	filter := bson.M{}
+	if quizID != nil {
+		filter["quiz_id"] = *quizID
+	}
+	cursor, err := s.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var records []models.LeaderboardRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (s *mongoLeaderboardStore) Upsert(ctx context.Context, records []models.LeaderboardRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	writes := make([]mongo.WriteModel, 0, len(records))
+	for _, record := range records {
+		writes = append(writes, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": record.AttemptID}).
+			SetReplacement(record).
+			SetUpsert(true))
+	}
+	_, err := s.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+func (s *mongoLeaderboardStore) DeleteIDs(ctx context.Context, attemptIDs []primitive.ObjectID) error {
+	_, err := s.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": attemptIDs}})
+	return err
+}
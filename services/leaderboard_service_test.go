@@ -0,0 +1,311 @@
+package services
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"quizmasterapi/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryLeaderboardStore keeps leaderboard rows in memory
+type memoryLeaderboardStore struct {
+	mu   sync.Mutex
+	rows map[primitive.ObjectID]models.LeaderboardRecord
+}
+
+func newMemoryLeaderboardStore() *memoryLeaderboardStore {
+	return &memoryLeaderboardStore{rows: map[primitive.ObjectID]models.LeaderboardRecord{}}
+}
+
+func (s *memoryLeaderboardStore) Replace(ctx context.Context, record models.LeaderboardRecord) (*models.LeaderboardRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, ok := s.rows[record.AttemptID]
+	s.rows[record.AttemptID] = record
+	if !ok {
+		return nil, nil
+	}
+	return &previous, nil
+}
+
+func (s *memoryLeaderboardStore) Delete(ctx context.Context, attemptID primitive.ObjectID) (*models.LeaderboardRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, ok := s.rows[attemptID]
+	if !ok {
+		return nil, nil
+	}
+	delete(s.rows, attemptID)
+	return &previous, nil
+}
+
+func (s *memoryLeaderboardStore) Find(ctx context.Context, quizID *primitive.ObjectID) ([]models.LeaderboardRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var records []models.LeaderboardRecord
+	for _, record := range s.rows {
+		if quizID == nil || record.QuizID == *quizID {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func (s *memoryLeaderboardStore) Upsert(ctx context.Context, records []models.LeaderboardRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, record := range records {
+		s.rows[record.AttemptID] = record
+	}
+	return nil
+}
+
+func (s *memoryLeaderboardStore) DeleteIDs(ctx context.Context, attemptIDs []primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range attemptIDs {
+		delete(s.rows, id)
+	}
+	return nil
+}
+
+// bruteForceAbove counts the scores, and distinct scores, strictly above score
+func bruteForceAbove(scores []float64, score float64) (entries, distinct int) {
+	seen := map[float64]bool{}
+	for _, s := range scores {
+		if s > score {
+			entries++
+			if !seen[s] {
+				seen[s] = true
+				distinct++
+			}
+		}
+	}
+	return entries, distinct
+}
+
+func TestRankIndexMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var index rankIndex
+	var scores []float64
+
+	for step := 0; step < 5000; step++ {
+		// Few distinct scores so that ties are common
+		score := float64(rng.Intn(40)) / 2
+		if len(scores) > 0 && rng.Intn(3) == 0 {
+			i := rng.Intn(len(scores))
+			score = scores[i]
+			scores = append(scores[:i], scores[i+1:]...)
+			index.Remove(score)
+		} else {
+			scores = append(scores, score)
+			index.Add(score)
+		}
+
+		if index.Len() != len(scores) {
+			t.Fatalf("step %d: index holds %d entries, want %d", step, index.Len(), len(scores))
+		}
+		query := float64(rng.Intn(44)-2) / 2
+		gotEntries, gotDistinct := index.Above(query)
+		wantEntries, wantDistinct := bruteForceAbove(scores, query)
+		if gotEntries != wantEntries || gotDistinct != wantDistinct {
+			t.Fatalf("step %d: Above(%v) = %d, %d, want %d, %d", step, query, gotEntries, gotDistinct, wantEntries, wantDistinct)
+		}
+	}
+
+	// Removing a score that is not indexed is a no-op
+	before := index.Len()
+	index.Remove(1000)
+	if index.Len() != before {
+		t.Fatalf("removing a missing score changed the length from %d to %d", before, index.Len())
+	}
+}
+
+func newTestLeaderboardService(store leaderboardStore) *LeaderboardService {
+	return &LeaderboardService{
+		store:  store,
+		cache:  NewLeaderboardCache(time.Minute),
+		boards: make(map[primitive.ObjectID]*quizBoard),
+	}
+}
+
+// TestLeaderboardMatchesRecompute applies random completions, regrades,
+// invalidations and practice attempts through the incremental path while
+// ranks are read concurrently, then checks that the materialized rows and
+// rank indexes match a recomputation from the final attempts
+func TestLeaderboardMatchesRecompute(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	ctx := context.Background()
+	store := newMemoryLeaderboardStore()
+	ls := newTestLeaderboardService(store)
+
+	quizzes := make([]models.Quiz, 3)
+	for i := range quizzes {
+		quizzes[i] = models.Quiz{ID: primitive.NewObjectID(), Category: models.CategoryScience, CourseID: "CS101"}
+	}
+
+	attempts := make([]models.QuizAttempt, 300)
+	for i := range attempts {
+		completedAt := time.Now().Add(-time.Duration(i) * time.Minute)
+		attempts[i] = models.QuizAttempt{
+			ID:          primitive.NewObjectID(),
+			QuizID:      quizzes[rng.Intn(len(quizzes))].ID,
+			StudentID:   primitive.NewObjectID(),
+			Mode:        models.ModeRanked,
+			TotalScore:  float64(rng.Intn(11)),
+			MaxScore:    10,
+			TimeTaken:   rng.Intn(600),
+			CompletedAt: &completedAt,
+		}
+		if rng.Intn(10) == 0 {
+			attempts[i].Mode = models.ModePractice
+		}
+	}
+	quizByID := map[primitive.ObjectID]*models.Quiz{}
+	for i := range quizzes {
+		quizByID[quizzes[i].ID] = &quizzes[i]
+	}
+
+	// Each worker owns a slice of attempts, like requests for different attempts
+	const workers = 6
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(int64(100 + w)))
+			for i := w; i < len(attempts); i += workers {
+				attempt := &attempts[i]
+				quiz := quizByID[attempt.QuizID]
+				if err := ls.Record(ctx, quiz, attempt); err != nil {
+					t.Errorf("record: %v", err)
+					return
+				}
+				switch rng.Intn(4) {
+				case 0:
+					attempt.TotalScore = float64(rng.Intn(11))
+					if err := ls.Record(ctx, quiz, attempt); err != nil {
+						t.Errorf("regrade: %v", err)
+						return
+					}
+				case 1:
+					invalidatedAt := time.Now()
+					attempt.InvalidatedAt = &invalidatedAt
+					if err := ls.Record(ctx, quiz, attempt); err != nil {
+						t.Errorf("invalidate: %v", err)
+						return
+					}
+				}
+				if _, _, err := ls.Rank(ctx, attempt.QuizID, attempt.TotalScore, rng.Intn(2) == 0); err != nil {
+					t.Errorf("rank: %v", err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+
+	expected := leaderboardRecords(quizzes, attempts)
+	for _, quiz := range quizzes {
+		var want []models.LeaderboardRecord
+		var scores []float64
+		for _, record := range expected {
+			if record.QuizID == quiz.ID {
+				want = append(want, record)
+				scores = append(scores, record.Score)
+			}
+		}
+		materialized, _ := store.Find(ctx, &quiz.ID)
+		if report := compareLeaderboard(quiz.ID, want, materialized); !report.Consistent {
+			t.Fatalf("quiz %s: materialized rows differ from recomputation: %+v", quiz.ID.Hex(), report)
+		}
+
+		board := ls.board(quiz.ID)
+		if board.scores == nil || !board.index.matches(want) {
+			t.Fatalf("quiz %s: rank index differs from recomputation", quiz.ID.Hex())
+		}
+		for score := -1.0; score <= 11; score += 0.5 {
+			entries, distinct := bruteForceAbove(scores, score)
+			rank, total, err := ls.Rank(ctx, quiz.ID, score, false)
+			if err != nil || rank != entries+1 || total != len(scores) {
+				t.Fatalf("quiz %s: Rank(%v) = %d of %d (%v), want %d of %d", quiz.ID.Hex(), score, rank, total, err, entries+1, len(scores))
+			}
+			if rank, _, _ := ls.Rank(ctx, quiz.ID, score, true); rank != distinct+1 {
+				t.Fatalf("quiz %s: dense Rank(%v) = %d, want %d", quiz.ID.Hex(), score, rank, distinct+1)
+			}
+		}
+	}
+}
+
+// racingLeaderboardStore runs a write while a load reads the rows, either
+// before or after the rows are read
+type racingLeaderboardStore struct {
+	*memoryLeaderboardStore
+	before, after func()
+}
+
+func (s *racingLeaderboardStore) Find(ctx context.Context, quizID *primitive.ObjectID) ([]models.LeaderboardRecord, error) {
+	if s.before != nil {
+		s.before()
+	}
+	records, err := s.memoryLeaderboardStore.Find(ctx, quizID)
+	if s.after != nil {
+		s.after()
+	}
+	return records, err
+}
+
+func TestLeaderboardReplaysWritesDuringLoad(t *testing.T) {
+	ctx := context.Background()
+	quiz := &models.Quiz{ID: primitive.NewObjectID()}
+	newAttempt := func(score float64) *models.QuizAttempt {
+		completedAt := time.Now()
+		return &models.QuizAttempt{ID: primitive.NewObjectID(), QuizID: quiz.ID, TotalScore: score, MaxScore: 10, CompletedAt: &completedAt}
+	}
+
+	for _, tc := range []string{"before", "after"} {
+		store := &racingLeaderboardStore{memoryLeaderboardStore: newMemoryLeaderboardStore()}
+		ls := newTestLeaderboardService(store)
+		if err := ls.Record(ctx, quiz, newAttempt(5)); err != nil {
+			t.Fatal(err)
+		}
+		regraded := newAttempt(9)
+		if err := ls.Record(ctx, quiz, regraded); err != nil {
+			t.Fatal(err)
+		}
+
+		write := func() {
+			regraded.TotalScore = 3
+			if err := ls.Record(ctx, quiz, regraded); err != nil {
+				t.Error(err)
+			}
+			if err := ls.Record(ctx, quiz, newAttempt(8)); err != nil {
+				t.Error(err)
+			}
+		}
+		if tc == "before" {
+			store.before = write
+		} else {
+			store.after = write
+		}
+
+		// Scores are now 5, 3 and 8
+		rank, total, err := ls.Rank(ctx, quiz.ID, 5, false)
+		if err != nil || rank != 2 || total != 3 {
+			t.Fatalf("write %s the read: rank of 5 is %d of %d (%v), want 2 of 3", tc, rank, total, err)
+		}
+	}
+}
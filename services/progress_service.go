@@ -0,0 +1,438 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	_ "time/tzdata" // Timezone names must resolve even on hosts without a zoneinfo database
+
+	"quizmasterapi/config"
+	"quizmasterapi/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	DefaultTimezone   = "UTC"
+	DefaultWeeklyGoal = 3
+	MaxWeeklyGoal     = 50
+	// Freeze tokens a student can hold; one is earned per week the goal is met
+	MaxFreezeTokens = 2
+
+	// Days of activity looked at for streaks
+	progressWindowDays = 400
+	// Weeks of goal history reported
+	progressWeeks = 8
+	// Frozen days kept on the progress document
+	maxFrozenDays = 60
+	// Attempts to save freeze and reward changes that raced with another request
+	maxProgressTries = 3
+
+	dayLayout = "2006-01-02"
+)
+
+// ErrInvalidProgressSettings is returned for an unknown timezone or an out of range goal
+var ErrInvalidProgressSettings = errors.New("invalid progress settings")
+
+// ProgressService tracks daily activity streaks and weekly goals from completed attempts
+type ProgressService struct {
+	collection        *mongo.Collection
+	attemptCollection *mongo.Collection
+}
+
+// ProgressReport is a student's streak and weekly goal progress
+type ProgressReport struct {
+	Timezone      string         `json:"timezone"`
+	Today         string         `json:"today"`
+	ActiveToday   bool           `json:"active_today"`
+	CurrentStreak int            `json:"current_streak"` // Days with a completed quiz; frozen days keep the streak alive without adding to it
+	LongestStreak int            `json:"longest_streak"` // Within the last year
+	FreezeTokens  int            `json:"freeze_tokens"`
+	FrozenDays    []string       `json:"frozen_days"`
+	WeeklyGoal    WeekProgress   `json:"weekly_goal"`
+	GoalStreak    int            `json:"goal_streak"` // Consecutive weeks the goal was met
+	Weeks         []WeekProgress `json:"weeks"`       // Most recent first, including the current week
+}
+
+// WeekProgress is the number of quizzes completed in a week against the goal
+type WeekProgress struct {
+	WeekStart string `json:"week_start"`
+	Target    int    `json:"target"`
+	Completed int    `json:"completed"`
+	Met       bool   `json:"met"`
+}
+
+// NewProgressService creates a new progress service
+func NewProgressService() *ProgressService {
+	return &ProgressService{
+		collection:        config.GetCollection("student_progress"),
+		attemptCollection: config.GetCollection("attempts"),
+	}
+}
+
+// Settings returns a student's progress settings, with defaults if they never changed them
+func (ps *ProgressService) Settings(ctx context.Context, studentID primitive.ObjectID) (*models.StudentProgress, error) {
+	var progress models.StudentProgress
+	err := ps.collection.FindOne(ctx, bson.M{"_id": studentID}).Decode(&progress)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return &models.StudentProgress{
+			StudentID:  studentID,
+			Timezone:   DefaultTimezone,
+			WeeklyGoal: DefaultWeeklyGoal,
+			FrozenDays: []string{},
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch progress settings: %w", err)
+	}
+	if progress.FrozenDays == nil {
+		progress.FrozenDays = []string{}
+	}
+	return &progress, nil
+}
+
+// UpdateSettings changes a student's timezone and/or weekly goal. Nil values are left unchanged
+func (ps *ProgressService) UpdateSettings(ctx context.Context, studentID primitive.ObjectID, timezone *string, weeklyGoal *int) (*models.StudentProgress, error) {
+	set := bson.M{"updated_at": time.Now()}
+	setOnInsert := bson.M{
+		"timezone":      DefaultTimezone,
+		"weekly_goal":   DefaultWeeklyGoal,
+		"freeze_tokens": 0,
+		"frozen_days":   []string{},
+	}
+
+	if timezone != nil {
+		if _, err := time.LoadLocation(*timezone); err != nil || *timezone == "" || *timezone == "Local" {
+			return nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidProgressSettings, *timezone)
+		}
+		set["timezone"] = *timezone
+		delete(setOnInsert, "timezone")
+	}
+	if weeklyGoal != nil {
+		if *weeklyGoal < 1 || *weeklyGoal > MaxWeeklyGoal {
+			return nil, fmt.Errorf("%w: weekly goal must be between 1 and %d", ErrInvalidProgressSettings, MaxWeeklyGoal)
+		}
+		set["weekly_goal"] = *weeklyGoal
+		delete(setOnInsert, "weekly_goal")
+	}
+
+	var progress models.StudentProgress
+	err := ps.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": studentID},
+		bson.M{"$set": set, "$setOnInsert": setOnInsert},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&progress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save progress settings: %w", err)
+	}
+	return &progress, nil
+}
+
+// Progress computes a student's streaks and weekly goals as of now. Freeze
+// tokens earned for met goals are granted, and tokens are spent on missed days
+// when that keeps the streak alive; both changes are saved
+func (ps *ProgressService) Progress(ctx context.Context, studentID primitive.ObjectID, now time.Time) (*ProgressReport, error) {
+	for try := 1; ; try++ {
+		settings, err := ps.Settings(ctx, studentID)
+		if err != nil {
+			return nil, err
+		}
+
+		loc, err := time.LoadLocation(settings.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+
+		activity, err := ps.dailyActivity(ctx, studentID, settings.Timezone, now)
+		if err != nil {
+			return nil, err
+		}
+
+		calc := newStreakCalculator(settings, activity, now.In(loc))
+		calc.rewardGoals()
+		calc.freezeMissedDays()
+
+		if !calc.changed() {
+			return calc.report(), nil
+		}
+
+		saved, err := ps.saveTokens(ctx, settings, calc)
+		if err != nil {
+			return nil, err
+		}
+		if saved || try == maxProgressTries {
+			return calc.report(), nil
+		}
+	}
+}
+
+// CurrentStreak returns a student's current daily streak
+func (ps *ProgressService) CurrentStreak(ctx context.Context, studentID primitive.ObjectID) (int, error) {
+	report, err := ps.Progress(ctx, studentID, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return report.CurrentStreak, nil
+}
+
+// dailyActivity counts completed attempts per local day over the progress
+// window. Invalidated attempts do not count towards activity or streaks
+func (ps *ProgressService) dailyActivity(ctx context.Context, studentID primitive.ObjectID, timezone string, now time.Time) (map[string]int, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"student_id":     studentID,
+			"completed_at":   bson.M{"$gte": now.AddDate(0, 0, -progressWindowDays)},
+			"invalidated_at": bson.M{"$exists": false},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{"$dateToString": bson.M{
+				"format":   "%Y-%m-%d",
+				"date":     "$completed_at",
+				"timezone": timezone,
+			}},
+			"count": bson.M{"$sum": 1},
+		}}},
+	}
+
+	cursor, err := ps.attemptCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate activity: %w", err)
+	}
+
+	var days []struct {
+		Day   string `bson:"_id"`
+		Count int    `bson:"count"`
+	}
+	if err := cursor.All(ctx, &days); err != nil {
+		return nil, fmt.Errorf("failed to aggregate activity: %w", err)
+	}
+
+	activity := make(map[string]int, len(days))
+	for _, day := range days {
+		activity[day.Day] = day.Count
+	}
+	return activity, nil
+}
+
+// saveTokens stores token and freeze changes, unless another request changed them first
+func (ps *ProgressService) saveTokens(ctx context.Context, previous *models.StudentProgress, calc *streakCalculator) (bool, error) {
+	filter := bson.M{
+		"_id":           previous.StudentID,
+		"freeze_tokens": previous.FreezeTokens,
+	}
+	if previous.LastRewardedWeek != "" {
+		filter["last_rewarded_week"] = previous.LastRewardedWeek
+	} else {
+		filter["last_rewarded_week"] = bson.M{"$exists": false}
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"freeze_tokens":      calc.tokens,
+			"last_rewarded_week": calc.lastRewardedWeek,
+			"updated_at":         time.Now(),
+		},
+		"$setOnInsert": bson.M{
+			"timezone":    previous.Timezone,
+			"weekly_goal": previous.WeeklyGoal,
+		},
+	}
+	if len(calc.newlyFrozen) > 0 {
+		update["$push"] = bson.M{"frozen_days": bson.M{"$each": calc.newlyFrozen, "$slice": -maxFrozenDays}}
+	}
+
+	// The first save creates the document; a concurrent first save hits the duplicate _id
+	result, err := ps.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to save streak progress: %w", err)
+	}
+	return result.MatchedCount > 0 || result.UpsertedCount > 0, nil
+}
+
+// streakCalculator derives streaks and goals from daily activity in the student's timezone
+type streakCalculator struct {
+	settings *models.StudentProgress
+	activity map[string]int
+	frozen   map[string]bool
+	today    time.Time
+
+	tokens           int
+	lastRewardedWeek string
+	newlyFrozen      []string
+}
+
+func newStreakCalculator(settings *models.StudentProgress, activity map[string]int, now time.Time) *streakCalculator {
+	frozen := make(map[string]bool, len(settings.FrozenDays))
+	for _, day := range settings.FrozenDays {
+		frozen[day] = true
+	}
+
+	return &streakCalculator{
+		settings:         settings,
+		activity:         activity,
+		frozen:           frozen,
+		today:            time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()),
+		tokens:           settings.FreezeTokens,
+		lastRewardedWeek: settings.LastRewardedWeek,
+	}
+}
+
+func (sc *streakCalculator) changed() bool {
+	return sc.tokens != sc.settings.FreezeTokens || sc.lastRewardedWeek != sc.settings.LastRewardedWeek || len(sc.newlyFrozen) > 0
+}
+
+// rewardGoals grants a freeze token for each week, oldest first, whose goal
+// was met and not yet rewarded
+func (sc *streakCalculator) rewardGoals() {
+	weeks := sc.weeks()
+	for i := len(weeks) - 1; i >= 0; i-- {
+		week := weeks[i]
+		if !week.Met || week.WeekStart <= sc.lastRewardedWeek {
+			continue
+		}
+		if sc.tokens < MaxFreezeTokens {
+			sc.tokens++
+		}
+		sc.lastRewardedWeek = week.WeekStart
+	}
+}
+
+// freezeMissedDays spends tokens on the days missed since the last active or
+// frozen day, provided there are enough tokens to cover all of them. Today
+// never needs a freeze since it is not over yet
+func (sc *streakCalculator) freezeMissedDays() {
+	if sc.active(sc.today) {
+		return
+	}
+
+	var missed []string
+	day := sc.today.AddDate(0, 0, -1)
+	for i := 0; i < progressWindowDays; i++ {
+		if sc.active(day) || sc.frozen[dayKey(day)] {
+			break
+		}
+		missed = append(missed, dayKey(day))
+		day = day.AddDate(0, 0, -1)
+	}
+
+	// Nothing to save if the streak already ended before the missed days
+	if len(missed) == 0 || len(missed) > sc.tokens || (!sc.active(day) && !sc.frozen[dayKey(day)]) {
+		return
+	}
+
+	for i := len(missed) - 1; i >= 0; i-- {
+		sc.frozen[missed[i]] = true
+		sc.newlyFrozen = append(sc.newlyFrozen, missed[i])
+	}
+	sc.tokens -= len(missed)
+}
+
+func (sc *streakCalculator) report() *ProgressReport {
+	weeks := sc.weeks()
+
+	goalStreak := 0
+	for i, week := range weeks {
+		if !week.Met {
+			// The current week can still be met
+			if i == 0 {
+				continue
+			}
+			break
+		}
+		goalStreak++
+	}
+
+	frozenDays := append(append([]string{}, sc.settings.FrozenDays...), sc.newlyFrozen...)
+	if len(frozenDays) > maxFrozenDays {
+		frozenDays = frozenDays[len(frozenDays)-maxFrozenDays:]
+	}
+
+	return &ProgressReport{
+		Timezone:      sc.settings.Timezone,
+		Today:         dayKey(sc.today),
+		ActiveToday:   sc.active(sc.today),
+		CurrentStreak: sc.currentStreak(),
+		LongestStreak: sc.longestStreak(),
+		FreezeTokens:  sc.tokens,
+		FrozenDays:    frozenDays,
+		WeeklyGoal:    weeks[0],
+		GoalStreak:    goalStreak,
+		Weeks:         weeks,
+	}
+}
+
+// currentStreak counts active days back from today, or from yesterday while
+// today has no activity yet
+func (sc *streakCalculator) currentStreak() int {
+	day := sc.today
+	if !sc.active(day) {
+		day = day.AddDate(0, 0, -1)
+	}
+
+	streak := 0
+	for i := 0; i < progressWindowDays; i++ {
+		switch {
+		case sc.active(day):
+			streak++
+		case sc.frozen[dayKey(day)]:
+		default:
+			return streak
+		}
+		day = day.AddDate(0, 0, -1)
+	}
+	return streak
+}
+
+func (sc *streakCalculator) longestStreak() int {
+	longest, streak := 0, 0
+	day := sc.today.AddDate(0, 0, -progressWindowDays)
+	for !day.After(sc.today) {
+		switch {
+		case sc.active(day):
+			streak++
+			if streak > longest {
+				longest = streak
+			}
+		case sc.frozen[dayKey(day)], day.Equal(sc.today):
+		default:
+			streak = 0
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return longest
+}
+
+// weeks reports goal progress for the recent weeks, current week first
+func (sc *streakCalculator) weeks() []WeekProgress {
+	start := startOfWeek(sc.today)
+
+	weeks := make([]WeekProgress, 0, progressWeeks)
+	for w := 0; w < progressWeeks; w++ {
+		weekStart := start.AddDate(0, 0, -7*w)
+		completed := 0
+		for d := 0; d < 7; d++ {
+			completed += sc.activity[dayKey(weekStart.AddDate(0, 0, d))]
+		}
+		weeks = append(weeks, WeekProgress{
+			WeekStart: dayKey(weekStart),
+			Target:    sc.settings.WeeklyGoal,
+			Completed: completed,
+			Met:       completed >= sc.settings.WeeklyGoal,
+		})
+	}
+	return weeks
+}
+
+func (sc *streakCalculator) active(day time.Time) bool {
+	return sc.activity[dayKey(day)] > 0
+}
+
+func dayKey(day time.Time) string {
+	return day.Format(dayLayout)
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"quizmasterapi/config"
+	"quizmasterapi/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// QuizStatsService maintains the attempt count and average score stored on each quiz
+type QuizStatsService struct {
+	collection        *mongo.Collection
+	attemptCollection *mongo.Collection
+}
+
+// NewQuizStatsService creates a new quiz stats service
+func NewQuizStatsService() *QuizStatsService {
+	return &QuizStatsService{
+		collection:        config.GetCollection("quizzes"),
+		attemptCollection: config.GetCollection("attempts"),
+	}
+}
+
+// RecordCompletion counts a completed attempt towards the quiz stats
+func (qs *QuizStatsService) RecordCompletion(ctx context.Context, quizID primitive.ObjectID, percentage float64) error {
+	return qs.apply(ctx, quizID, 1, percentage)
+}
+
+// RemoveAttempt takes a previously counted attempt out of the quiz stats
+func (qs *QuizStatsService) RemoveAttempt(ctx context.Context, quizID primitive.ObjectID, percentage float64) error {
+	return qs.apply(ctx, quizID, -1, -percentage)
+}
+
+// AdjustPercentage applies a change in a counted attempt's percentage
+func (qs *QuizStatsService) AdjustPercentage(ctx context.Context, quizID primitive.ObjectID, delta float64) error {
+	if delta == 0 {
+		return nil
+	}
+	return qs.apply(ctx, quizID, 0, delta)
+}
+
+// apply updates the counters and recomputes the average in a single update
+func (qs *QuizStatsService) apply(ctx context.Context, quizID primitive.ObjectID, countDelta int, percentageDelta float64) error {
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"attempt_count":  bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$attempt_count", 0}}, countDelta}},
+			"percentage_sum": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$percentage_sum", 0}}, percentageDelta}},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"average_score": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$attempt_count", 0}},
+				bson.M{"$round": bson.A{bson.M{"$divide": bson.A{"$percentage_sum", "$attempt_count"}}, 2}},
+				0,
+			}},
+		}}},
+	}
+
+	if _, err := qs.collection.UpdateOne(ctx, bson.M{"_id": quizID}, update); err != nil {
+		return fmt.Errorf("failed to update quiz stats: %w", err)
+	}
+	return nil
+}
+
+// Backfill computes the stats of quizzes created before they were maintained
+// from their counted attempts. Sorting and paginating by the stats relies on
+// every quiz having them. It returns the number of quizzes updated
+func (qs *QuizStatsService) Backfill(ctx context.Context) (int, error) {
+	missing := bson.M{"$or": bson.A{
+		bson.M{"attempt_count": bson.M{"$exists": false}},
+		bson.M{"average_score": bson.M{"$exists": false}},
+	}}
+	cursor, err := qs.collection.Find(ctx, missing, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch quizzes without stats: %w", err)
+	}
+	var quizzes []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &quizzes); err != nil {
+		return 0, fmt.Errorf("failed to fetch quizzes without stats: %w", err)
+	}
+	if len(quizzes) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]primitive.ObjectID, len(quizzes))
+	for i, quiz := range quizzes {
+		ids[i] = quiz.ID
+	}
+	cursor, err = qs.attemptCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"quiz_id":        bson.M{"$in": ids},
+			"completed_at":   bson.M{"$exists": true},
+			"invalidated_at": bson.M{"$exists": false},
+			"mode":           bson.M{"$ne": models.ModePractice},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":            "$quiz_id",
+			"attempt_count":  bson.M{"$sum": 1},
+			"percentage_sum": bson.M{"$sum": "$percentage"},
+		}}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to aggregate quiz stats: %w", err)
+	}
+	var totals []struct {
+		QuizID        primitive.ObjectID `bson:"_id"`
+		AttemptCount  int                `bson:"attempt_count"`
+		PercentageSum float64            `bson:"percentage_sum"`
+	}
+	if err := cursor.All(ctx, &totals); err != nil {
+		return 0, fmt.Errorf("failed to aggregate quiz stats: %w", err)
+	}
+	byQuiz := make(map[primitive.ObjectID]int, len(totals))
+	for i, total := range totals {
+		byQuiz[total.QuizID] = i
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(ids))
+	for _, id := range ids {
+		count, sum, average := 0, 0.0, 0.0
+		if i, ok := byQuiz[id]; ok {
+			count, sum = totals[i].AttemptCount, totals[i].PercentageSum
+			average = math.Round(sum/float64(count)*100) / 100
+		}
+		// Quizzes whose stats were set meanwhile are left alone
+		filter := bson.M{"_id": id, "$or": missing["$or"]}
+		writes = append(writes, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(bson.M{
+			"$set": bson.M{
+				"attempt_count":  count,
+				"percentage_sum": sum,
+				"average_score":  average,
+			},
+		}))
+	}
+
+	result, err := qs.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return 0, fmt.Errorf("failed to backfill quiz stats: %w", err)
+	}
+	return int(result.ModifiedCount), nil
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"math/rand"
+
+	"quizmasterapi/models"
+)
+
+// rankIndex is an order-statistic treap of scores. It answers how many
+// entries (and how many distinct scores) lie above a score in O(log n),
+// which gives standard and dense competition ranks without scanning
+type rankIndex struct {
+	root *rankNode
+}
+
+type rankNode struct {
+	score    float64
+	count    int // Entries with this score
+	total    int // Entries in this subtree
+	distinct int // Distinct scores in this subtree
+	priority uint32
+	left     *rankNode
+	right    *rankNode
+}
+
+func (n *rankNode) update() {
+	n.total = n.count + n.left.size() + n.right.size()
+	n.distinct = 1 + n.left.distinctScores() + n.right.distinctScores()
+}
+
+func (n *rankNode) size() int {
+	if n == nil {
+		return 0
+	}
+	return n.total
+}
+
+func (n *rankNode) distinctScores() int {
+	if n == nil {
+		return 0
+	}
+	return n.distinct
+}
+
+// Add records one entry with the given score
+func (ri *rankIndex) Add(score float64) {
+	ri.root = insertRankNode(ri.root, score)
+}
+
+// Remove forgets one entry with the given score, if present
+func (ri *rankIndex) Remove(score float64) {
+	ri.root = removeRankNode(ri.root, score)
+}
+
+// Len returns the number of entries
+func (ri *rankIndex) Len() int {
+	return ri.root.size()
+}
+
+// Above returns the number of entries and of distinct scores strictly above score
+func (ri *rankIndex) Above(score float64) (entries, distinct int) {
+	n := ri.root
+	for n != nil {
+		switch {
+		case score < n.score:
+			entries += n.count + n.right.size()
+			distinct += 1 + n.right.distinctScores()
+			n = n.left
+		case score > n.score:
+			n = n.right
+		default:
+			entries += n.right.size()
+			distinct += n.right.distinctScores()
+			return entries, distinct
+		}
+	}
+	return entries, distinct
+}
+
+// matches reports whether the index ranks every expected row as an index
+// built from exactly those rows would
+func (ri *rankIndex) matches(expected []models.LeaderboardRecord) bool {
+	var fresh rankIndex
+	for _, record := range expected {
+		fresh.Add(record.Score)
+	}
+	if ri.Len() != fresh.Len() {
+		return false
+	}
+	for _, record := range expected {
+		gotEntries, gotDistinct := ri.Above(record.Score)
+		wantEntries, wantDistinct := fresh.Above(record.Score)
+		if gotEntries != wantEntries || gotDistinct != wantDistinct {
+			return false
+		}
+	}
+	return true
+}
+
+func insertRankNode(n *rankNode, score float64) *rankNode {
+	if n == nil {
+		return &rankNode{score: score, count: 1, total: 1, distinct: 1, priority: rand.Uint32()}
+	}
+
+	switch {
+	case score < n.score:
+		n.left = insertRankNode(n.left, score)
+		if n.left.priority > n.priority {
+			n = rotateRight(n)
+		}
+	case score > n.score:
+		n.right = insertRankNode(n.right, score)
+		if n.right.priority > n.priority {
+			n = rotateLeft(n)
+		}
+	default:
+		n.count++
+	}
+	n.update()
+	return n
+}
+
+func removeRankNode(n *rankNode, score float64) *rankNode {
+	if n == nil {
+		return nil
+	}
+
+	switch {
+	case score < n.score:
+		n.left = removeRankNode(n.left, score)
+	case score > n.score:
+		n.right = removeRankNode(n.right, score)
+	case n.count > 1:
+		n.count--
+	default:
+		return deleteRankNode(n)
+	}
+	n.update()
+	return n
+}
+
+// deleteRankNode rotates n down until it is a leaf and drops it
+func deleteRankNode(n *rankNode) *rankNode {
+	switch {
+	case n.left == nil:
+		return n.right
+	case n.right == nil:
+		return n.left
+	case n.left.priority > n.right.priority:
+		n = rotateRight(n)
+		n.right = deleteRankNode(n.right)
+	default:
+		n = rotateLeft(n)
+		n.left = deleteRankNode(n.left)
+	}
+	n.update()
+	return n
+}
+
+func rotateRight(n *rankNode) *rankNode {
+	l := n.left
+	n.left = l.right
+	n.update()
+	l.right = n
+	l.update()
+	return l
+}
+
+func rotateLeft(n *rankNode) *rankNode {
+	r := n.right
+	n.right = r.left
+	n.update()
+	r.left = n
+	r.update()
+	return r
+}
@@ -0,0 +1,341 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"quizmasterapi/config"
+	"quizmasterapi/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	DefaultReviewSessionSize = 20
+	MaxReviewSessionSize     = 100
+
+	// SM-2 parameters
+	initialEaseFactor = 2.5
+	minEaseFactor     = 1.3
+	// Quality of a review, from 0 (blackout) to 5 (perfect recall); 3 and up is a pass
+	MaxReviewQuality  = 5
+	passReviewQuality = 3
+)
+
+var (
+	// ErrReviewItemNotFound is returned when a review item does not exist, is not the student's or its question was deleted
+	ErrReviewItemNotFound = errors.New("review item not found")
+	// ErrReviewNotDue is returned when an item is graded before the day it is due
+	ErrReviewNotDue = errors.New("review item is not due yet")
+	// ErrReviewConflict is returned when an item was graded concurrently
+	ErrReviewConflict = errors.New("review item was graded concurrently")
+)
+
+// ReviewService schedules spaced repetition reviews of the questions students missed
+type ReviewService struct {
+	collection     *mongo.Collection
+	quizCollection *mongo.Collection
+}
+
+// ReviewSession is the practice session of the items due today
+type ReviewSession struct {
+	Date  string       `json:"date"` // Student's local day, YYYY-MM-DD
+	Due   int          `json:"due"`  // Items due by the end of the day, the session holds at most limit of them
+	Items []ReviewCard `json:"items"`
+}
+
+// ReviewCard is a due item with its question
+type ReviewCard struct {
+	ItemID       primitive.ObjectID `json:"item_id"`
+	QuizID       primitive.ObjectID `json:"quiz_id"`
+	QuizTitle    string             `json:"quiz_title"`
+	Question     models.Question    `json:"question"`
+	Repetitions  int                `json:"repetitions"`
+	IntervalDays int                `json:"interval_days"`
+	Lapses       int                `json:"lapses"`
+	DueAt        time.Time          `json:"due_at"`
+}
+
+// NewReviewService creates a new review service
+func NewReviewService() *ReviewService {
+	return &ReviewService{
+		collection:     config.GetCollection("review_items"),
+		quizCollection: config.GetCollection("quizzes"),
+	}
+}
+
+// QueueMissed adds the questions a completed attempt answered incorrectly or
+// skipped to the student's review queue, due as soon as the quiz review
+// policy reveals their answers. Questions of quizzes that never reveal them
+// are not queued. A question already in the queue starts its schedule over
+func (rs *ReviewService) QueueMissed(ctx context.Context, quiz *models.Quiz, attempt *models.QuizAttempt) error {
+	now := time.Now()
+	dueAt := now
+	if !AnswersRevealed(quiz, now) {
+		if quiz.ReviewPolicy != models.ReviewAfterClose || quiz.ClosesAt == nil {
+			return nil
+		}
+		dueAt = *quiz.ClosesAt
+	}
+
+	writes := []mongo.WriteModel{}
+	for _, answer := range attempt.Answers {
+		if answer.IsCorrect {
+			continue
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{
+				"student_id":  attempt.StudentID,
+				"quiz_id":     attempt.QuizID,
+				"question_id": answer.QuestionID,
+			}).
+			SetUpdate(bson.M{
+				"$set": bson.M{
+					"attempt_id":    attempt.ID,
+					"repetitions":   0,
+					"interval_days": 0,
+					"due_at":        dueAt,
+					"updated_at":    now,
+				},
+				"$inc":         bson.M{"lapses": 1},
+				"$setOnInsert": bson.M{"ease_factor": initialEaseFactor, "created_at": now},
+			}).
+			SetUpsert(true))
+	}
+	if len(writes) == 0 {
+		return nil
+	}
+
+	if _, err := rs.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+		return fmt.Errorf("failed to queue missed questions: %w", err)
+	}
+	return nil
+}
+
+// Today returns the items due by the end of the student's day, most overdue
+// first, as a practice session of at most limit items. Items whose question
+// was deleted are dropped from the queue, and items of quizzes that no longer
+// reveal their answers, e.g. after a review policy change, are held back
+func (rs *ReviewService) Today(ctx context.Context, studentID primitive.ObjectID, timezone string, limit int, now time.Time) (*ReviewSession, error) {
+	today := now.In(reviewLocation(timezone))
+	filter := bson.M{
+		"student_id": studentID,
+		"due_at":     bson.M{"$lt": endOfDay(today)},
+	}
+
+	due, err := rs.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count due reviews: %w", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "due_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := rs.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch due reviews: %w", err)
+	}
+	var items []models.ReviewItem
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, fmt.Errorf("failed to fetch due reviews: %w", err)
+	}
+
+	quizIDs := []primitive.ObjectID{}
+	seen := map[primitive.ObjectID]bool{}
+	for _, item := range items {
+		if !seen[item.QuizID] {
+			seen[item.QuizID] = true
+			quizIDs = append(quizIDs, item.QuizID)
+		}
+	}
+	quizzes, err := rs.quizzes(ctx, quizIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &ReviewSession{
+		Date:  today.Format(dayLayout),
+		Due:   int(due),
+		Items: make([]ReviewCard, 0, len(items)),
+	}
+	orphans := []primitive.ObjectID{}
+	for _, item := range items {
+		quiz, ok := quizzes[item.QuizID]
+		var question *models.Question
+		if ok {
+			question = questionsByID(quiz)[item.QuestionID]
+		}
+		if question == nil {
+			orphans = append(orphans, item.ID)
+			continue
+		}
+		if !AnswersRevealed(quiz, now) {
+			session.Due--
+			continue
+		}
+		// The answer is only given once the item is graded
+		card := *question
+		card.CorrectAnswer = nil
+		card.Explanation = ""
+		session.Items = append(session.Items, ReviewCard{
+			ItemID:       item.ID,
+			QuizID:       item.QuizID,
+			QuizTitle:    quiz.Title,
+			Question:     card,
+			Repetitions:  item.Repetitions,
+			IntervalDays: item.IntervalDays,
+			Lapses:       item.Lapses,
+			DueAt:        item.DueAt,
+		})
+	}
+
+	if len(orphans) > 0 {
+		if _, err := rs.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": orphans}}); err != nil {
+			return nil, fmt.Errorf("failed to drop reviews of deleted questions: %w", err)
+		}
+		session.Due -= len(orphans)
+	}
+	return session, nil
+}
+
+// Item returns a student's review item with its quiz and question
+func (rs *ReviewService) Item(ctx context.Context, studentID, itemID primitive.ObjectID) (*models.ReviewItem, *models.Quiz, *models.Question, error) {
+	var item models.ReviewItem
+	err := rs.collection.FindOne(ctx, bson.M{"_id": itemID, "student_id": studentID}).Decode(&item)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil, nil, ErrReviewItemNotFound
+	}
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to fetch review item: %w", err)
+	}
+
+	quizzes, err := rs.quizzes(ctx, []primitive.ObjectID{item.QuizID})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	quiz, ok := quizzes[item.QuizID]
+	if !ok {
+		return nil, nil, nil, ErrReviewItemNotFound
+	}
+	question, ok := questionsByID(quiz)[item.QuestionID]
+	if !ok {
+		return nil, nil, nil, ErrReviewItemNotFound
+	}
+	return &item, quiz, question, nil
+}
+
+// Grade reschedules a due item from the quality of the review. The update
+// only applies if the item was not graded since it was read
+func (rs *ReviewService) Grade(ctx context.Context, item *models.ReviewItem, quality int, timezone string, now time.Time) (*models.ReviewItem, error) {
+	if !item.DueAt.Before(endOfDay(now.In(reviewLocation(timezone)))) {
+		return nil, ErrReviewNotDue
+	}
+
+	next := ScheduleReview(*item, quality, now)
+	result, err := rs.collection.UpdateOne(ctx, bson.M{
+		"_id":        item.ID,
+		"student_id": item.StudentID,
+		"updated_at": item.UpdatedAt,
+	}, bson.M{"$set": bson.M{
+		"ease_factor":      next.EaseFactor,
+		"interval_days":    next.IntervalDays,
+		"repetitions":      next.Repetitions,
+		"lapses":           next.Lapses,
+		"due_at":           next.DueAt,
+		"last_reviewed_at": next.LastReviewedAt,
+		"updated_at":       next.UpdatedAt,
+	}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reschedule review: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrReviewConflict
+	}
+	return &next, nil
+}
+
+// ScheduleReview applies the SM-2 algorithm to an item reviewed with the
+// given quality. A pass grows the interval from 1 to 6 days, then by the
+// ease factor; a fail starts over with a review the next day
+func ScheduleReview(item models.ReviewItem, quality int, now time.Time) models.ReviewItem {
+	if item.EaseFactor == 0 {
+		item.EaseFactor = initialEaseFactor
+	}
+
+	if quality >= passReviewQuality {
+		switch item.Repetitions {
+		case 0:
+			item.IntervalDays = 1
+		case 1:
+			item.IntervalDays = 6
+		default:
+			item.IntervalDays = int(math.Round(float64(item.IntervalDays) * item.EaseFactor))
+		}
+		item.Repetitions++
+	} else {
+		item.Repetitions = 0
+		item.IntervalDays = 1
+		item.Lapses++
+	}
+
+	miss := float64(MaxReviewQuality - quality)
+	item.EaseFactor = math.Max(minEaseFactor, round2(item.EaseFactor+0.1-miss*(0.08+miss*0.02)))
+	item.DueAt = now.AddDate(0, 0, item.IntervalDays)
+	item.LastReviewedAt = &now
+	item.UpdatedAt = now
+	return item
+}
+
+// AnswersRevealed reports whether the review policy of a quiz lets students
+// see its correct answers and explanations at the given time
+func AnswersRevealed(quiz *models.Quiz, now time.Time) bool {
+	switch quiz.ReviewPolicy {
+	case models.ReviewAfterClose:
+		return quiz.ClosesAt != nil && !now.Before(*quiz.ClosesAt)
+	case models.ReviewNever:
+		return false
+	}
+	return true
+}
+
+// quizzes returns the quizzes with the given IDs by ID
+func (rs *ReviewService) quizzes(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]*models.Quiz, error) {
+	quizzes := make(map[primitive.ObjectID]*models.Quiz, len(ids))
+	if len(ids) == 0 {
+		return quizzes, nil
+	}
+
+	opts := options.Find().SetProjection(bson.M{"title": 1, "questions": 1, "review_policy": 1, "closes_at": 1})
+	cursor, err := rs.quizCollection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch review quizzes: %w", err)
+	}
+	var found []models.Quiz
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, fmt.Errorf("failed to fetch review quizzes: %w", err)
+	}
+	for i := range found {
+		quizzes[found[i].ID] = &found[i]
+	}
+	return quizzes, nil
+}
+
+// reviewLocation resolves a student's timezone, falling back to UTC
+func reviewLocation(timezone string) *time.Location {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// endOfDay returns the next local midnight after t
+func endOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+}
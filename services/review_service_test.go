@@ -0,0 +1,34 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"quizmasterapi/models"
+)
+
+func TestAnswersRevealed(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		policy   models.ReviewPolicy
+		closesAt *time.Time
+		want     bool
+	}{
+		{"default", "", nil, true},
+		{"immediately", models.ReviewImmediately, &future, true},
+		{"after close, still open", models.ReviewAfterClose, &future, false},
+		{"after close, closed", models.ReviewAfterClose, &past, true},
+		{"after close, closing now", models.ReviewAfterClose, &now, true},
+		{"after close without a closing time", models.ReviewAfterClose, nil, false},
+		{"never", models.ReviewNever, &past, false},
+	}
+	for _, tt := range tests {
+		quiz := &models.Quiz{ReviewPolicy: tt.policy, ClosesAt: tt.closesAt}
+		if got := AnswersRevealed(quiz, now); got != tt.want {
+			t.Errorf("%s: AnswersRevealed = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+// Package services provides business logic services
+package services
+
+import (
+	"errors"
+	"math"
+
+	"quizmasterapi/models"
+)
+
+// DefaultDifficultyWeights are used when difficulty weighting is enabled without
+// explicit weights, and to weight quizzes in the global leaderboard
+var DefaultDifficultyWeights = models.DifficultyWeights{Easy: 1, Medium: 1.25, Hard: 1.5}
+
+// resolveModifiers validates modifiers and fills in default values
+func resolveModifiers(modifiers *models.ScoringModifiers) (*models.ScoringModifiers, error) {
+	if modifiers == nil {
+		return nil, nil
+	}
+	resolved := &models.ScoringModifiers{}
+
+	if streak := modifiers.Streak; streak != nil {
+		s := *streak
+		if s.MinStreak == 0 {
+			s.MinStreak = 3
+		}
+		if s.Step == 0 {
+			s.Step = 0.1
+		}
+		if s.MaxMultiplier == 0 {
+			s.MaxMultiplier = 1.5
+		}
+		if s.MinStreak < 1 || s.Step < 0 || s.MaxMultiplier < 1 {
+			return nil, errors.New("streak bonus requires min_streak >= 1, step >= 0 and max_multiplier >= 1")
+		}
+		resolved.Streak = &s
+	}
+
+	if perfect := modifiers.Perfect; perfect != nil {
+		p := *perfect
+		if p.Percent == 0 {
+			p.Percent = 0.1
+		}
+		if p.Percent < 0 {
+			return nil, errors.New("perfect bonus percent must not be negative")
+		}
+		resolved.Perfect = &p
+	}
+
+	if difficulty := modifiers.Difficulty; difficulty != nil {
+		d := *difficulty
+		if d.Easy == 0 {
+			d.Easy = DefaultDifficultyWeights.Easy
+		}
+		if d.Medium == 0 {
+			d.Medium = DefaultDifficultyWeights.Medium
+		}
+		if d.Hard == 0 {
+			d.Hard = DefaultDifficultyWeights.Hard
+		}
+		if d.Easy < 0 || d.Medium < 0 || d.Hard < 0 {
+			return nil, errors.New("difficulty weights must not be negative")
+		}
+		resolved.Difficulty = &d
+	}
+
+	return resolved, nil
+}
+
+// DifficultyMultiplier returns the weight of a difficulty level
+func DifficultyMultiplier(weights models.DifficultyWeights, level models.DifficultyLevel) float64 {
+	switch level {
+	case models.LevelEasy:
+		return weights.Easy
+	case models.LevelMedium:
+		return weights.Medium
+	case models.LevelHard:
+		return weights.Hard
+	}
+	return 1
+}
+
+// answerScorer scores the answers of one attempt in submission order,
+// tracking the current streak of correct answers
+type answerScorer struct {
+	ss         *ScoringService
+	strategy   ScoringStrategy
+	modifiers  *models.ScoringModifiers
+	difficulty float64
+	streak     int
+}
+
+// newAnswerScorer creates a scorer for an attempt on the quiz with the given policy
+func (ss *ScoringService) newAnswerScorer(quiz *models.Quiz, policy *models.ScoringPolicy) (*answerScorer, error) {
+	strategy, err := ss.Strategy(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	scorer := &answerScorer{ss: ss, strategy: strategy, difficulty: 1}
+	if policy != nil && policy.Modifiers != nil {
+		scorer.modifiers, err = resolveModifiers(policy.Modifiers)
+		if err != nil {
+			return nil, err
+		}
+		if scorer.modifiers.Difficulty != nil {
+			scorer.difficulty = DifficultyMultiplier(*scorer.modifiers.Difficulty, quiz.DifficultyLevel)
+		}
+	}
+	return scorer, nil
+}
+
+// score computes the breakdown of the next answer and advances the streak
+func (s *answerScorer) score(question *models.Question, answer *models.Answer) models.ScoreBreakdown {
+	penalty := AssistPenalty(answer.Assists)
+
+	if answer.Skipped {
+		// A skipped question earns nothing and doesn't break the streak;
+		// a skip penalty is a fraction of the question's base points
+		breakdown := models.ScoreBreakdown{
+			BasePoints:           question.Points,
+			StreakLength:         s.streak,
+			StreakMultiplier:     1,
+			DifficultyMultiplier: 1,
+			AssistPenalty:        roundPoints(float64(question.Points) * penalty),
+		}
+		breakdown.Total = -breakdown.AssistPenalty
+		return breakdown
+	}
+
+	if answer.IsCorrect {
+		s.streak++
+	} else {
+		s.streak = 0
+	}
+
+	breakdown := models.ScoreBreakdown{
+		BasePoints:           question.Points,
+		StrategyPoints:       s.ss.ScoreAnswer(s.strategy, question.Points, answer.TimeToAnswer, question.TimeLimit, answer.IsCorrect),
+		StreakLength:         s.streak,
+		StreakMultiplier:     1,
+		DifficultyMultiplier: 1,
+	}
+
+	// Modifiers and penalties only apply to points that were earned
+	points := breakdown.StrategyPoints
+	if points > 0 && s.modifiers != nil {
+		if streak := s.modifiers.Streak; streak != nil && s.streak >= streak.MinStreak {
+			multiplier := math.Min(1+streak.Step*float64(s.streak-streak.MinStreak+1), streak.MaxMultiplier)
+			breakdown.StreakMultiplier = multiplier
+			breakdown.StreakBonus = roundPoints(points * (multiplier - 1))
+		}
+		if s.modifiers.Difficulty != nil {
+			breakdown.DifficultyMultiplier = s.difficulty
+			breakdown.DifficultyBonus = roundPoints((points + breakdown.StreakBonus) * (s.difficulty - 1))
+		}
+	}
+
+	total := points + breakdown.StreakBonus + breakdown.DifficultyBonus
+	if total > 0 {
+		breakdown.AssistPenalty = roundPoints(total * penalty)
+	}
+
+	breakdown.Total = roundPoints(total - breakdown.AssistPenalty)
+	return breakdown
+}
+
+// AssistPenalty returns the combined penalty fraction of hints and lifelines, capped at 1
+func AssistPenalty(assists []models.AssistUsage) float64 {
+	penalty := 0.0
+	for _, assist := range assists {
+		penalty += assist.Penalty
+	}
+	return math.Min(penalty, 1)
+}
+
+// perfectBonus returns the bonus for answering every question correctly
+func (s *answerScorer) perfectBonus(quiz *models.Quiz) float64 {
+	if s.modifiers == nil || s.modifiers.Perfect == nil {
+		return 0
+	}
+	basePoints := 0
+	for _, q := range quiz.Questions {
+		basePoints += q.Points
+	}
+	return roundPoints(float64(basePoints) * s.modifiers.Perfect.Percent)
+}
+
+// roundPoints rounds points to 2 decimal places
+func roundPoints(points float64) float64 {
+	return math.Round(points*100) / 100
+}
@@ -2,7 +2,12 @@
 package services
 
 import (
+	"fmt"
 	"math"
+
+	"quizmasterapi/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // ScoringService handles quiz scoring logic
@@ -13,6 +18,36 @@ func NewScoringService() *ScoringService {
 	return &ScoringService{}
 }
 
+// Strategy returns the scoring strategy selected by a policy.
+// A nil policy selects the default speed decay rules.
+func (ss *ScoringService) Strategy(policy *models.ScoringPolicy) (ScoringStrategy, error) {
+	if policy == nil || policy.Strategy == "" {
+		return newSpeedDecayStrategy(nil)
+	}
+	return BuildScoringStrategy(policy.Strategy, policy.Params)
+}
+
+// ResolvePolicy validates a policy and returns it with every parameter and
+// modifier filled in, ready to be snapshotted on an attempt
+func (ss *ScoringService) ResolvePolicy(policy *models.ScoringPolicy) (*models.ScoringPolicy, error) {
+	strategy, err := ss.Strategy(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := &models.ScoringPolicy{
+		Strategy: strategy.Name(),
+		Params:   strategy.Params(),
+	}
+	if policy != nil {
+		resolved.Modifiers, err = resolveModifiers(policy.Modifiers)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resolved, nil
+}
+
 // CalculateScore calculates points based on response time
 // Rules:
 // - Answered within 5 seconds → 100% of points
@@ -20,25 +55,13 @@ func NewScoringService() *ScoringService {
 // - Answered after 10 seconds → 50% of points
 // - Maximum time is 15 seconds per question
 func (ss *ScoringService) CalculateScore(basePoints int, timeToAnswer int, isCorrect bool) float64 {
-	if !isCorrect {
-		return 0
-	}
-
-	var multiplier float64
-
-	if timeToAnswer <= 5 {
-		// Fast answer - full points
-		multiplier = 1.0
-	} else if timeToAnswer <= 10 {
-		// Medium speed - linear decay from 100% to 70%
-		// Formula: 1.0 - ((timeToAnswer - 5) * 0.06)
-		multiplier = 1.0 - ((float64(timeToAnswer-5) / 5.0) * 0.3)
-	} else {
-		// Slow answer - minimum 50% points
-		multiplier = 0.5
-	}
+	strategy, _ := newSpeedDecayStrategy(nil)
+	return ss.ScoreAnswer(strategy, basePoints, timeToAnswer, 0, isCorrect)
+}
 
-	score := float64(basePoints) * multiplier
+// ScoreAnswer calculates the points of one answer with the given strategy
+func (ss *ScoringService) ScoreAnswer(strategy ScoringStrategy, basePoints, timeToAnswer, timeLimit int, isCorrect bool) float64 {
+	score := strategy.Score(basePoints, timeToAnswer, timeLimit, isCorrect)
 	return math.Round(score*100) / 100 // Round to 2 decimal places
 }
 
@@ -55,3 +78,140 @@ func (ss *ScoringService) CalculateTotalScore(answers []struct {
 	}
 	return math.Round(totalScore*100) / 100
 }
+
+// MaxScore returns the highest score achievable on a quiz under a policy:
+// every question answered correctly and instantly, including all bonuses
+func (ss *ScoringService) MaxScore(quiz *models.Quiz, policy *models.ScoringPolicy) (float64, error) {
+	scorer, err := ss.newAnswerScorer(quiz, policy)
+	if err != nil {
+		return 0, err
+	}
+
+	maxScore := 0.0
+	for i := range quiz.Questions {
+		maxScore += scorer.score(&quiz.Questions[i], &models.Answer{IsCorrect: true}).Total
+	}
+	return roundPoints(maxScore + scorer.perfectBonus(quiz)), nil
+}
+
+// MaxQuestionScore returns the most points an answer to a question can earn
+// under a policy: answered correctly and instantly at the longest streak the
+// quiz allows, including the difficulty bonus
+func (ss *ScoringService) MaxQuestionScore(quiz *models.Quiz, policy *models.ScoringPolicy, question *models.Question) (float64, error) {
+	scorer, err := ss.newAnswerScorer(quiz, policy)
+	if err != nil {
+		return 0, err
+	}
+	scorer.streak = len(quiz.Questions) - 1
+	return scorer.score(question, &models.Answer{IsCorrect: true}).Total, nil
+}
+
+// ScoreNextAnswer computes the breakdown of a new answer, taking the streak
+// built by the attempt's previous answers into account
+func (ss *ScoringService) ScoreNextAnswer(quiz *models.Quiz, attempt *models.QuizAttempt, question *models.Question, answer *models.Answer) (models.ScoreBreakdown, error) {
+	scorer, err := ss.newAnswerScorer(quiz, attempt.Scoring)
+	if err != nil {
+		return models.ScoreBreakdown{}, err
+	}
+
+	questions := questionsByID(quiz)
+	for i := range attempt.Answers {
+		if q, ok := questions[attempt.Answers[i].QuestionID]; ok {
+			scorer.score(q, &attempt.Answers[i])
+		}
+	}
+
+	return scorer.score(question, answer), nil
+}
+
+// ScoreAttempt recomputes the score of an attempt from its stored answers,
+// using the scoring policy snapshotted on the attempt.
+// Points of each answer are recalculated from the quiz questions, except for
+// answers with a manual override. Totals, answer counts, percentage and the
+// scoring version are updated on the attempt in place. Adaptive attempts are
+// scored on the questions served to them.
+func (ss *ScoringService) ScoreAttempt(quiz *models.Quiz, attempt *models.QuizAttempt) error {
+	quiz = AttemptQuiz(quiz, attempt)
+	scorer, err := ss.newAnswerScorer(quiz, attempt.Scoring)
+	if err != nil {
+		return err
+	}
+	maxScore, err := ss.MaxScore(quiz, attempt.Scoring)
+	if err != nil {
+		return err
+	}
+
+	// The attempt's assist log is authoritative for hint and lifeline usage
+	assists := make(map[primitive.ObjectID][]models.AssistUsage)
+	for _, assist := range attempt.Assists {
+		assists[assist.QuestionID] = append(assists[assist.QuestionID], assist)
+	}
+
+	questions := questionsByID(quiz)
+	totalScore := 0.0
+	correct, incorrect := 0, 0
+	for i := range attempt.Answers {
+		answer := &attempt.Answers[i]
+		question, ok := questions[answer.QuestionID]
+		if !ok {
+			// Question was removed from the quiz; it no longer counts
+			continue
+		}
+
+		if len(attempt.Assists) > 0 {
+			answer.Assists = assists[answer.QuestionID]
+		}
+
+		breakdown := scorer.score(question, answer)
+		answer.Breakdown = &breakdown
+		if answer.Override != nil {
+			answer.PointsEarned = answer.Override.Points
+		} else {
+			answer.PointsEarned = breakdown.Total
+		}
+		totalScore += answer.PointsEarned
+
+		switch {
+		case answer.Skipped:
+			// Counted as unanswered
+		case answer.IsCorrect:
+			correct++
+		default:
+			incorrect++
+		}
+	}
+
+	attempt.PerfectBonus = 0
+	if len(quiz.Questions) > 0 && correct == len(quiz.Questions) {
+		attempt.PerfectBonus = scorer.perfectBonus(quiz)
+		totalScore += attempt.PerfectBonus
+	}
+
+	// Negative marking can push the total below zero; a quiz never scores less than nothing
+	attempt.TotalScore = math.Max(0, roundPoints(totalScore))
+	attempt.MaxScore = maxScore
+	attempt.CorrectCount = correct
+	attempt.IncorrectCount = incorrect
+	attempt.UnansweredCount = len(quiz.Questions) - correct - incorrect
+	attempt.Percentage = 0
+	if maxScore > 0 {
+		attempt.Percentage = roundPoints(attempt.TotalScore / maxScore * 100)
+	}
+	attempt.ScoringVersion = ScoringVersion(scorer.strategy)
+	return nil
+}
+
+// questionsByID indexes the questions of a quiz by ID
+func questionsByID(quiz *models.Quiz) map[primitive.ObjectID]*models.Question {
+	questions := make(map[primitive.ObjectID]*models.Question, len(quiz.Questions))
+	for i := range quiz.Questions {
+		questions[quiz.Questions[i].ID] = &quiz.Questions[i]
+	}
+	return questions
+}
+
+// ScoringVersion identifies the scoring rules stored on completed attempts,
+// so historical scores stay explainable when the rules change
+func ScoringVersion(strategy ScoringStrategy) string {
+	return fmt.Sprintf("%s-%s", strategy.Name(), strategy.Version())
+}
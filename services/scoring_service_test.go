@@ -0,0 +1,51 @@
+package services
+
+import (
+	"testing"
+
+	"quizmasterapi/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestMaxQuestionScoreCoversModifiers scores a perfect, instant attempt with
+// streak and difficulty bonuses and checks that no answer earns more than
+// MaxQuestionScore, which the last answer of the streak reaches
+func TestMaxQuestionScoreCoversModifiers(t *testing.T) {
+	quiz := &models.Quiz{DifficultyLevel: models.LevelHard}
+	for i := 0; i < 5; i++ {
+		quiz.Questions = append(quiz.Questions, models.Question{ID: primitive.NewObjectID(), Points: 10, TimeLimit: 30})
+	}
+	policy := &models.ScoringPolicy{
+		Strategy: StrategySpeedDecay,
+		Modifiers: &models.ScoringModifiers{
+			Streak:     &models.StreakBonus{MinStreak: 2, Step: 0.1, MaxMultiplier: 1.5},
+			Difficulty: &models.DifficultyWeights{Easy: 1, Medium: 1.25, Hard: 1.5},
+		},
+	}
+
+	attempt := &models.QuizAttempt{Scoring: policy}
+	for _, question := range quiz.Questions {
+		attempt.Answers = append(attempt.Answers, models.Answer{QuestionID: question.ID, IsCorrect: true})
+	}
+	ss := NewScoringService()
+	if err := ss.ScoreAttempt(quiz, attempt); err != nil {
+		t.Fatal(err)
+	}
+
+	maxPoints, err := ss.MaxQuestionScore(quiz, policy, &quiz.Questions[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if maxPoints <= float64(quiz.Questions[0].Points) {
+		t.Fatalf("max points %v, want more than the base points with bonuses", maxPoints)
+	}
+	for i, answer := range attempt.Answers {
+		if answer.PointsEarned > maxPoints {
+			t.Fatalf("answer %d earned %v, more than the max %v", i, answer.PointsEarned, maxPoints)
+		}
+	}
+	if last := attempt.Answers[len(attempt.Answers)-1].PointsEarned; last != maxPoints {
+		t.Fatalf("last answer of the streak earned %v, want the max %v", last, maxPoints)
+	}
+}
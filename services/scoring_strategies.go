@@ -0,0 +1,248 @@
+// Package services provides business logic services
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// ScoringStrategy computes the points earned for a single answer
+type ScoringStrategy interface {
+	// Name is the registry name of the strategy
+	Name() string
+	// Version changes whenever the strategy's formula changes
+	Version() string
+	// Params returns the effective parameters, including defaults
+	Params() map[string]float64
+	// Score returns the unrounded points for an answer
+	Score(basePoints, timeToAnswer, timeLimit int, isCorrect bool) float64
+}
+
+// ScoringStrategyFactory builds a strategy from its parameters, applying defaults
+type ScoringStrategyFactory func(params map[string]float64) (ScoringStrategy, error)
+
+// Names of the built-in scoring strategies
+const (
+	StrategyAccuracy        = "accuracy"
+	StrategySpeedDecay      = "speed_decay"
+	StrategyExponential     = "exponential"
+	StrategyNegativeMarking = "negative_marking"
+)
+
+var (
+	scoringRegistryMu sync.RWMutex
+	scoringRegistry   = map[string]ScoringStrategyFactory{
+		StrategyAccuracy:        newAccuracyStrategy,
+		StrategySpeedDecay:      newSpeedDecayStrategy,
+		StrategyExponential:     newExponentialStrategy,
+		StrategyNegativeMarking: newNegativeMarkingStrategy,
+	}
+)
+
+// RegisterScoringStrategy adds or replaces a named scoring strategy
+func RegisterScoringStrategy(name string, factory ScoringStrategyFactory) {
+	scoringRegistryMu.Lock()
+	defer scoringRegistryMu.Unlock()
+	scoringRegistry[name] = factory
+}
+
+// ScoringStrategyNames returns the registered strategy names in sorted order
+func ScoringStrategyNames() []string {
+	scoringRegistryMu.RLock()
+	defer scoringRegistryMu.RUnlock()
+
+	names := make([]string, 0, len(scoringRegistry))
+	for name := range scoringRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BuildScoringStrategy builds a registered strategy with the given parameters
+func BuildScoringStrategy(name string, params map[string]float64) (ScoringStrategy, error) {
+	scoringRegistryMu.RLock()
+	factory, ok := scoringRegistry[name]
+	scoringRegistryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown scoring strategy %q", name)
+	}
+	return factory(params)
+}
+
+// resolveParams merges params over defaults, rejecting unknown keys
+func resolveParams(strategy string, defaults, params map[string]float64) (map[string]float64, error) {
+	resolved := make(map[string]float64, len(defaults))
+	for key, value := range defaults {
+		resolved[key] = value
+	}
+	for key, value := range params {
+		if _, ok := defaults[key]; !ok {
+			return nil, fmt.Errorf("unknown parameter %q for scoring strategy %q", key, strategy)
+		}
+		resolved[key] = value
+	}
+	return resolved, nil
+}
+
+// requireFraction checks that a parameter lies within [0, 1]
+func requireFraction(strategy string, params map[string]float64, key string) error {
+	if v := params[key]; v < 0 || v > 1 {
+		return fmt.Errorf("parameter %q of scoring strategy %q must be between 0 and 1", key, strategy)
+	}
+	return nil
+}
+
+// accuracyStrategy awards full points for every correct answer, regardless of time
+type accuracyStrategy struct{}
+
+func newAccuracyStrategy(params map[string]float64) (ScoringStrategy, error) {
+	if _, err := resolveParams(StrategyAccuracy, nil, params); err != nil {
+		return nil, err
+	}
+	return accuracyStrategy{}, nil
+}
+
+func (accuracyStrategy) Name() string               { return StrategyAccuracy }
+func (accuracyStrategy) Version() string            { return "v1" }
+func (accuracyStrategy) Params() map[string]float64 { return map[string]float64{} }
+
+func (accuracyStrategy) Score(basePoints, timeToAnswer, timeLimit int, isCorrect bool) float64 {
+	if !isCorrect {
+		return 0
+	}
+	return float64(basePoints)
+}
+
+// speedDecayStrategy awards full points up to full_until seconds, decays linearly
+// to decay_floor at decay_until seconds, then awards slow_multiplier
+type speedDecayStrategy struct {
+	params map[string]float64
+}
+
+var speedDecayDefaults = map[string]float64{
+	"full_until":      5,
+	"decay_until":     10,
+	"decay_floor":     0.7,
+	"slow_multiplier": 0.5,
+}
+
+func newSpeedDecayStrategy(params map[string]float64) (ScoringStrategy, error) {
+	resolved, err := resolveParams(StrategySpeedDecay, speedDecayDefaults, params)
+	if err != nil {
+		return nil, err
+	}
+	if resolved["full_until"] < 0 || resolved["decay_until"] < resolved["full_until"] {
+		return nil, fmt.Errorf("scoring strategy %q requires 0 <= full_until <= decay_until", StrategySpeedDecay)
+	}
+	for _, key := range []string{"decay_floor", "slow_multiplier"} {
+		if err := requireFraction(StrategySpeedDecay, resolved, key); err != nil {
+			return nil, err
+		}
+	}
+	return speedDecayStrategy{params: resolved}, nil
+}
+
+func (s speedDecayStrategy) Name() string               { return StrategySpeedDecay }
+func (s speedDecayStrategy) Version() string            { return "v1" }
+func (s speedDecayStrategy) Params() map[string]float64 { return s.params }
+
+func (s speedDecayStrategy) Score(basePoints, timeToAnswer, timeLimit int, isCorrect bool) float64 {
+	if !isCorrect {
+		return 0
+	}
+
+	fullUntil := s.params["full_until"]
+	decayUntil := s.params["decay_until"]
+	t := float64(timeToAnswer)
+
+	var multiplier float64
+	switch {
+	case t <= fullUntil:
+		// Fast answer - full points
+		multiplier = 1.0
+	case t <= decayUntil:
+		// Medium speed - linear decay from 100% to decay_floor
+		multiplier = 1.0 - ((t-fullUntil)/(decayUntil-fullUntil))*(1.0-s.params["decay_floor"])
+	default:
+		// Slow answer - flat minimum
+		multiplier = s.params["slow_multiplier"]
+	}
+
+	return float64(basePoints) * multiplier
+}
+
+// exponentialStrategy halves the points every half_life seconds after a grace
+// period, never going below min_multiplier
+type exponentialStrategy struct {
+	params map[string]float64
+}
+
+var exponentialDefaults = map[string]float64{
+	"grace":          2,
+	"half_life":      5,
+	"min_multiplier": 0.25,
+}
+
+func newExponentialStrategy(params map[string]float64) (ScoringStrategy, error) {
+	resolved, err := resolveParams(StrategyExponential, exponentialDefaults, params)
+	if err != nil {
+		return nil, err
+	}
+	if resolved["grace"] < 0 || resolved["half_life"] <= 0 {
+		return nil, fmt.Errorf("scoring strategy %q requires grace >= 0 and half_life > 0", StrategyExponential)
+	}
+	if err := requireFraction(StrategyExponential, resolved, "min_multiplier"); err != nil {
+		return nil, err
+	}
+	return exponentialStrategy{params: resolved}, nil
+}
+
+func (s exponentialStrategy) Name() string               { return StrategyExponential }
+func (s exponentialStrategy) Version() string            { return "v1" }
+func (s exponentialStrategy) Params() map[string]float64 { return s.params }
+
+func (s exponentialStrategy) Score(basePoints, timeToAnswer, timeLimit int, isCorrect bool) float64 {
+	if !isCorrect {
+		return 0
+	}
+
+	elapsed := math.Max(0, float64(timeToAnswer)-s.params["grace"])
+	multiplier := math.Pow(0.5, elapsed/s.params["half_life"])
+	return float64(basePoints) * math.Max(multiplier, s.params["min_multiplier"])
+}
+
+// negativeMarkingStrategy awards full points for correct answers and deducts
+// penalty times the base points for wrong answers
+type negativeMarkingStrategy struct {
+	params map[string]float64
+}
+
+var negativeMarkingDefaults = map[string]float64{
+	"penalty": 0.25,
+}
+
+func newNegativeMarkingStrategy(params map[string]float64) (ScoringStrategy, error) {
+	resolved, err := resolveParams(StrategyNegativeMarking, negativeMarkingDefaults, params)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireFraction(StrategyNegativeMarking, resolved, "penalty"); err != nil {
+		return nil, err
+	}
+	return negativeMarkingStrategy{params: resolved}, nil
+}
+
+func (s negativeMarkingStrategy) Name() string               { return StrategyNegativeMarking }
+func (s negativeMarkingStrategy) Version() string            { return "v1" }
+func (s negativeMarkingStrategy) Params() map[string]float64 { return s.params }
+
+func (s negativeMarkingStrategy) Score(basePoints, timeToAnswer, timeLimit int, isCorrect bool) float64 {
+	if !isCorrect {
+		return -float64(basePoints) * s.params["penalty"]
+	}
+	return float64(basePoints)
+}
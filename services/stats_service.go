@@ -0,0 +1,428 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"quizmasterapi/config"
+	"quizmasterapi/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// DefaultTrendWeeks is the number of weeks covered by the trend unless asked otherwise
+	DefaultTrendWeeks = 12
+	// MaxTrendWeeks bounds the trend window
+	MaxTrendWeeks = 52
+	// A topic needs this many answers before it can be reported as weak
+	minTopicAnswers = 5
+	// Number of weakest topics reported
+	weakestTopicCount = 3
+)
+
+// StatsService computes a student's performance analytics from their completed attempts
+type StatsService struct {
+	attemptCollection *mongo.Collection
+}
+
+// StatsSummary aggregates a set of completed attempts and their answers
+type StatsSummary struct {
+	Attempts            int     `json:"attempts"`
+	AveragePercentage   float64 `json:"average_percentage"`
+	Answers             int     `json:"answers"`
+	Correct             int     `json:"correct"`
+	Accuracy            float64 `json:"accuracy"`              // Percentage of answers that were correct
+	AverageResponseTime float64 `json:"average_response_time"` // In seconds, skipped questions excluded
+}
+
+// TrendPoint is the performance of one week, weeks start on Monday in the student's timezone
+type TrendPoint struct {
+	WeekStart         string  `json:"week_start"`
+	Attempts          int     `json:"attempts"`
+	AveragePercentage float64 `json:"average_percentage"`
+}
+
+// CategoryStats is the performance in one quiz category
+type CategoryStats struct {
+	Category models.QuizCategory `json:"category"`
+	StatsSummary
+	Trend []TrendPoint `json:"trend"`
+}
+
+// DifficultyStats is the performance at one difficulty level
+type DifficultyStats struct {
+	DifficultyLevel models.DifficultyLevel `json:"difficulty_level"`
+	StatsSummary
+}
+
+// QuestionTypeStats is the accuracy on one type of question
+type QuestionTypeStats struct {
+	Type                models.QuestionType `json:"type"`
+	Answers             int                 `json:"answers"`
+	Correct             int                 `json:"correct"`
+	Accuracy            float64             `json:"accuracy"`
+	AverageResponseTime float64             `json:"average_response_time"`
+}
+
+// TopicStats is the accuracy on a category at a difficulty level
+type TopicStats struct {
+	Category        models.QuizCategory    `json:"category"`
+	DifficultyLevel models.DifficultyLevel `json:"difficulty_level"`
+	Answers         int                    `json:"answers"`
+	Accuracy        float64                `json:"accuracy"`
+}
+
+// StudentStats is a student's progress dashboard
+type StudentStats struct {
+	Timezone      string              `json:"timezone"`
+	TrendWeeks    int                 `json:"trend_weeks"`
+	Overall       StatsSummary        `json:"overall"`
+	Trend         []TrendPoint        `json:"trend"`
+	Categories    []CategoryStats     `json:"categories"`
+	Difficulties  []DifficultyStats   `json:"difficulties"`
+	QuestionTypes []QuestionTypeStats `json:"question_types"`
+	WeakestTopics []TopicStats        `json:"weakest_topics"` // Lowest accuracy first
+}
+
+// NewStatsService creates a new stats service
+func NewStatsService() *StatsService {
+	return &StatsService{
+		attemptCollection: config.GetCollection("attempts"),
+	}
+}
+
+// statsGroup is one row of the aggregation: a category, difficulty and
+// question type or week, with the sums needed to roll rows up
+type statsGroup struct {
+	Key struct {
+		Category   models.QuizCategory    `bson:"category"`
+		Difficulty models.DifficultyLevel `bson:"difficulty_level"`
+		Type       models.QuestionType    `bson:"type"`
+		Week       string                 `bson:"week"`
+	} `bson:"_id"`
+	Attempts      int     `bson:"attempts"`
+	PercentageSum float64 `bson:"percentage_sum"`
+	Answers       int     `bson:"answers"`
+	Correct       int     `bson:"correct"`
+	Timed         int     `bson:"timed"`
+	TimeSum       float64 `bson:"time_sum"`
+}
+
+type statsFacets struct {
+	Attempts []statsGroup `bson:"attempts"`
+	Answers  []statsGroup `bson:"answers"`
+	Trend    []statsGroup `bson:"trend"`
+}
+
+// StudentStats computes the dashboard of a student over their counted
+// attempts. Trend weeks are computed in the given timezone
+func (ss *StatsService) StudentStats(ctx context.Context, studentID primitive.ObjectID, timezone string, weeks int, now time.Time) (*StudentStats, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc, timezone = time.UTC, DefaultTimezone
+	}
+	trendStart := startOfWeek(now.In(loc)).AddDate(0, 0, -7*(weeks-1))
+
+	facets, err := ss.aggregate(ctx, studentID, timezone, trendStart)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildStudentStats(facets, timezone, weeks), nil
+}
+
+// aggregate groups the student's attempts and answers by category and
+// difficulty in one pass. The match is served by the attempts
+// {student_id, completed_at} index and quizzes are looked up by _id
+func (ss *StatsService) aggregate(ctx context.Context, studentID primitive.ObjectID, timezone string, trendStart time.Time) (*statsFacets, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"student_id":     studentID,
+			"completed_at":   bson.M{"$ne": nil},
+			"invalidated_at": bson.M{"$exists": false},
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "quizzes",
+			"localField":   "quiz_id",
+			"foreignField": "_id",
+			"pipeline": bson.A{
+				bson.M{"$project": bson.M{"category": 1, "difficulty_level": 1, "questions._id": 1, "questions.type": 1}},
+			},
+			"as": "quiz",
+		}}},
+		{{Key: "$unwind", Value: "$quiz"}},
+		{{Key: "$project", Value: bson.M{
+			"category":         "$quiz.category",
+			"difficulty_level": "$quiz.difficulty_level",
+			"percentage":       1,
+			"completed_at":     1,
+			"answers": bson.M{"$map": bson.M{
+				"input": bson.M{"$ifNull": bson.A{"$answers", bson.A{}}},
+				"as":    "answer",
+				"in": bson.M{
+					"is_correct":     "$$answer.is_correct",
+					"skipped":        bson.M{"$ifNull": bson.A{"$$answer.skipped", false}},
+					"time_to_answer": "$$answer.time_to_answer",
+					"type": bson.M{"$let": bson.M{
+						"vars": bson.M{"question": bson.M{"$arrayElemAt": bson.A{
+							bson.M{"$filter": bson.M{
+								"input": "$quiz.questions",
+								"cond":  bson.M{"$eq": bson.A{"$$this._id", "$$answer.question_id"}},
+							}},
+							0,
+						}}},
+						"in": "$$question.type",
+					}},
+				},
+			}},
+		}}},
+		{{Key: "$facet", Value: bson.M{
+			"attempts": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":            bson.M{"category": "$category", "difficulty_level": "$difficulty_level"},
+					"attempts":       bson.M{"$sum": 1},
+					"percentage_sum": bson.M{"$sum": "$percentage"},
+				}},
+			},
+			"answers": bson.A{
+				bson.M{"$unwind": "$answers"},
+				bson.M{"$group": bson.M{
+					"_id":     bson.M{"category": "$category", "difficulty_level": "$difficulty_level", "type": "$answers.type"},
+					"answers": bson.M{"$sum": 1},
+					"correct": bson.M{"$sum": bson.M{"$cond": bson.A{"$answers.is_correct", 1, 0}}},
+					"timed":   bson.M{"$sum": bson.M{"$cond": bson.A{"$answers.skipped", 0, 1}}},
+					"time_sum": bson.M{"$sum": bson.M{"$cond": bson.A{
+						"$answers.skipped", 0, "$answers.time_to_answer",
+					}}},
+				}},
+			},
+			"trend": bson.A{
+				bson.M{"$match": bson.M{"completed_at": bson.M{"$gte": trendStart}}},
+				bson.M{"$group": bson.M{
+					"_id": bson.M{
+						"category": "$category",
+						"week": bson.M{"$dateToString": bson.M{
+							"date": bson.M{"$dateTrunc": bson.M{
+								"date":        "$completed_at",
+								"unit":        "week",
+								"timezone":    timezone,
+								"startOfWeek": "monday",
+							}},
+							"format":   "%Y-%m-%d",
+							"timezone": timezone,
+						}},
+					},
+					"attempts":       bson.M{"$sum": 1},
+					"percentage_sum": bson.M{"$sum": "$percentage"},
+				}},
+			},
+		}}},
+	}
+
+	cursor, err := ss.attemptCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate stats: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var facets statsFacets
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&facets); err != nil {
+			return nil, fmt.Errorf("failed to decode stats: %w", err)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to aggregate stats: %w", err)
+	}
+	return &facets, nil
+}
+
+// statsTotals accumulates aggregation rows before averages are computed
+type statsTotals struct {
+	attempts      int
+	percentageSum float64
+	answers       int
+	correct       int
+	timed         int
+	timeSum       float64
+}
+
+func (t *statsTotals) add(group statsGroup) {
+	t.attempts += group.Attempts
+	t.percentageSum += group.PercentageSum
+	t.answers += group.Answers
+	t.correct += group.Correct
+	t.timed += group.Timed
+	t.timeSum += group.TimeSum
+}
+
+func (t *statsTotals) summary() StatsSummary {
+	return StatsSummary{
+		Attempts:            t.attempts,
+		AveragePercentage:   ratio(t.percentageSum, t.attempts, 1),
+		Answers:             t.answers,
+		Correct:             t.correct,
+		Accuracy:            ratio(float64(t.correct), t.answers, 100),
+		AverageResponseTime: ratio(t.timeSum, t.timed, 1),
+	}
+}
+
+// ratio returns sum / count scaled and rounded to 2 decimals, 0 without data
+func ratio(sum float64, count int, scale float64) float64 {
+	if count == 0 {
+		return 0
+	}
+	return math.Round(sum/float64(count)*scale*100) / 100
+}
+
+// buildStudentStats rolls the aggregation rows up into the dashboard
+func buildStudentStats(facets *statsFacets, timezone string, weeks int) *StudentStats {
+	var overall statsTotals
+	categories := map[models.QuizCategory]*statsTotals{}
+	difficulties := map[models.DifficultyLevel]*statsTotals{}
+	types := map[models.QuestionType]*statsTotals{}
+	topics := map[[2]string]*statsTotals{}
+
+	categoryTotals := func(category models.QuizCategory) *statsTotals {
+		if categories[category] == nil {
+			categories[category] = &statsTotals{}
+		}
+		return categories[category]
+	}
+	difficultyTotals := func(difficulty models.DifficultyLevel) *statsTotals {
+		if difficulties[difficulty] == nil {
+			difficulties[difficulty] = &statsTotals{}
+		}
+		return difficulties[difficulty]
+	}
+
+	for _, group := range facets.Attempts {
+		overall.add(group)
+		categoryTotals(group.Key.Category).add(group)
+		difficultyTotals(group.Key.Difficulty).add(group)
+	}
+	for _, group := range facets.Answers {
+		overall.add(group)
+		categoryTotals(group.Key.Category).add(group)
+		difficultyTotals(group.Key.Difficulty).add(group)
+		if group.Key.Type != "" {
+			if types[group.Key.Type] == nil {
+				types[group.Key.Type] = &statsTotals{}
+			}
+			types[group.Key.Type].add(group)
+		}
+		topic := [2]string{string(group.Key.Category), string(group.Key.Difficulty)}
+		if topics[topic] == nil {
+			topics[topic] = &statsTotals{}
+		}
+		topics[topic].add(group)
+	}
+
+	// Weekly trend, overall and per category
+	weekly := map[string]*statsTotals{}
+	categoryTrends := map[models.QuizCategory][]TrendPoint{}
+	for _, group := range facets.Trend {
+		if weekly[group.Key.Week] == nil {
+			weekly[group.Key.Week] = &statsTotals{}
+		}
+		weekly[group.Key.Week].add(group)
+		categoryTrends[group.Key.Category] = append(categoryTrends[group.Key.Category], trendPoint(group.Key.Week, group.Attempts, group.PercentageSum))
+	}
+
+	stats := &StudentStats{
+		Timezone:      timezone,
+		TrendWeeks:    weeks,
+		Overall:       overall.summary(),
+		Trend:         []TrendPoint{},
+		Categories:    []CategoryStats{},
+		Difficulties:  []DifficultyStats{},
+		QuestionTypes: []QuestionTypeStats{},
+		WeakestTopics: []TopicStats{},
+	}
+
+	for week, t := range weekly {
+		stats.Trend = append(stats.Trend, trendPoint(week, t.attempts, t.percentageSum))
+	}
+	sortTrend(stats.Trend)
+
+	for category, t := range categories {
+		trend := categoryTrends[category]
+		if trend == nil {
+			trend = []TrendPoint{}
+		}
+		sortTrend(trend)
+		stats.Categories = append(stats.Categories, CategoryStats{Category: category, StatsSummary: t.summary(), Trend: trend})
+	}
+	sort.Slice(stats.Categories, func(i, j int) bool {
+		return stats.Categories[i].Category < stats.Categories[j].Category
+	})
+
+	for _, difficulty := range []models.DifficultyLevel{models.LevelEasy, models.LevelMedium, models.LevelHard} {
+		if t, ok := difficulties[difficulty]; ok {
+			stats.Difficulties = append(stats.Difficulties, DifficultyStats{DifficultyLevel: difficulty, StatsSummary: t.summary()})
+		}
+	}
+
+	for questionType, t := range types {
+		summary := t.summary()
+		stats.QuestionTypes = append(stats.QuestionTypes, QuestionTypeStats{
+			Type:                questionType,
+			Answers:             summary.Answers,
+			Correct:             summary.Correct,
+			Accuracy:            summary.Accuracy,
+			AverageResponseTime: summary.AverageResponseTime,
+		})
+	}
+	sort.Slice(stats.QuestionTypes, func(i, j int) bool {
+		return stats.QuestionTypes[i].Type < stats.QuestionTypes[j].Type
+	})
+
+	for topic, t := range topics {
+		summary := t.summary()
+		if summary.Answers < minTopicAnswers || summary.Accuracy >= 100 {
+			continue
+		}
+		stats.WeakestTopics = append(stats.WeakestTopics, TopicStats{
+			Category:        models.QuizCategory(topic[0]),
+			DifficultyLevel: models.DifficultyLevel(topic[1]),
+			Answers:         summary.Answers,
+			Accuracy:        summary.Accuracy,
+		})
+	}
+	sort.Slice(stats.WeakestTopics, func(i, j int) bool {
+		a, b := stats.WeakestTopics[i], stats.WeakestTopics[j]
+		if a.Accuracy != b.Accuracy {
+			return a.Accuracy < b.Accuracy
+		}
+		if a.Answers != b.Answers {
+			return a.Answers > b.Answers
+		}
+		return a.Category < b.Category || (a.Category == b.Category && a.DifficultyLevel < b.DifficultyLevel)
+	})
+	if len(stats.WeakestTopics) > weakestTopicCount {
+		stats.WeakestTopics = stats.WeakestTopics[:weakestTopicCount]
+	}
+
+	return stats
+}
+
+func trendPoint(week string, attempts int, percentageSum float64) TrendPoint {
+	return TrendPoint{WeekStart: week, Attempts: attempts, AveragePercentage: ratio(percentageSum, attempts, 1)}
+}
+
+// sortTrend orders trend points from the oldest week
+func sortTrend(trend []TrendPoint) {
+	sort.Slice(trend, func(i, j int) bool { return trend[i].WeekStart < trend[j].WeekStart })
+}
+
+// startOfWeek returns midnight of the Monday of t's week, in t's location
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+}